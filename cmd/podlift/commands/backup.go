@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ekinertac/podlift/internal/backup"
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	backupDir  string
+	backupKeep int
+	restoreYes bool
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup DEPENDENCY",
+	Short: "Back up a dependency to a local directory",
+	Long: `Streams a backup of a dependency over SSH into a local directory.
+
+The strategy is picked from the image: pg_dump for postgres, mysqldump for
+mysql and mariadb, an RDB snapshot for redis. Other dependencies with a volume
+are archived as a tarball while the container is stopped. Set backup: on the
+dependency to choose a strategy.
+
+Each backup gets a .sha256 checksum file. Only the newest --keep backups
+of a dependency are kept.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBackup,
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore DEPENDENCY FILE",
+	Short: "Restore a dependency from a backup file",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runRestore,
+}
+
+func init() {
+	backupCmd.Flags().StringVar(&backupDir, "dir", "backups", "Backup directory (relative to podlift.yml)")
+	backupCmd.Flags().IntVar(&backupKeep, "keep", 7, "Number of backups to keep per dependency (0 keeps all)")
+	restoreCmd.Flags().BoolVarP(&restoreYes, "yes", "y", false, "Don't ask for confirmation")
+	rootCmd.AddCommand(backupCmd, restoreCmd)
+}
+
+// backupTarget resolves the target, strategy and server of a dependency
+func backupTarget(cfg *config.Config, name string) (backup.Target, backup.Strategy, *config.Server, error) {
+	target, err := backup.NewTarget(cfg, name)
+	if err != nil {
+		return target, nil, nil, err
+	}
+	strategy, err := backup.Detect(target, cfg.Dependencies[name].Backup)
+	if err != nil {
+		return target, nil, nil, err
+	}
+	server, _, err := cfg.GetDependencyServer(cfg.Dependencies[name])
+	if err != nil {
+		return target, nil, nil, fmt.Errorf("dependency '%s': %w", name, err)
+	}
+	return target, strategy, server, nil
+}
+
+func runBackup(cmd *cobra.Command, args []string) error {
+	cfg := loadedConfig
+	target, strategy, server, err := backupTarget(cfg, args[0])
+	if err != nil {
+		return err
+	}
+
+	dir := backupDir
+	if !filepath.IsAbs(dir) {
+		configPath, err := resolveConfigPath()
+		if err != nil {
+			return err
+		}
+		dir = filepath.Join(filepath.Dir(configPath), dir)
+	}
+
+	client, err := connectWithTimeout(*server, 30*time.Second)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	fmt.Println(ui.Info(fmt.Sprintf("Backing up %s on %s (%s)...", target.Name, server.Host, strategy.Name())))
+	start := time.Now()
+	path, err := backup.Run(client, strategy, target, dir, start)
+	if err != nil {
+		return err
+	}
+
+	info, _ := os.Stat(path)
+	fmt.Println(ui.Success(fmt.Sprintf("Saved %s (%.1fMB in %s)", path, float64(info.Size())/1024/1024, time.Since(start).Round(time.Second))))
+
+	removed, err := backup.Prune(dir, target, strategy, backupKeep)
+	if err != nil {
+		return err
+	}
+	if len(removed) > 0 {
+		fmt.Println(ui.Info(fmt.Sprintf("Removed %d old backup(s)", len(removed))))
+	}
+	return nil
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	cfg := loadedConfig
+	target, strategy, server, err := backupTarget(cfg, args[0])
+	if err != nil {
+		return err
+	}
+	path := args[1]
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("backup file: %w", err)
+	}
+
+	if err := backup.Verify(path); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		fmt.Println(ui.Warning(fmt.Sprintf("No checksum file for %s, can't verify it", filepath.Base(path))))
+	} else {
+		fmt.Println(ui.Success("Checksum verified"))
+	}
+
+	if !restoreYes {
+		fmt.Println(ui.Warning(fmt.Sprintf("This replaces the data of %s on %s with %s", target.Name, server.Host, filepath.Base(path))))
+		if !confirm() {
+			fmt.Println(ui.Info("Aborted"))
+			return nil
+		}
+	}
+
+	client, err := connectWithTimeout(*server, 30*time.Second)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	fmt.Println(ui.Info(fmt.Sprintf("Restoring %s (%s)...", target.Name, strategy.Name())))
+	if err := backup.Restore(client, strategy, target, path); err != nil {
+		return err
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("%s restored from %s", target.Name, filepath.Base(path))))
+	return nil
+}
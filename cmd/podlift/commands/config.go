@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/ekinertac/podlift/internal/config"
 	"github.com/ekinertac/podlift/internal/ui"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
@@ -17,60 +16,94 @@ var (
 var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "Show current configuration",
-	Long:  `Display the current podlift configuration with environment variables resolved.`,
-	RunE:  runConfig,
+	Long: `Display the current podlift configuration with environment variables resolved.
+
+Values of env and exports, which often come from .env or secrets.enc, and of
+keys like password or token are masked unless --show-secrets is given.`,
+	RunE: runConfig,
 }
 
 func init() {
-	configCmd.Flags().BoolVar(&configShowSecrets, "show-secrets", false, "Include environment variable values")
+	configCmd.Flags().BoolVar(&configShowSecrets, "show-secrets", false, "Show env, exports and other secret values unmasked")
 	rootCmd.AddCommand(configCmd)
 }
 
 func runConfig(cmd *cobra.Command, args []string) error {
-	// Load config
-	cfg, err := config.Load("podlift.yml")
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
-	}
+	cfg := loadedConfig
 
 	fmt.Println(ui.Title("Current Configuration"))
 	fmt.Println()
 
-	// Convert config to YAML
-	data, err := yaml.Marshal(cfg)
-	if err != nil {
+	var doc yaml.Node
+	if err := doc.Encode(cfg); err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	configStr := string(data)
-
 	// Mask secrets if not showing them
 	if !configShowSecrets {
-		configStr = maskSecrets(configStr)
+		maskSecrets(&doc)
 	}
 
-	fmt.Println(configStr)
+	data, err := yaml.Marshal(&doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	fmt.Println(string(data))
 
 	return nil
 }
 
-func maskSecrets(yamlStr string) string {
-	lines := strings.Split(yamlStr, "\n")
-	secretKeys := []string{"password", "secret", "key", "token", "credentials"}
-	
-	for i, line := range lines {
-		lower := strings.ToLower(line)
-		for _, key := range secretKeys {
-			if strings.Contains(lower, key+":") && !strings.Contains(line, "${") {
-				// Mask the value
-				parts := strings.SplitN(line, ":", 2)
-				if len(parts) == 2 {
-					lines[i] = parts[0] + ": ********"
-				}
+// secretKeySuffixes are key names whose values are masked wherever they appear
+var secretKeySuffixes = []string{"password", "secret", "key", "token", "credentials"}
+
+// maskedValue replaces secret values in the output
+const maskedValue = "********"
+
+// maskSecrets masks every value under env and exports, since any of them may be
+// substituted from .env or secrets.enc (e.g. DATABASE_URL), and the values of
+// keys named like a secret
+func maskSecrets(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			maskSecrets(child)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := strings.ToLower(node.Content[i].Value), node.Content[i+1]
+			switch {
+			case key == "env" || key == "exports":
+				maskValues(value)
+			case isSecretKey(key) && value.Kind == yaml.ScalarNode:
+				maskValues(value)
+			default:
+				maskSecrets(value)
 			}
 		}
 	}
-	
-	return strings.Join(lines, "\n")
 }
 
+// maskValues masks all scalar values in a node, keeping map keys
+func maskValues(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		node.Value, node.Tag, node.Style = maskedValue, "!!str", 0
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			maskValues(child)
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			maskValues(node.Content[i])
+		}
+	}
+}
+
+func isSecretKey(key string) bool {
+	for _, suffix := range secretKeySuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
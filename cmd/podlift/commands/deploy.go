@@ -3,7 +3,6 @@ package commands
 import (
 	"fmt"
 
-	"github.com/ekinertac/podlift/internal/config"
 	"github.com/ekinertac/podlift/internal/deploy"
 	"github.com/ekinertac/podlift/internal/git"
 	"github.com/ekinertac/podlift/internal/ui"
@@ -16,6 +15,9 @@ var (
 	deployParallel     bool
 	deployDryRun       bool
 	deployZeroDowntime bool
+	deployImageTag     string
+
+	deployIgnoreDependencyHealth bool
 )
 
 func init() {
@@ -25,6 +27,8 @@ func init() {
 	deployCommand.Flags().BoolVar(&deployParallel, "parallel", false, "Deploy to all servers in parallel")
 	deployCommand.Flags().BoolVar(&deployDryRun, "dry-run", false, "Show what would happen without executing")
 	deployCommand.Flags().BoolVar(&deployZeroDowntime, "zero-downtime", true, "Use zero-downtime deployment with nginx (default: true)")
+	deployCommand.Flags().StringVar(&deployImageTag, "image-tag", "", "Deploy a prebuilt image tag from the registry (skips build)")
+	deployCommand.Flags().BoolVar(&deployIgnoreDependencyHealth, "ignore-dependency-health", false, "Deploy even if a dependency fails its health check")
 }
 
 var deployCommand = &cobra.Command{
@@ -35,30 +39,13 @@ var deployCommand = &cobra.Command{
 }
 
 func runDeploy(cmd *cobra.Command, args []string) error {
-	// Load configuration
-	configPath, err := config.Find()
-	if err != nil {
-		fmt.Println(ui.Error("podlift.yml not found"))
-		fmt.Println()
-		fmt.Println("Run: podlift init")
-		return err
-	}
-
-	cfg, err := config.Load(configPath)
-	if err != nil {
-		fmt.Println(ui.Error("Configuration invalid"))
-		fmt.Println()
-		fmt.Println(err.Error())
-		return err
-	}
+	cfg := loadedConfig
 
-	// Load environment variables
-	if err := cfg.SubstituteConfigEnvVars(); err != nil {
-		return err
-	}
+	// Prebuilt images don't depend on the local working tree
+	prebuilt := deploy.UsesPrebuiltImage(cfg, deployImageTag)
 
 	// Check git state (unless dry run)
-	if !deployDryRun {
+	if !deployDryRun && !prebuilt {
 		if git.IsRepository() {
 			if err := git.RequireCleanState(); err != nil {
 				fmt.Println(ui.Error("Git working tree is dirty"))
@@ -96,6 +83,9 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		Parallel:     deployParallel,
 		DryRun:       deployDryRun,
 		ZeroDowntime: useZeroDowntime,
+		ImageTag:     deployImageTag,
+
+		IgnoreDependencyHealth: deployIgnoreDependencyHealth,
 	}
 
 	if err := deploy.Deploy(deployOpts); err != nil {
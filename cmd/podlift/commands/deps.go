@@ -0,0 +1,291 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/deploy"
+	"github.com/ekinertac/podlift/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	depsDownYes      bool
+	depsUpgradeYes   bool
+	depsUpgradeForce bool
+)
+
+var depsCmd = &cobra.Command{
+	Use:   "deps",
+	Short: "Manage dependencies (databases, caches, ...)",
+	Long: `Start, stop and inspect dependencies without deploying the app.
+
+Each dependency runs only on the server it's placed on (host, role or labels,
+default: the primary server). Commands act on all dependencies, or on the
+ones named as arguments.`,
+}
+
+var depsUpCmd = &cobra.Command{
+	Use:   "up [NAME...]",
+	Short: "Start dependencies that aren't running",
+	RunE:  runDepsUp,
+}
+
+var depsDownCmd = &cobra.Command{
+	Use:   "down [NAME...]",
+	Short: "Stop and remove dependency containers (volumes are kept)",
+	RunE:  runDepsDown,
+}
+
+var depsUpgradeCmd = &cobra.Command{
+	Use:   "upgrade NAME",
+	Short: "Recreate a dependency with its current config (image, env, volume, ...)",
+	Long: `Replaces a dependency container whose config changed, e.g. a new image tag.
+
+The new image is pulled first, then the old container is stopped and the new
+one started on the same named volume. If it doesn't become healthy, the old
+container is restored.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDepsUpgrade,
+}
+
+var depsStatusCmd = &cobra.Command{
+	Use:   "status [NAME...]",
+	Short: "Show where dependencies run and their state",
+	RunE:  runDepsStatus,
+}
+
+func init() {
+	depsDownCmd.Flags().BoolVarP(&depsDownYes, "yes", "y", false, "Don't ask for confirmation")
+	depsUpgradeCmd.Flags().BoolVarP(&depsUpgradeYes, "yes", "y", false, "Don't ask for confirmation")
+	depsUpgradeCmd.Flags().BoolVar(&depsUpgradeForce, "force", false, "Recreate the container even if its config didn't change")
+	depsCmd.AddCommand(depsUpCmd, depsDownCmd, depsUpgradeCmd, depsStatusCmd)
+	rootCmd.AddCommand(depsCmd)
+}
+
+// dependencyGroup is the dependencies placed on one server
+type dependencyGroup struct {
+	Server config.Server
+	Names  []string
+}
+
+// groupDependencies resolves the server of each named dependency (all if none are named)
+func groupDependencies(cfg *config.Config, names []string) ([]dependencyGroup, error) {
+	if len(names) == 0 {
+		names = cfg.DependencyOrder()
+	}
+
+	var groups []dependencyGroup
+	index := make(map[string]int)
+	for _, name := range names {
+		dep, ok := cfg.Dependencies[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown dependency '%s'", name)
+		}
+		server, _, err := cfg.GetDependencyServer(dep)
+		if err != nil {
+			return nil, fmt.Errorf("dependency '%s': %w", name, err)
+		}
+
+		i, ok := index[server.Host]
+		if !ok {
+			i = len(groups)
+			index[server.Host] = i
+			groups = append(groups, dependencyGroup{Server: *server})
+		}
+		groups[i].Names = append(groups[i].Names, name)
+	}
+	return groups, nil
+}
+
+func runDepsUp(cmd *cobra.Command, args []string) error {
+	cfg := loadedConfig
+	groups, err := groupDependencies(cfg, args)
+	if err != nil {
+		return err
+	}
+	if len(groups) == 0 {
+		fmt.Println(ui.Info("No dependencies configured"))
+		return nil
+	}
+
+	for _, group := range groups {
+		fmt.Println(ui.Title(fmt.Sprintf("Server: %s", group.Server.Host)))
+		fmt.Println()
+
+		client, err := connectWithTimeout(group.Server, 30*time.Second)
+		if err != nil {
+			return err
+		}
+		err = deploy.EnsureNetwork(client, cfg)
+		if err == nil {
+			err = deploy.StartDependencies(cfg, client, group.Names, deploy.DependencyOptions{})
+		}
+		client.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runDepsDown(cmd *cobra.Command, args []string) error {
+	cfg := loadedConfig
+	groups, err := groupDependencies(cfg, args)
+	if err != nil {
+		return err
+	}
+	if len(groups) == 0 {
+		fmt.Println(ui.Info("No dependencies configured"))
+		return nil
+	}
+
+	if !depsDownYes {
+		var names []string
+		for _, group := range groups {
+			names = append(names, group.Names...)
+		}
+		fmt.Println(ui.Warning(fmt.Sprintf("This will stop %v; the app can't reach them until podlift deps up", names)))
+		if !confirm() {
+			fmt.Println(ui.Info("Aborted"))
+			return nil
+		}
+		fmt.Println()
+	}
+
+	for _, group := range groups {
+		fmt.Println(ui.Title(fmt.Sprintf("Server: %s", group.Server.Host)))
+		fmt.Println()
+
+		client, err := connectWithTimeout(group.Server, 30*time.Second)
+		if err != nil {
+			return err
+		}
+		err = deploy.StopDependencies(cfg, client, group.Names)
+		client.Close()
+		if err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+func runDepsUpgrade(cmd *cobra.Command, args []string) error {
+	cfg := loadedConfig
+	groups, err := groupDependencies(cfg, args)
+	if err != nil {
+		return err
+	}
+	name, server := args[0], groups[0].Server
+	dep := cfg.Dependencies[name]
+
+	client, err := connectWithTimeout(server, 30*time.Second)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := deploy.EnsureNetwork(client, cfg); err != nil {
+		return err
+	}
+
+	current := deploy.InspectDependencies(cfg, client, []string{name})[0]
+	if current.State == "missing" {
+		fmt.Println(ui.Info(fmt.Sprintf("%s is not running on %s, starting it", name, server.Host)))
+		return deploy.StartDependencies(cfg, client, []string{name}, deploy.DependencyOptions{})
+	}
+	if !current.Changed && !depsUpgradeForce {
+		fmt.Println(ui.Success(fmt.Sprintf("%s is up to date (use --force to recreate it)", name)))
+		return nil
+	}
+
+	fmt.Println(ui.Title(fmt.Sprintf("Upgrade %s on %s", name, server.Host)))
+	fmt.Println()
+	if current.Image != dep.Image {
+		fmt.Println(ui.Info(fmt.Sprintf("Image: %s → %s", current.Image, dep.Image)))
+	} else {
+		fmt.Println(ui.Info("Config changed (env, volume, ports, command, options or healthcheck)"))
+	}
+
+	if deploy.IsMajorUpgrade(current.Image, dep.Image) {
+		fmt.Println(ui.Warning("This is a major version upgrade. Data written by the old version may need a"))
+		fmt.Println(ui.Warning("migration first (e.g. dump and restore, or pg_upgrade for postgres). Back up the volume."))
+	}
+
+	if !depsUpgradeYes {
+		fmt.Println(ui.Warning(fmt.Sprintf("%s is down while the containers swap", name)))
+		if !confirm() {
+			fmt.Println(ui.Info("Aborted"))
+			return nil
+		}
+	}
+	fmt.Println()
+
+	return deploy.UpgradeDependency(cfg, client, name)
+}
+
+func runDepsStatus(cmd *cobra.Command, args []string) error {
+	cfg := loadedConfig
+	groups, err := groupDependencies(cfg, args)
+	if err != nil {
+		return err
+	}
+	if len(groups) == 0 {
+		fmt.Println(ui.Info("No dependencies configured"))
+		return nil
+	}
+
+	fmt.Println(ui.Title(fmt.Sprintf("Dependencies for: %s", cfg.Service)))
+	fmt.Println()
+
+	var rows []table.Row
+	for _, group := range groups {
+		client, err := connectWithTimeout(group.Server, 10*time.Second)
+		if err != nil {
+			for _, name := range group.Names {
+				rows = append(rows, table.Row{group.Server.Host, name, "unreachable", "-", cfg.Dependencies[name].Image, "-"})
+			}
+			continue
+		}
+
+		for _, status := range deploy.InspectDependencies(cfg, client, group.Names) {
+			health, image, spec := status.Health, status.Image, "current"
+			if status.State == "running" {
+				// The probe deploys wait for, not only docker's own healthcheck
+				if probed := deploy.ProbeDependency(cfg, client, status.Name); probed != "" {
+					health = probed
+				}
+			}
+			if health == "" {
+				health = "-"
+			}
+			if image == "" {
+				image = cfg.Dependencies[status.Name].Image
+			}
+			switch {
+			case status.State == "missing":
+				spec = "-"
+			case status.Changed:
+				spec = "changed"
+			}
+			rows = append(rows, table.Row{group.Server.Host, status.Name, status.State, health, image, spec})
+		}
+		client.Close()
+	}
+
+	columns := []table.Column{
+		{Title: "Server", Width: 20},
+		{Title: "Dependency", Width: 15},
+		{Title: "State", Width: 12},
+		{Title: "Health", Width: 10},
+		{Title: "Image", Width: 25},
+		{Title: "Config", Width: 8},
+	}
+	fmt.Println(ui.NewTable(columns, rows).Render())
+	fmt.Println()
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ekinertac/podlift/internal/config"
+)
+
+func TestGroupDependencies(t *testing.T) {
+	cfg := &config.Config{
+		Service: "myapp",
+		Dependencies: map[string]config.Dependency{
+			"postgres": {Image: "postgres:16", Role: "db"},
+			"redis":    {Image: "redis:7"},
+			"mongo":    {Image: "mongo:7", Role: "db"},
+		},
+	}
+	cfg.Servers.Set(map[string][]config.Server{
+		"web": {{Host: "192.168.1.10", Labels: []string{"primary"}}},
+		"db":  {{Host: "192.168.1.20"}},
+	})
+
+	groups, err := groupDependencies(cfg, nil)
+	if err != nil {
+		t.Fatalf("groupDependencies() error = %v", err)
+	}
+
+	got := make(map[string][]string)
+	for _, group := range groups {
+		got[group.Server.Host] = group.Names
+	}
+	want := map[string][]string{
+		"192.168.1.20": {"mongo", "postgres"},
+		"192.168.1.10": {"redis"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groupDependencies() = %v, want %v", got, want)
+	}
+
+	if _, err := groupDependencies(cfg, []string{"mysql"}); err == nil {
+		t.Error("groupDependencies() should fail for an unknown dependency")
+	}
+}
@@ -2,9 +2,16 @@ package commands
 
 import (
 	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/charmbracelet/x/term"
 	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/deploy"
+	"github.com/ekinertac/podlift/internal/shell"
 	"github.com/ekinertac/podlift/internal/ssh"
 	"github.com/ekinertac/podlift/internal/ui"
 	"github.com/spf13/cobra"
@@ -12,18 +19,27 @@ import (
 
 var (
 	execReplica int
+	execHost    string
 )
 
 var execCmd = &cobra.Command{
 	Use:   "exec <service> <command>",
 	Short: "Execute command in a running container",
-	Long:  `Execute a command in a running container for the specified service.`,
-	Args:  cobra.MinimumNArgs(2),
-	RunE:  runExec,
+	Long: `Execute a command in a running container for the specified service.
+
+When run from a terminal, the command gets a terminal too, so shells and REPLs
+work. Otherwise stdin and stdout are piped, e.g.:
+
+  podlift exec web -- pg_dump mydb > backup.sql
+
+podlift exits with the exit code of the command.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runExec,
 }
 
 func init() {
 	execCmd.Flags().IntVar(&execReplica, "replica", 1, "Execute on specific replica")
+	execCmd.Flags().StringVar(&execHost, "host", "", "Server to execute on (default: primary server)")
 	rootCmd.AddCommand(execCmd)
 }
 
@@ -31,85 +47,112 @@ func runExec(cmd *cobra.Command, args []string) error {
 	serviceName := args[0]
 	command := args[1:]
 
-	// Load config
-	cfg, err := config.Load("podlift.yml")
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+	cfg := loadedConfig
+	if _, ok := cfg.Services[serviceName]; !ok {
+		return fmt.Errorf("unknown service '%s'", serviceName)
 	}
 
-	// Get primary server
-	primaryServer, _, err := cfg.GetPrimaryServer()
+	server, err := selectServer(cfg, execHost)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println(ui.Title(fmt.Sprintf("Executing command on %s (replica %d)", serviceName, execReplica)))
-	fmt.Println()
-
-	// Connect to primary server
-	client, err := ssh.NewClient(ssh.Config{
-		Host:    primaryServer.Host,
-		Port:    primaryServer.Port,
-		User:    primaryServer.User,
-		KeyPath: primaryServer.SSHKey,
-		Timeout: 30 * time.Second,
-	})
+	client, err := connectWithTimeout(*server, 30*time.Second)
 	if err != nil {
-		return fmt.Errorf("failed to create SSH client: %w", err)
+		return err
 	}
 	defer client.Close()
 
-	if err := client.Connect(); err != nil {
-		return fmt.Errorf("failed to connect: %w", err)
+	containerName, err := findReplicaContainer(cfg, client, serviceName, execReplica)
+	if err != nil {
+		return fmt.Errorf("%w on %s", err, server.Host)
 	}
 
-	// Build container name
-	containerName := fmt.Sprintf("%s-%s-%d", cfg.Service, serviceName, execReplica)
+	// Status goes to stderr so piped output stays clean
+	fmt.Fprintln(os.Stderr, ui.Info(fmt.Sprintf("Executing in %s on %s", containerName, server.Host)))
+
+	opts, restore, err := attachStreams()
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	err = client.ExecuteInteractive(buildExecCommand(containerName, command, opts.TTY), opts)
+	if code, ok := ssh.ExitStatus(err); ok {
+		return &ExitError{Code: code}
+	}
+	return err
+}
 
-	// Check if container exists
-	checkCmd := fmt.Sprintf("sudo docker ps --filter name=%s --format '{{.Names}}'", containerName)
-	output, err := client.Execute(checkCmd)
-	if err != nil || output == "" {
-		return fmt.Errorf("container %s not found or not running", containerName)
+// attachStreams wires a remote command to stdin, stdout and stderr
+// From a terminal, the command gets a terminal of the same size and the local one is put in
+// raw mode, so keys like Ctrl-C reach the command; restore undoes that.
+func attachStreams() (opts ssh.InteractiveOptions, restore func(), err error) {
+	stdinFd, stdoutFd := os.Stdin.Fd(), os.Stdout.Fd()
+	opts = ssh.InteractiveOptions{
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		TTY:    term.IsTerminal(stdinFd) && term.IsTerminal(stdoutFd),
+	}
+	if !opts.TTY {
+		return opts, func() {}, nil
 	}
 
-	// Execute command in container
-	execCommand := fmt.Sprintf("sudo docker exec -it %s %s", containerName, joinArgs(command))
-	
-	fmt.Println(ui.Info(fmt.Sprintf("Executing: %s", joinArgs(command))))
-	fmt.Println()
+	opts.Term = os.Getenv("TERM")
+	if width, height, err := term.GetSize(stdoutFd); err == nil {
+		opts.Size = ssh.TerminalSize{Width: width, Height: height}
+	}
 
-	result, err := client.Execute(execCommand)
+	state, err := term.MakeRaw(stdinFd)
 	if err != nil {
-		return fmt.Errorf("command failed: %w", err)
+		return opts, nil, fmt.Errorf("failed to set terminal to raw mode: %w", err)
 	}
 
-	fmt.Println(result)
-	return nil
+	resize, stop := notifyResize(stdoutFd)
+	opts.Resize = resize
+	return opts, func() {
+		stop()
+		term.Restore(stdinFd, state)
+	}, nil
 }
 
-func joinArgs(args []string) string {
-	result := ""
-	for i, arg := range args {
-		if i > 0 {
-			result += " "
-		}
-		// Quote args with spaces
-		if containsSpace(arg) {
-			result += fmt.Sprintf(`"%s"`, arg)
-		} else {
-			result += arg
-		}
+// buildExecCommand builds the remote docker exec command; each argument reaches the container unchanged
+func buildExecCommand(containerName string, command []string, tty bool) string {
+	flags := "-i"
+	if tty {
+		flags = "-it"
 	}
-	return result
+	return shell.Command("sudo", "docker", "exec", flags, containerName).Arg(command...).String()
 }
 
-func containsSpace(s string) bool {
-	for _, r := range s {
-		if r == ' ' {
-			return true
+// findReplicaContainer returns the running container of a replica of a service
+// docker ps lists the newest container first, so mid-deploy the new release wins.
+func findReplicaContainer(cfg *config.Config, client ssh.SSHClient, serviceName string, replica int) (string, error) {
+	replicas, err := deploy.ListReplicas(cfg, client, serviceName)
+	if err != nil {
+		return "", err
+	}
+
+	running := map[int]bool{}
+	for _, r := range replicas {
+		if r.Number == replica {
+			return r.Container, nil
 		}
+		running[r.Number] = true
 	}
-	return false
-}
 
+	if len(running) == 0 {
+		return "", fmt.Errorf("service '%s' is not running", serviceName)
+	}
+	var numbers []int
+	for n := range running {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	names := make([]string, len(numbers))
+	for i, n := range numbers {
+		names[i] = strconv.Itoa(n)
+	}
+	return "", fmt.Errorf("replica %d of '%s' is not running (running: %s)", replica, serviceName, strings.Join(names, ", "))
+}
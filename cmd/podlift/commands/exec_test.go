@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/shell"
+	"github.com/ekinertac/podlift/internal/ssh"
+)
+
+func TestBuildExecCommand(t *testing.T) {
+	got := buildExecCommand("myapp-web-1", []string{"python", "-c", `print("it's ok"); import os`}, true)
+	want := `sudo docker exec -it myapp-web-1 python -c 'print("it'\''s ok"); import os'`
+	if got != want {
+		t.Errorf("buildExecCommand() = %q, want %q", got, want)
+	}
+
+	// Without a local terminal, stdin is piped and no TTY is allocated
+	got = buildExecCommand("myapp-web-1", []string{"pg_dump", "mydb"}, false)
+	want = `sudo docker exec -i myapp-web-1 pg_dump mydb`
+	if got != want {
+		t.Errorf("buildExecCommand() = %q, want %q", got, want)
+	}
+}
+
+func TestFindReplicaContainer(t *testing.T) {
+	cfg := &config.Config{Service: "myapp", Services: map[string]config.Service{"web": {Replicas: 2}}}
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		if !strings.Contains(cmd, "label=podlift.container_type=web") {
+			t.Errorf("containers should be found by labels, got %q", cmd)
+		}
+		// Newest first: a deploy is starting def456 while abc123 still runs; an old container has no replica label
+		return "myapp-web-def456-1\t1\nmyapp-web-abc123-2\t2\nmyapp-web-abc123-1\t1\nmyapp-web-old-3\t\n", nil
+	}
+
+	tests := []struct {
+		replica int
+		want    string
+		wantErr string
+	}{
+		{replica: 1, want: "myapp-web-def456-1"},
+		{replica: 2, want: "myapp-web-abc123-2"},
+		{replica: 3, want: "myapp-web-old-3"},
+		{replica: 4, wantErr: "replica 4 of 'web' is not running (running: 1, 2, 3)"},
+	}
+	for _, tt := range tests {
+		got, err := findReplicaContainer(cfg, client, "web", tt.replica)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("findReplicaContainer(%d) error = %v, want %q", tt.replica, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("findReplicaContainer(%d) = %q, %v, want %q", tt.replica, got, err, tt.want)
+		}
+	}
+
+	client.ExecuteFunc = func(cmd string) (string, error) { return "", nil }
+	if _, err := findReplicaContainer(cfg, client, "web", 1); err == nil || !strings.Contains(err.Error(), "is not running") {
+		t.Errorf("findReplicaContainer() error = %v, want not running", err)
+	}
+}
+
+func FuzzBuildExecCommand(f *testing.F) {
+	f.Add("python", "manage.py shell", "")
+	f.Add("sh", "-c", "echo $HOME; rm -rf /")
+	f.Add("'", `"`, "\\")
+
+	f.Fuzz(func(t *testing.T, a, b, c string) {
+		args := []string{a, b, c}
+		for _, arg := range args {
+			if strings.IndexByte(arg, 0) >= 0 {
+				t.Skip("NUL can't appear in a shell argument")
+			}
+		}
+
+		got, err := shell.Split(buildExecCommand("myapp-web-1", args, true))
+		if err != nil {
+			t.Fatalf("Split() error = %v", err)
+		}
+
+		want := append([]string{"sudo", "docker", "exec", "-it", "myapp-web-1"}, args...)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("arguments = %q, want %q", got, want)
+		}
+	})
+}
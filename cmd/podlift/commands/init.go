@@ -14,7 +14,8 @@ func init() {
 }
 
 var initCommand = &cobra.Command{
-	Use:   "init",
+	Use:         "init",
+	Annotations: withoutConfig,
 	Short: "Initialize podlift configuration",
 	Long:  "Creates a podlift.yml configuration file and .env.example in the current directory",
 	RunE:  runInit,
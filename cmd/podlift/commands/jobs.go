@@ -0,0 +1,181 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/deploy"
+	"github.com/ekinertac/podlift/internal/shell"
+	"github.com/ekinertac/podlift/internal/ssh"
+	"github.com/ekinertac/podlift/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	jobsLogsTail   int
+	jobsLogsFollow bool
+)
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Inspect and run scheduled jobs",
+	Long: `Jobs are commands run on a cron schedule, each in a one-off container of the
+current release with its service's env, volumes and limits.
+
+Every deploy installs the configured jobs into /etc/cron.d on the server they're
+placed on (host, role or labels, default: the primary server) and removes the
+ones that were deleted from podlift.yml.`,
+}
+
+var jobsLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List jobs, their schedule and last run",
+	Args:  cobra.NoArgs,
+	RunE:  runJobsLs,
+}
+
+var jobsRunCmd = &cobra.Command{
+	Use:   "run NAME",
+	Short: "Run a job now, in the foreground",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runJobsRun,
+}
+
+var jobsLogsCmd = &cobra.Command{
+	Use:   "logs NAME",
+	Short: "Show the output of a job's scheduled runs",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runJobsLogs,
+}
+
+func init() {
+	jobsLogsCmd.Flags().IntVarP(&jobsLogsTail, "tail", "n", 100, "Number of lines from the end of the log")
+	jobsLogsCmd.Flags().BoolVarP(&jobsLogsFollow, "follow", "f", false, "Follow the log")
+	jobsCmd.AddCommand(jobsLsCmd, jobsRunCmd, jobsLogsCmd)
+	rootCmd.AddCommand(jobsCmd)
+}
+
+// connectJob connects to the server a job is installed on
+func connectJob(cfg *config.Config, name string) (*ssh.Client, *config.Server, error) {
+	if _, ok := cfg.Jobs[name]; !ok {
+		return nil, nil, fmt.Errorf("unknown job '%s'", name)
+	}
+	server, err := cfg.GetJobServer(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("job '%s': %w", name, err)
+	}
+	client, err := connectWithTimeout(*server, 30*time.Second)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, server, nil
+}
+
+func runJobsLs(cmd *cobra.Command, args []string) error {
+	cfg := loadedConfig
+	if len(cfg.Jobs) == 0 {
+		fmt.Println(ui.Info("No jobs configured"))
+		return nil
+	}
+
+	fmt.Println(ui.Title(fmt.Sprintf("Jobs for: %s", cfg.Service)))
+	fmt.Println()
+
+	clients := map[string]*ssh.Client{}
+	defer func() {
+		for _, client := range clients {
+			if client != nil {
+				client.Close()
+			}
+		}
+	}()
+
+	var rows []table.Row
+	for _, name := range cfg.JobNames() {
+		job := cfg.Jobs[name]
+		server, err := cfg.GetJobServer(name)
+		if err != nil {
+			return fmt.Errorf("job '%s': %w", name, err)
+		}
+
+		client, ok := clients[server.Host]
+		if !ok {
+			client, _ = connectWithTimeout(*server, 10*time.Second)
+			clients[server.Host] = client
+		}
+
+		lastRun := "unreachable"
+		if client != nil {
+			lastRun = "never"
+			if run, err := deploy.LastJobRun(cfg, client, name); err != nil {
+				lastRun = "unknown"
+			} else if run.Time != "" {
+				lastRun = run.Time + " " + run.Status
+			}
+		}
+		rows = append(rows, table.Row{name, job.Schedule, server.Host, cfg.JobService(name), job.Command, lastRun})
+	}
+
+	columns := []table.Column{
+		{Title: "Job", Width: 15},
+		{Title: "Schedule", Width: 15},
+		{Title: "Server", Width: 20},
+		{Title: "Service", Width: 10},
+		{Title: "Command", Width: 30},
+		{Title: "Last run", Width: 30},
+	}
+	fmt.Println(ui.NewTable(columns, rows).Render())
+	fmt.Println()
+	return nil
+}
+
+func runJobsRun(cmd *cobra.Command, args []string) error {
+	cfg := loadedConfig
+	name := args[0]
+	client, _, err := connectJob(cfg, name)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	command, err := shell.Split(cfg.Jobs[name].Command)
+	if err != nil {
+		return fmt.Errorf("job '%s' command: %w", name, err)
+	}
+	opts := deploy.TaskOptions{Service: cfg.JobService(name), Command: command}
+	result, err := runTask(cfg, client, opts)
+	if err != nil {
+		fmt.Println(ui.Error(err.Error()))
+		return err
+	}
+	if result.ExitCode != 0 {
+		return &ExitError{Code: result.ExitCode}
+	}
+	return nil
+}
+
+func runJobsLogs(cmd *cobra.Command, args []string) error {
+	cfg := loadedConfig
+	name := args[0]
+	client, server, err := connectJob(cfg, name)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	logPath := deploy.JobLogPath(cfg.Service, name)
+	if _, err := client.Execute(shell.Join("sudo", "test", "-f", logPath)); err != nil {
+		fmt.Println(ui.Info(fmt.Sprintf("Job '%s' hasn't run on %s yet", name, server.Host)))
+		return nil
+	}
+
+	tailCmd := shell.Command("sudo", "tail", "-n", strconv.Itoa(jobsLogsTail))
+	if jobsLogsFollow {
+		tailCmd.Arg("-F")
+	}
+	return client.ExecuteWithOutput(tailCmd.Arg(logPath).String(), os.Stdout, os.Stderr)
+}
@@ -3,109 +3,106 @@ package commands
 import (
 	"fmt"
 	"os"
-	"strings"
+	"os/signal"
+	"regexp"
+	"sort"
 	"time"
 
 	"github.com/ekinertac/podlift/internal/config"
-	"github.com/ekinertac/podlift/internal/docker"
-	"github.com/ekinertac/podlift/internal/ssh"
-	"github.com/ekinertac/podlift/internal/ui"
+	"github.com/ekinertac/podlift/internal/logs"
 	"github.com/spf13/cobra"
 )
 
 var (
-	logsFollow bool
-	logsTail   int
-	logsSince  string
+	logsFollow     bool
+	logsTail       int
+	logsSince      string
+	logsUntil      string
+	logsTimestamps bool
+	logsMerge      bool
+	logsGrep       string
+	logsHost       string
 )
 
 func init() {
 	rootCmd.AddCommand(logsCommand)
-	logsCommand.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Follow log output")
-	logsCommand.Flags().IntVarP(&logsTail, "tail", "n", 100, "Number of lines to show from the end of the logs")
-	logsCommand.Flags().StringVar(&logsSince, "since", "", "Show logs since timestamp (e.g. 2h, 30m)")
+	logsCommand.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Follow log output, including containers started later")
+	logsCommand.Flags().IntVarP(&logsTail, "tail", "n", 100, "Number of lines to show from the end of each replica's logs (0 for all)")
+	logsCommand.Flags().StringVar(&logsSince, "since", "", "Show logs since timestamp (e.g. 2h, 30m, 2024-01-01T12:00:00)")
+	logsCommand.Flags().StringVar(&logsUntil, "until", "", "Show logs before timestamp (e.g. 10m, 2024-01-01T13:00:00)")
+	logsCommand.Flags().BoolVarP(&logsTimestamps, "timestamps", "t", false, "Show timestamps")
+	logsCommand.Flags().BoolVar(&logsMerge, "merge", false, "Merge the lines of all replicas in time order")
+	logsCommand.Flags().StringVar(&logsGrep, "grep", "", "Only show lines matching a regular expression")
+	logsCommand.Flags().StringVar(&logsHost, "host", "", "Only show logs from this server")
 }
 
 var logsCommand = &cobra.Command{
 	Use:   "logs <service>",
 	Short: "View container logs",
-	Long:  "Display logs from a service container",
-	Args:  cobra.MinimumNArgs(1),
-	RunE:  runLogs,
+	Long: `Display logs from every replica of a service on every server.
+
+Each line is prefixed with its server and replica, e.g. 192.168.1.10/web-2.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runLogs,
 }
 
 func runLogs(cmd *cobra.Command, args []string) error {
 	serviceName := args[0] // e.g., "web", "worker"
 
-	// Load configuration
-	configPath, err := config.Find()
-	if err != nil {
-		return fmt.Errorf("podlift.yml not found")
-	}
-
-	cfg, err := config.Load(configPath)
-	if err != nil {
-		return err
+	if logsMerge && logsFollow {
+		return fmt.Errorf("--merge can't be used with --follow (followed lines are printed as they arrive)")
 	}
-
-	// Get primary server (show logs from first server)
-	primaryServer, _, err := cfg.GetPrimaryServer()
-	if err != nil {
-		return err
+	opts := logs.Options{
+		Tail:       logsTail,
+		Follow:     logsFollow,
+		Since:      logsSince,
+		Until:      logsUntil,
+		Timestamps: logsTimestamps,
+		Merge:      logsMerge,
 	}
-
-	// Create SSH client
-	sshClient, err := ssh.NewClient(ssh.Config{
-		Host:    primaryServer.Host,
-		Port:    primaryServer.Port,
-		User:    primaryServer.User,
-		KeyPath: primaryServer.SSHKey,
-		Timeout: 10 * time.Second,
-	})
-	if err != nil {
-		return err
-	}
-	defer sshClient.Close()
-
-	// Find container name (get first container for this service)
-	findCmd := fmt.Sprintf(`docker ps --filter "label=podlift.service=%s" --filter "label=podlift.container_type=%s" --format "{{.Names}}" | head -1`, cfg.Service, serviceName)
-	
-	containerName, err := sshClient.Execute(findCmd)
-	if err != nil || strings.TrimSpace(containerName) == "" {
-		// Try without container_type filter (for now)
-		findCmd = fmt.Sprintf(`docker ps --filter "label=podlift.service=%s" --format "{{.Names}}" | grep "-%s-" | head -1`, cfg.Service, serviceName)
-		containerName, err = sshClient.Execute(findCmd)
-		if err != nil || strings.TrimSpace(containerName) == "" {
-			return fmt.Errorf("no running container found for service '%s'", serviceName)
+	if logsGrep != "" {
+		re, err := regexp.Compile(logsGrep)
+		if err != nil {
+			return fmt.Errorf("invalid --grep: %w", err)
 		}
+		opts.Grep = re
 	}
 
-	containerName = strings.TrimSpace(containerName)
-
-	fmt.Println(ui.Info(fmt.Sprintf("Logs for %s (%s)", serviceName, containerName)))
-	fmt.Println()
-
-	// Build logs command
-	logsCmd := docker.GenerateLogsCommand(containerName, logsTail, logsFollow)
-	
-	if logsSince != "" {
-		logsCmd += fmt.Sprintf(" --since %s", logsSince)
+	cfg := loadedConfig
+	if _, ok := cfg.Services[serviceName]; !ok {
+		return fmt.Errorf("unknown service '%s'", serviceName)
 	}
 
-	// Stream logs
-	if logsFollow {
-		// For follow mode, stream indefinitely
-		return sshClient.ExecuteWithOutput(logsCmd, os.Stdout, os.Stderr)
+	var hosts []config.Server
+	if logsHost != "" {
+		server, err := selectServer(cfg, logsHost)
+		if err != nil {
+			return err
+		}
+		hosts = append(hosts, *server)
+	} else {
+		seen := map[string]bool{}
+		for _, server := range cfg.GetAllServers() {
+			if !seen[server.Host] {
+				seen[server.Host] = true
+				hosts = append(hosts, server.Server)
+			}
+		}
+		sort.Slice(hosts, func(i, j int) bool { return hosts[i].Host < hosts[j].Host })
 	}
 
-	// For non-follow mode, just show output
-	output, err := sshClient.Execute(logsCmd)
-	if err != nil {
-		return fmt.Errorf("failed to get logs: %w", err)
+	var servers []logs.Server
+	for _, server := range hosts {
+		client, err := connectWithTimeout(server, 10*time.Second)
+		if err != nil {
+			return fmt.Errorf("%s: %w", server.Host, err)
+		}
+		defer client.Close()
+		servers = append(servers, logs.Server{Host: server.Host, Client: client})
 	}
 
-	fmt.Print(output)
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
 
-	return nil
+	return logs.Show(ctx, cfg, servers, serviceName, opts, os.Stdout)
 }
-
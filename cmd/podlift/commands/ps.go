@@ -6,8 +6,8 @@ import (
 	"time"
 
 	"github.com/charmbracelet/bubbles/table"
-	"github.com/ekinertac/podlift/internal/config"
 	"github.com/ekinertac/podlift/internal/docker"
+	"github.com/ekinertac/podlift/internal/shell"
 	"github.com/ekinertac/podlift/internal/ssh"
 	"github.com/ekinertac/podlift/internal/ui"
 	"github.com/spf13/cobra"
@@ -28,16 +28,7 @@ var psCommand = &cobra.Command{
 }
 
 func runPs(cmd *cobra.Command, args []string) error {
-	// Load configuration
-	configPath, err := config.Find()
-	if err != nil {
-		return fmt.Errorf("podlift.yml not found")
-	}
-
-	cfg, err := config.Load(configPath)
-	if err != nil {
-		return err
-	}
+	cfg := loadedConfig
 
 	fmt.Println(ui.Title(fmt.Sprintf("Services for: %s", cfg.Service)))
 	fmt.Println()
@@ -75,7 +66,7 @@ func runPs(cmd *cobra.Command, args []string) error {
 		// List containers
 		psCmd := docker.GeneratePsCommand(cfg.Service)
 		if psAll {
-			psCmd = fmt.Sprintf(`docker ps -a --filter "label=podlift.service=%s" --format "{{.Names}}\t{{.Status}}\t{{.Label \"podlift.version\"}}"`, cfg.Service)
+			psCmd = shell.Join("docker", "ps", "-a", "--filter", "label=podlift.service="+cfg.Service, "--format", "{{.Names}}\t{{.Status}}\t{{.Label \"podlift.version\"}}")
 		}
 
 		output, err := sshClient.Execute(psCmd)
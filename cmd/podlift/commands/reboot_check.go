@@ -0,0 +1,189 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/deploy"
+	"github.com/ekinertac/podlift/internal/ssh"
+	"github.com/ekinertac/podlift/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rebootCheckHost    bool
+	rebootCheckYes     bool
+	rebootCheckTimeout time.Duration
+)
+
+func init() {
+	rebootCheckCommand.Flags().BoolVar(&rebootCheckHost, "host", false, "Reboot the whole host instead of restarting docker")
+	rebootCheckCommand.Flags().BoolVarP(&rebootCheckYes, "yes", "y", false, "Don't ask for confirmation")
+	rebootCheckCommand.Flags().DurationVar(&rebootCheckTimeout, "timeout", 5*time.Minute, "How long to wait for recovery on each server")
+	rootCmd.AddCommand(rebootCheckCommand)
+}
+
+var rebootCheckCommand = &cobra.Command{
+	Use:   "reboot-check",
+	Short: "Restart docker (or the host) and verify the app recovers",
+	Long: `Restarts the docker daemon on each server, one at a time, and verifies that
+containers, dependencies and the nginx upstream come back. With --host the
+whole server is rebooted instead.
+
+This takes the app down while it runs: use it on a staging server.`,
+	Args: cobra.NoArgs,
+	RunE: runRebootCheck,
+}
+
+func runRebootCheck(cmd *cobra.Command, args []string) error {
+	cfg := loadedConfig
+
+	allServers := cfg.GetAllServers()
+	what := "restart docker"
+	if rebootCheckHost {
+		what = "reboot"
+	}
+
+	fmt.Println(ui.Title(fmt.Sprintf("Reboot check for %s", cfg.Service)))
+	fmt.Println()
+
+	if !rebootCheckYes {
+		fmt.Println(ui.Warning(fmt.Sprintf("This will %s on %d server(s); the app is down until it recovers", what, len(allServers))))
+		if !confirm() {
+			fmt.Println(ui.Info("Aborted"))
+			return nil
+		}
+		fmt.Println()
+	}
+
+	failed := 0
+	for i, serverWithRole := range allServers {
+		server := serverWithRole.Server
+		fmt.Printf("[%d/%d] Server: %s (%s)\n", i+1, len(allServers), server.Host, serverWithRole.Role)
+		fmt.Println()
+
+		if err := rebootCheckServer(server, cfg); err != nil {
+			fmt.Println(ui.Error(err.Error()))
+			failed++
+		}
+		fmt.Println()
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("recovery failed on %d server(s)", failed)
+	}
+
+	fmt.Println(ui.Success("All servers recovered"))
+	return nil
+}
+
+// rebootCheckServer restarts docker or the host on one server and waits for the app to recover
+func rebootCheckServer(server config.Server, cfg *config.Config) error {
+	client, err := connectWithTimeout(server, 30*time.Second)
+	if err != nil {
+		return err
+	}
+
+	if rebootCheckHost {
+		// The boot ID changes on reboot, so we can tell a rebooted host from one that hasn't gone down yet
+		bootID, _ := client.Execute("cat /proc/sys/kernel/random/boot_id")
+
+		fmt.Println(ui.Info("Rebooting host..."))
+		client.Execute("sudo nohup sh -c 'sleep 1; systemctl reboot' >/dev/null 2>&1 &")
+		client.Close()
+
+		client, err = waitForReboot(server, strings.TrimSpace(bootID), rebootCheckTimeout)
+		if err != nil {
+			return err
+		}
+		fmt.Println(ui.Success("Host is back"))
+	} else {
+		fmt.Println(ui.Info("Restarting docker..."))
+		if output, err := client.Execute("sudo systemctl restart docker"); err != nil {
+			client.Close()
+			return fmt.Errorf("failed to restart docker: %w\n%s", err, output)
+		}
+	}
+	defer client.Close()
+
+	// Containers with a restart policy come back on their own; give them until the deadline
+	fmt.Println(ui.Info("Waiting for containers..."))
+	deadline := time.Now().Add(rebootCheckTimeout)
+	report := deploy.VerifyRecovery(client, server, cfg)
+	for !report.OK() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Second)
+		report = deploy.VerifyRecovery(client, server, cfg)
+	}
+
+	checks := report.Checks
+	if report.OK() {
+		checks = append(checks, deploy.CheckRecoveredHealth(client, cfg, report.Containers)...)
+	}
+
+	failed := 0
+	for _, check := range checks {
+		line := fmt.Sprintf("  %s: %s", check.Name, check.Detail)
+		if check.OK {
+			fmt.Println(ui.Success(line))
+		} else {
+			fmt.Println(ui.Error(line))
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%s did not recover (%d failed check(s))", server.Host, failed)
+	}
+	return nil
+}
+
+// waitForReboot reconnects to a server until it comes back with a new boot ID
+func waitForReboot(server config.Server, oldBootID string, timeout time.Duration) (*ssh.Client, error) {
+	deadline := time.Now().Add(timeout)
+	time.Sleep(10 * time.Second) // Don't catch the host before it goes down
+
+	for time.Now().Before(deadline) {
+		client, err := connectWithTimeout(server, 10*time.Second)
+		if err == nil {
+			bootID, err := client.Execute("cat /proc/sys/kernel/random/boot_id")
+			if err == nil && strings.TrimSpace(bootID) != oldBootID {
+				return client, nil
+			}
+			client.Close()
+		}
+		time.Sleep(5 * time.Second)
+	}
+
+	return nil, fmt.Errorf("%s did not come back within %s", server.Host, timeout)
+}
+
+// confirm asks the user to continue and returns true on yes
+func confirm() bool {
+	fmt.Print("Continue? [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// connectWithTimeout opens an SSH connection to a server
+func connectWithTimeout(server config.Server, timeout time.Duration) (*ssh.Client, error) {
+	client, err := ssh.NewClient(ssh.Config{
+		Host:    server.Host,
+		Port:    server.Port,
+		User:    server.User,
+		KeyPath: server.SSHKey,
+		Timeout: timeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SSH client: %w", err)
+	}
+
+	if err := client.Connect(); err != nil {
+		return nil, fmt.Errorf("SSH connection failed: %w", err)
+	}
+	return client, nil
+}
@@ -0,0 +1,43 @@
+//go:build !windows
+
+package commands
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/ekinertac/podlift/internal/ssh"
+)
+
+// notifyResize sends the new size of the terminal on fd whenever it changes
+func notifyResize(fd uintptr) (<-chan ssh.TerminalSize, func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGWINCH)
+
+	sizes := make(chan ssh.TerminalSize, 1)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-sigs:
+				width, height, err := term.GetSize(fd)
+				if err != nil {
+					continue
+				}
+				select {
+				case sizes <- ssh.TerminalSize{Width: width, Height: height}:
+				default:
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return sizes, func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package commands
+
+import "github.com/ekinertac/podlift/internal/ssh"
+
+// notifyResize is a no-op on Windows, which has no SIGWINCH; the remote
+// terminal keeps the size it started with
+func notifyResize(fd uintptr) (<-chan ssh.TerminalSize, func()) {
+	return nil, func() {}
+}
@@ -6,7 +6,10 @@ import (
 	"time"
 
 	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/deploy"
+	"github.com/ekinertac/podlift/internal/docker"
 	"github.com/ekinertac/podlift/internal/nginx"
+	"github.com/ekinertac/podlift/internal/shell"
 	"github.com/ekinertac/podlift/internal/ssh"
 	"github.com/ekinertac/podlift/internal/ui"
 	"github.com/spf13/cobra"
@@ -31,16 +34,7 @@ var rollbackCommand = &cobra.Command{
 }
 
 func runRollback(cmd *cobra.Command, args []string) error {
-	// Load configuration
-	configPath, err := config.Find()
-	if err != nil {
-		return fmt.Errorf("podlift.yml not found")
-	}
-
-	cfg, err := config.Load(configPath)
-	if err != nil {
-		return err
-	}
+	cfg := loadedConfig
 
 	fmt.Println(ui.Title(fmt.Sprintf("Rolling back %s", cfg.Service)))
 	fmt.Println()
@@ -69,7 +63,7 @@ func runRollback(cmd *cobra.Command, args []string) error {
 		}
 
 		// Find stopped containers with podlift.service label
-		listCmd := fmt.Sprintf("sudo docker ps -a --filter label=podlift.service=%s --filter status=exited --format '{{.Names}}\t{{.Image}}' | head -10", cfg.Service)
+		listCmd := shell.Join("sudo", "docker", "ps", "-a", "--filter", "label=podlift.service="+cfg.Service, "--filter", "status=exited", "--format", "{{.Names}}\t{{.Image}}\t{{.Label \"podlift.container_type\"}}") + " | head -10"
 		output, err := client.Execute(listCmd)
 		if err != nil || output == "" {
 			fmt.Println(ui.Warning("  No previous deployment found"))
@@ -84,22 +78,24 @@ func runRollback(cmd *cobra.Command, args []string) error {
 
 		// If --to specified, filter by version
 		var targetContainers []string
-		if rollbackTo != "" {
-			for _, line := range lines {
-				if strings.Contains(line, rollbackTo) {
-					parts := strings.Fields(line)
-					if len(parts) > 0 {
-						targetContainers = append(targetContainers, parts[0])
-					}
-				}
+		restartPolicies := make(map[string]string)
+		for _, line := range lines {
+			if rollbackTo != "" && !strings.Contains(line, rollbackTo) {
+				continue
+			}
+			// Otherwise use the first (most recent) stopped containers
+			parts := strings.Fields(line)
+			if len(parts) == 0 {
+				continue
+			}
+			// Jobs like migrations exited on purpose, starting them again would re-run them
+			if len(parts) > 2 && cfg.IsJob(parts[2]) {
+				continue
 			}
-		} else {
-			// Use first (most recent) stopped containers
-			for _, line := range lines {
-				parts := strings.Fields(line)
-				if len(parts) > 0 {
-					targetContainers = append(targetContainers, parts[0])
-				}
+			targetContainers = append(targetContainers, parts[0])
+			restartPolicies[parts[0]] = config.DefaultRestartPolicy
+			if len(parts) > 2 && cfg.Services[parts[2]].Restart != "" {
+				restartPolicies[parts[0]] = cfg.Services[parts[2]].Restart
 			}
 		}
 
@@ -109,16 +105,24 @@ func runRollback(cmd *cobra.Command, args []string) error {
 		}
 
 		// Stop current running containers
-		stopCmd := fmt.Sprintf("sudo docker ps --filter label=podlift.service=%s --format '{{.Names}}' | xargs -r sudo docker stop", cfg.Service)
-		_, err = client.Execute(stopCmd)
+		// Their restart policy is cleared so a reboot doesn't bring them back next to the rolled back ones
+		runningCmd := shell.Join("sudo", "docker", "ps", "--filter", "label=podlift.service="+cfg.Service, "--filter", "label=podlift.container_type", "--format", "{{.Names}}")
+		running, err := client.Execute(runningCmd)
 		if err != nil {
-			fmt.Println(ui.Warning(fmt.Sprintf("  Failed to stop current containers: %v", err)))
+			fmt.Println(ui.Warning(fmt.Sprintf("  Failed to list current containers: %v", err)))
+		}
+		if current := strings.Fields(running); len(current) > 0 {
+			client.Execute(docker.GenerateUpdateRestartCommand("no", current...))
+			if _, err := client.Execute(shell.Command("sudo", "docker", "stop").Arg(current...).String()); err != nil {
+				fmt.Println(ui.Warning(fmt.Sprintf("  Failed to stop current containers: %v", err)))
+			}
 		}
 
 		// Start previous containers
 		for _, container := range targetContainers {
 			fmt.Println(ui.Info(fmt.Sprintf("  Starting %s", container)))
-			startCmd := fmt.Sprintf("sudo docker start %s", container)
+			client.Execute(docker.GenerateUpdateRestartCommand(restartPolicies[container], container))
+			startCmd := shell.Join("sudo", "docker", "start", container)
 			_, err := client.Execute(startCmd)
 			if err != nil {
 				return fmt.Errorf("failed to start %s: %w", container, err)
@@ -126,6 +130,13 @@ func runRollback(cmd *cobra.Command, args []string) error {
 			fmt.Println(ui.Success(fmt.Sprintf("  ✓ %s started", container)))
 		}
 
+		// Scheduled jobs run the rolled back release too
+		if rel, err := deploy.CurrentRelease(cfg, client); err != nil {
+			fmt.Println(ui.Warning(fmt.Sprintf("  Jobs not updated: %v", err)))
+		} else if err := deploy.InstallJobs(cfg, client, server.Server, rel); err != nil {
+			fmt.Println(ui.Warning(fmt.Sprintf("  Jobs not updated: %v", err)))
+		}
+
 		// Update nginx if configured
 		if cfg.Proxy != nil && cfg.Proxy.Enabled {
 			nginxMgr := nginx.NewManager(client)
@@ -2,11 +2,15 @@ package commands
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/ui"
 	"github.com/spf13/cobra"
 )
 
@@ -18,6 +22,24 @@ var (
 	Date    = ""
 )
 
+var (
+	// configFile is the config path from --config (falls back to $PODLIFT_CONFIG)
+	configFile string
+
+	// environment is the active environment overlay (--env), e.g. "staging"
+	environment string
+
+	// loadedConfig is the config loaded by rootCmd before a command runs
+	loadedConfig *config.Config
+)
+
+// skipConfigAnnotation marks commands that run without loading podlift.yml,
+// e.g. init, which creates it, or validate, which reports loading step by step
+const skipConfigAnnotation = "podlift.skip_config"
+
+// withoutConfig is the annotation of commands that don't need the loaded config
+var withoutConfig = map[string]string{skipConfigAnnotation: "true"}
+
 var rootCmd = &cobra.Command{
 	Use:   "podlift",
 	Short: "Simple, transparent deployment for containerized applications",
@@ -26,6 +48,39 @@ No black boxes, no magic, no broken promises.`,
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	Version:       getVersion(),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Show the active environment in every command header
+		ui.SetEnvironment(environment)
+
+		if !needsConfig(cmd) {
+			return nil
+		}
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		loadedConfig = cfg
+		return nil
+	},
+}
+
+// needsConfig reports whether a command runs with the loaded config
+// Commands that only print help, cobra's help and completion commands, and
+// commands annotated withoutConfig (or under a parent that is) don't.
+func needsConfig(cmd *cobra.Command) bool {
+	if !cmd.Runnable() {
+		return false
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		switch c.Name() {
+		case "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+			return false
+		}
+		if c.Annotations[skipConfigAnnotation] != "" {
+			return false
+		}
+	}
+	return true
 }
 
 // Execute runs the root command
@@ -34,6 +89,9 @@ func Execute() error {
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Path to config file (default: podlift.yml, or $PODLIFT_CONFIG)")
+	rootCmd.PersistentFlags().StringVarP(&environment, "env", "d", "", "Environment overlay to load (podlift.<env>.yml, .env.<env>)")
+
 	// Add version subcommand for 'podlift version'
 	rootCmd.AddCommand(versionCmd)
 	
@@ -41,9 +99,54 @@ func init() {
 	rootCmd.SetVersionTemplate(getVersionOutput())
 }
 
+// resolveConfigPath returns the config file to use, in order:
+// --config, $PODLIFT_CONFIG, then podlift.yml in the current or a parent directory
+func resolveConfigPath() (string, error) {
+	path := configFile
+	if path == "" {
+		path = os.Getenv("PODLIFT_CONFIG")
+	}
+
+	if path == "" {
+		return config.Find()
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("config file not found: %s", path)
+		}
+		return "", fmt.Errorf("failed to access config file: %w", err)
+	}
+	return filepath.Abs(path)
+}
+
+// loadConfig loads the config the same way for every command:
+// resolve the path, merge the environment overlay, substitute env vars and validate
+// rootCmd calls it before each command; commands use loadedConfig.
+func loadConfig() (*config.Config, error) {
+	configPath, err := resolveConfigPath()
+	if err != nil {
+		fmt.Println(ui.Error(err.Error()))
+		fmt.Println()
+		fmt.Println("Run: podlift init")
+		return nil, err
+	}
+
+	cfg, err := config.LoadEnvironment(configPath, environment)
+	if err != nil {
+		fmt.Println(ui.Error("Configuration invalid"))
+		fmt.Println()
+		fmt.Println(err.Error())
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
 var versionCmd = &cobra.Command{
-	Use:   "version",
-	Short: "Show podlift version",
+	Use:         "version",
+	Short:       "Show podlift version",
+	Annotations: withoutConfig,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Print(getVersionOutput())
 	},
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveConfigPath(t *testing.T) {
+	dir := t.TempDir()
+	flagPath := filepath.Join(dir, "flag.yml")
+	envPath := filepath.Join(dir, "env.yml")
+	for _, p := range []string{flagPath, envPath, filepath.Join(dir, "podlift.yml")} {
+		if err := os.WriteFile(p, []byte("service: myapp\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Run from a subdirectory so the default search has to walk up
+	sub := filepath.Join(dir, "app", "src")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(sub)
+
+	defer func() { configFile = "" }()
+
+	tests := []struct {
+		name    string
+		flag    string
+		env     string
+		want    string
+		wantErr bool
+	}{
+		{name: "flag wins", flag: flagPath, env: envPath, want: flagPath},
+		{name: "env var", env: envPath, want: envPath},
+		{name: "search parents", want: filepath.Join(dir, "podlift.yml")},
+		{name: "missing file", flag: filepath.Join(dir, "missing.yml"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile = tt.flag
+			t.Setenv("PODLIFT_CONFIG", tt.env)
+
+			got, err := resolveConfigPath()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveConfigPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("resolveConfigPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsConfig(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"deploy"}, true},
+		{[]string{"deps", "up"}, true},
+		{[]string{"jobs", "ls"}, true},
+		{[]string{"config"}, true},
+		{[]string{"deps"}, false}, // Only prints help
+		{[]string{"init"}, false},
+		{[]string{"validate"}, false},
+		{[]string{"schema"}, false},
+		{[]string{"secrets", "set"}, false},
+		{[]string{"version"}, false},
+		{[]string{"help"}, false},
+	}
+
+	rootCmd.InitDefaultHelpCmd() // Added by Execute otherwise
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			cmd, _, err := rootCmd.Find(tt.args)
+			if err != nil {
+				t.Fatalf("Find(%v) error = %v", tt.args, err)
+			}
+			if got := needsConfig(cmd); got != tt.want {
+				t.Errorf("needsConfig(%s) = %v, want %v", cmd.CommandPath(), got, tt.want)
+			}
+		})
+	}
+}
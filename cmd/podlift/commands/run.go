@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/deploy"
+	"github.com/ekinertac/podlift/internal/ssh"
+	"github.com/ekinertac/podlift/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	runHost   string
+	runDetach bool
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run <service> [-- <command>...]",
+	Short: "Run a one-off task in a new container from the current release",
+	Long: `Starts a fresh container from the image running on the server, with the
+service's env, volumes and resource limits, and runs a command in it. The
+container is removed when the command exits, and podlift exits with the
+command's exit code.
+
+Use it for migrations and management commands, instead of running them inside
+a replica that serves traffic:
+
+  podlift run web -- python manage.py migrate
+
+Without a command, the service's own command runs. From a terminal, the task
+gets a terminal too, so shells work:
+
+  podlift run web -- bash
+
+Otherwise stdin is piped to the task, and Ctrl-C stops its container.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runRun,
+}
+
+func init() {
+	runCmd.Flags().StringVar(&runHost, "host", "", "Server to run on (default: primary server)")
+	runCmd.Flags().BoolVar(&runDetach, "detach", false, "Start the task in the background and print its container name")
+	rootCmd.AddCommand(runCmd)
+}
+
+// ExitError makes podlift exit with the exit code of a remote command
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// selectServer returns the configured server with the given host, or the primary server
+func selectServer(cfg *config.Config, host string) (*config.Server, error) {
+	if host == "" {
+		server, _, err := cfg.GetPrimaryServer()
+		return server, err
+	}
+	for _, server := range cfg.GetAllServers() {
+		if server.Host == host {
+			return &server.Server, nil
+		}
+	}
+	return nil, fmt.Errorf("no server %s in config", host)
+}
+
+func runRun(cmd *cobra.Command, args []string) error {
+	cfg := loadedConfig
+	server, err := selectServer(cfg, runHost)
+	if err != nil {
+		return err
+	}
+
+	client, err := connectWithTimeout(*server, 30*time.Second)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	opts := deploy.TaskOptions{Service: args[0], Command: args[1:], Detach: runDetach}
+	result, err := runTask(cfg, client, opts)
+	if err != nil {
+		fmt.Println(ui.Error(err.Error()))
+		return err
+	}
+
+	if runDetach {
+		fmt.Println(ui.Success(fmt.Sprintf("Started %s on %s", result.Container, server.Host)))
+		fmt.Println(ui.Info(fmt.Sprintf("Follow it with: ssh %s sudo docker logs -f %s", server.Host, result.Container)))
+		return nil
+	}
+	if result.ExitCode != 0 {
+		return &ExitError{Code: result.ExitCode}
+	}
+	return nil
+}
+
+// runTask runs a task attached to this process's stdin and output, unless it's detached
+func runTask(cfg *config.Config, client ssh.SSHClient, opts deploy.TaskOptions) (deploy.TaskResult, error) {
+	if opts.Detach {
+		return deploy.RunTask(cfg, client, opts, ssh.InteractiveOptions{})
+	}
+
+	streams, restore, err := attachStreams()
+	if err != nil {
+		return deploy.TaskResult{}, err
+	}
+	defer restore()
+
+	// From a terminal Ctrl-C goes to the task; otherwise it stops the task's container
+	if !streams.TTY {
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		defer signal.Stop(interrupt)
+		opts.Interrupt = interrupt
+	}
+
+	return deploy.RunTask(cfg, client, opts, streams)
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var schemaOutput string
+
+var schemaCmd = &cobra.Command{
+	Use:         "schema",
+	Annotations: withoutConfig,
+	Short:       "Print the JSON Schema for podlift.yml",
+	Long: `Print the JSON Schema for podlift.yml.
+
+Use it for editor autocompletion or to validate config in CI, e.g.
+  podlift schema > podlift.schema.json`,
+	RunE: runSchema,
+}
+
+func init() {
+	schemaCmd.Flags().StringVarP(&schemaOutput, "output", "o", "", "Write the schema to a file instead of stdout")
+	rootCmd.AddCommand(schemaCmd)
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	data, err := config.SchemaJSON()
+	if err != nil {
+		return fmt.Errorf("failed to generate schema: %w", err)
+	}
+	data = append(data, '\n')
+
+	if schemaOutput == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(schemaOutput, data, 0644); err != nil {
+		return fmt.Errorf("failed to write schema: %w", err)
+	}
+	return nil
+}
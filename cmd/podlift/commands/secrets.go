@@ -0,0 +1,290 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ekinertac/podlift/internal/secrets"
+	"github.com/ekinertac/podlift/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var secretsCmd = &cobra.Command{
+	Use:         "secrets",
+	Annotations: withoutConfig, // Secrets are edited before the config that uses them resolves
+	Short:       "Manage encrypted secrets",
+	Long: `Manage secrets stored encrypted in secrets.enc next to podlift.yml.
+
+secrets.enc is safe to commit. The key is read from PODLIFT_SECRETS_KEY,
+or from secrets.key (never commit it). Secrets are decrypted in memory at
+deploy time and can be referenced like environment variables: ${SECRET_KEY}`,
+}
+
+var secretsInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create a key and an empty secrets file",
+	Args:  cobra.NoArgs,
+	RunE:  runSecretsInit,
+}
+
+var secretsSetCmd = &cobra.Command{
+	Use:   "set NAME [VALUE]",
+	Short: "Set a secret (reads the value from stdin if omitted)",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE:  runSecretsSet,
+}
+
+var secretsGetCmd = &cobra.Command{
+	Use:   "get NAME",
+	Short: "Print a secret",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSecretsGet,
+}
+
+var secretsEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit secrets in $EDITOR",
+	Args:  cobra.NoArgs,
+	RunE:  runSecretsEdit,
+}
+
+var secretsRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Re-encrypt secrets with a new key",
+	Args:  cobra.NoArgs,
+	RunE:  runSecretsRotate,
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsInitCmd, secretsSetCmd, secretsGetCmd, secretsEditCmd, secretsRotateCmd)
+	rootCmd.AddCommand(secretsCmd)
+}
+
+// secretsDir returns the directory holding podlift.yml, secrets.enc and secrets.key
+func secretsDir() (string, error) {
+	configPath, err := resolveConfigPath()
+	if err != nil {
+		return "", fmt.Errorf("%w (run: podlift init)", err)
+	}
+	return filepath.Dir(configPath), nil
+}
+
+// openSecrets loads the key and decrypts the secrets file
+func openSecrets() (path string, key []byte, values map[string]string, err error) {
+	dir, err := secretsDir()
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	path = filepath.Join(dir, secrets.FileName)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return "", nil, nil, fmt.Errorf("%s not found (run: podlift secrets init)", secrets.FileName)
+	}
+
+	key, err = secrets.LoadKey(dir)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	values, err = secrets.Load(path, key)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return path, key, values, nil
+}
+
+func runSecretsInit(cmd *cobra.Command, args []string) error {
+	dir, err := secretsDir()
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, secrets.FileName)
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", secrets.FileName)
+	}
+
+	// Reuse an existing key (env var or key file), otherwise create one
+	key, err := secrets.LoadKey(dir)
+	if err == secrets.ErrNoKey {
+		if key, err = secrets.GenerateKey(); err != nil {
+			return err
+		}
+		if err := secrets.WriteKey(dir, key); err != nil {
+			return err
+		}
+		fmt.Println(ui.Success(fmt.Sprintf("Created %s", secrets.KeyFileName)))
+	} else if err != nil {
+		return err
+	}
+
+	if err := secrets.Save(path, key, map[string]string{}); err != nil {
+		return err
+	}
+	fmt.Println(ui.Success(fmt.Sprintf("Created %s", secrets.FileName)))
+
+	if err := ensureGitignored(dir, secrets.KeyFileName); err != nil {
+		fmt.Println(ui.Warning(fmt.Sprintf("Could not update .gitignore: %v", err)))
+	}
+
+	fmt.Println()
+	fmt.Println(ui.Info(fmt.Sprintf("Commit %s, never %s", secrets.FileName, secrets.KeyFileName)))
+	fmt.Println(ui.Info(fmt.Sprintf("For CI, set %s to the contents of %s", secrets.KeyEnvVar, secrets.KeyFileName)))
+	return nil
+}
+
+func runSecretsSet(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if err := secrets.ValidateName(name); err != nil {
+		return err
+	}
+
+	path, key, values, err := openSecrets()
+	if err != nil {
+		return err
+	}
+
+	var value string
+	if len(args) == 2 {
+		value = args[1]
+	} else {
+		// Reading from stdin keeps the value out of shell history
+		data, err := io.ReadAll(bufio.NewReader(os.Stdin))
+		if err != nil {
+			return fmt.Errorf("failed to read value: %w", err)
+		}
+		value = strings.TrimRight(string(data), "\r\n")
+	}
+
+	values[name] = value
+	if err := secrets.Save(path, key, values); err != nil {
+		return err
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Set %s", name)))
+	return nil
+}
+
+func runSecretsGet(cmd *cobra.Command, args []string) error {
+	_, _, values, err := openSecrets()
+	if err != nil {
+		return err
+	}
+
+	value, ok := values[args[0]]
+	if !ok {
+		return fmt.Errorf("secret %s not found", args[0])
+	}
+
+	fmt.Println(value)
+	return nil
+}
+
+func runSecretsEdit(cmd *cobra.Command, args []string) error {
+	path, key, values, err := openSecrets()
+	if err != nil {
+		return err
+	}
+
+	// Decrypted copy is readable only by the owner and removed afterwards
+	tmp, err := os.CreateTemp("", "podlift-secrets-*.env")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(secrets.Format(values)); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	tmp.Close()
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	editCmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", tmp.Name())
+	editCmd.Stdin = os.Stdin
+	editCmd.Stdout = os.Stdout
+	editCmd.Stderr = os.Stderr
+	if err := editCmd.Run(); err != nil {
+		return fmt.Errorf("editor failed: %w", err)
+	}
+
+	data, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return fmt.Errorf("failed to read temp file: %w", err)
+	}
+
+	edited, err := secrets.Parse(data)
+	if err != nil {
+		return fmt.Errorf("secrets not saved: %w", err)
+	}
+
+	if err := secrets.Save(path, key, edited); err != nil {
+		return err
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Saved %d secret(s)", len(edited))))
+	return nil
+}
+
+func runSecretsRotate(cmd *cobra.Command, args []string) error {
+	path, key, values, err := openSecrets()
+	if err != nil {
+		return err
+	}
+
+	newKey, err := secrets.GenerateKey()
+	if err != nil {
+		return err
+	}
+
+	if err := secrets.Rotate(filepath.Dir(path), key, newKey, values); err != nil {
+		return err
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Rotated key and re-encrypted %d secret(s)", len(values))))
+	if os.Getenv(secrets.KeyEnvVar) != "" {
+		fmt.Println(ui.Warning(fmt.Sprintf("%s is set and takes precedence over %s", secrets.KeyEnvVar, secrets.KeyFileName)))
+		fmt.Println(ui.Info(fmt.Sprintf("  Update it (and CI) to the new key in %s", secrets.KeyFileName)))
+	}
+	return nil
+}
+
+// ensureGitignored adds a file name to .gitignore in dir if it isn't listed yet
+func ensureGitignored(dir, name string) error {
+	path := filepath.Join(dir, ".gitignore")
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == name || strings.TrimSpace(line) == "/"+name {
+			return nil
+		}
+	}
+
+	content := string(data)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	content += name + "\n"
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return err
+	}
+	fmt.Println(ui.Success(fmt.Sprintf("Added %s to .gitignore", name)))
+	return nil
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/ekinertac/podlift/internal/config"
 	"github.com/ekinertac/podlift/internal/setup"
 	"github.com/ekinertac/podlift/internal/ssh"
 	"github.com/ekinertac/podlift/internal/ui"
@@ -12,14 +11,16 @@ import (
 )
 
 var (
-	setupNoFirewall bool
-	setupNoSecurity bool
+	setupNoFirewall  bool
+	setupNoSecurity  bool
+	setupLogRotation bool
 )
 
 func init() {
 	rootCmd.AddCommand(setupCommand)
 	setupCommand.Flags().BoolVar(&setupNoFirewall, "no-firewall", false, "Skip firewall configuration")
 	setupCommand.Flags().BoolVar(&setupNoSecurity, "no-security", false, "Skip security hardening")
+	setupCommand.Flags().BoolVar(&setupLogRotation, "log-rotation", false, "Make log rotation Docker's default in /etc/docker/daemon.json (restarts Docker)")
 }
 
 var setupCommand = &cobra.Command{
@@ -30,21 +31,7 @@ var setupCommand = &cobra.Command{
 }
 
 func runSetup(cmd *cobra.Command, args []string) error {
-	// Load configuration
-	configPath, err := config.Find()
-	if err != nil {
-		return fmt.Errorf("podlift.yml not found. Run: podlift init")
-	}
-
-	cfg, err := config.Load(configPath)
-	if err != nil {
-		return err
-	}
-
-	// Load environment variables
-	if err := cfg.SubstituteConfigEnvVars(); err != nil {
-		return err
-	}
+	cfg := loadedConfig
 
 	fmt.Println(ui.Title("Setting up servers..."))
 	fmt.Println()
@@ -71,7 +58,7 @@ func runSetup(cmd *cobra.Command, args []string) error {
 		defer sshClient.Close()
 
 		// Connect
-		fmt.Println(ui.Info("[1/4] Connecting to server..."))
+		fmt.Println(ui.Info("[1/5] Connecting to server..."))
 		if err := sshClient.Connect(); err != nil {
 			return fmt.Errorf("failed to connect to %s: %w", server.Host, err)
 		}
@@ -79,7 +66,7 @@ func runSetup(cmd *cobra.Command, args []string) error {
 		fmt.Println()
 
 		// Install Docker
-		fmt.Println(ui.Info("[2/4] Installing Docker..."))
+		fmt.Println(ui.Info("[2/5] Installing Docker..."))
 		if err := setup.InstallDocker(sshClient); err != nil {
 			return fmt.Errorf("Docker installation failed on %s: %w", server.Host, err)
 		}
@@ -87,27 +74,39 @@ func runSetup(cmd *cobra.Command, args []string) error {
 
 		// Configure firewall
 		if !setupNoFirewall {
-			fmt.Println(ui.Info("[3/4] Configuring firewall..."))
+			fmt.Println(ui.Info("[3/5] Configuring firewall..."))
 			if err := setup.ConfigureFirewall(sshClient); err != nil {
 				fmt.Println(ui.Warning(fmt.Sprintf("Firewall configuration failed: %v", err)))
 				fmt.Println(ui.Info("Skip firewall: podlift setup --no-firewall"))
 			}
 			fmt.Println()
 		} else {
-			fmt.Println(ui.Info("[3/4] Skipping firewall configuration (--no-firewall)"))
+			fmt.Println(ui.Info("[3/5] Skipping firewall configuration (--no-firewall)"))
 			fmt.Println()
 		}
 
 		// Apply security
 		if !setupNoSecurity {
-			fmt.Println(ui.Info("[4/4] Applying security settings..."))
+			fmt.Println(ui.Info("[4/5] Applying security settings..."))
 			if err := setup.ApplySecurity(sshClient); err != nil {
 				fmt.Println(ui.Warning(fmt.Sprintf("Security configuration failed: %v", err)))
 				fmt.Println(ui.Info("Skip security: podlift setup --no-security"))
 			}
 			fmt.Println()
 		} else {
-			fmt.Println(ui.Info("[4/4] Skipping security hardening (--no-security)"))
+			fmt.Println(ui.Info("[4/5] Skipping security hardening (--no-security)"))
+			fmt.Println()
+		}
+
+		// Configure log rotation
+		if setupLogRotation {
+			fmt.Println(ui.Info("[5/5] Configuring Docker log rotation..."))
+			if err := setup.ConfigureLogRotation(sshClient, cfg.DaemonLogging()); err != nil {
+				return fmt.Errorf("log rotation failed on %s: %w", server.Host, err)
+			}
+			fmt.Println()
+		} else {
+			fmt.Println(ui.Info("[5/5] Skipping Docker log rotation (enable with --log-rotation)"))
 			fmt.Println()
 		}
 
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/ekinertac/podlift/internal/config"
 	"github.com/ekinertac/podlift/internal/nginx"
 	"github.com/ekinertac/podlift/internal/ssh"
 	"github.com/ekinertac/podlift/internal/ssl"
@@ -47,16 +46,7 @@ var sslStatusCommand = &cobra.Command{
 }
 
 func runSSLSetup(cmd *cobra.Command, args []string) error {
-	// Load configuration
-	configPath, err := config.Find()
-	if err != nil {
-		return fmt.Errorf("podlift.yml not found")
-	}
-
-	cfg, err := config.Load(configPath)
-	if err != nil {
-		return err
-	}
+	cfg := loadedConfig
 
 	// Check if SSL is configured
 	if cfg.Proxy == nil || cfg.Proxy.SSL == "" || cfg.Proxy.SSL == "false" {
@@ -151,16 +141,7 @@ func runSSLSetup(cmd *cobra.Command, args []string) error {
 }
 
 func runSSLRenew(cmd *cobra.Command, args []string) error {
-	// Load config to get server
-	configPath, err := config.Find()
-	if err != nil {
-		return fmt.Errorf("podlift.yml not found")
-	}
-
-	cfg, err := config.Load(configPath)
-	if err != nil {
-		return err
-	}
+	cfg := loadedConfig
 
 	primaryServer, _, err := cfg.GetPrimaryServer()
 	if err != nil {
@@ -186,16 +167,7 @@ func runSSLRenew(cmd *cobra.Command, args []string) error {
 }
 
 func runSSLStatus(cmd *cobra.Command, args []string) error {
-	// Load config
-	configPath, err := config.Find()
-	if err != nil {
-		return fmt.Errorf("podlift.yml not found")
-	}
-
-	cfg, err := config.Load(configPath)
-	if err != nil {
-		return err
-	}
+	cfg := loadedConfig
 
 	if cfg.Domain == "" {
 		return fmt.Errorf("domain not configured")
@@ -5,7 +5,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/docker"
+	"github.com/ekinertac/podlift/internal/shell"
 	"github.com/ekinertac/podlift/internal/ssh"
 	"github.com/ekinertac/podlift/internal/ui"
 	"github.com/spf13/cobra"
@@ -23,11 +24,7 @@ func init() {
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
-	// Load config
-	cfg, err := config.Load("podlift.yml")
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
-	}
+	cfg := loadedConfig
 
 	fmt.Println(ui.Title("Deployment Status"))
 	fmt.Println()
@@ -61,7 +58,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	fmt.Println(ui.Info("Current deployment:"))
 	
 	// List containers
-	listCmd := fmt.Sprintf("sudo docker ps --filter label=podlift.service=%s --format '{{.Names}}\t{{.Image}}\t{{.Status}}'", cfg.Service)
+	listCmd := shell.Join("sudo", "docker", "ps", "--filter", "label=podlift.service="+cfg.Service, "--format", "{{.Names}}\t{{.Image}}\t{{.Status}}\t{{.Label \"podlift.image_id\"}}")
 	output, err := client.Execute(listCmd)
 	if err != nil {
 		fmt.Println(ui.Error("  No deployment found"))
@@ -79,6 +76,9 @@ func runStatus(cmd *cobra.Command, args []string) error {
 					fmt.Println(ui.Success(fmt.Sprintf("  Version: %s", version)))
 				}
 			}
+			if len(parts) >= 4 && parts[3] != "" {
+				fmt.Println(ui.Info(fmt.Sprintf("  Digest: %s", parts[3])))
+			}
 			
 			healthyCount := 0
 			totalCount := len(lines)
@@ -107,6 +107,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 
 	// Show servers status
 	fmt.Println(ui.Info("Servers:"))
+	digests := make(map[string]bool)
 	for _, server := range allServers {
 		srvClient, err := ssh.NewClient(ssh.Config{
 			Host:    server.Host,
@@ -127,7 +128,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		}
 
 		// Count containers on this server
-		countCmd := fmt.Sprintf("sudo docker ps --filter label=podlift.service=%s --format '{{.Names}}' | wc -l", cfg.Service)
+		countCmd := shell.Join("sudo", "docker", "ps", "--filter", "label=podlift.service="+cfg.Service, "--format", "{{.Names}}") + " | wc -l"
 		countOutput, err := srvClient.Execute(countCmd)
 		containerCount := "0"
 		if err == nil {
@@ -143,15 +144,32 @@ func runStatus(cmd *cobra.Command, args []string) error {
 			deps = strings.Join(depList, ", ")
 		}
 
+		// Collect image digests of app containers on this server
+		digestCmd := shell.Join("sudo", "docker", "ps", "--filter", "label=podlift.service="+cfg.Service, "--format", `{{.Label "podlift.image_id"}}`) + " | sort -u"
+		digestOutput, err := srvClient.Execute(digestCmd)
+		var serverDigests []string
+		if err == nil {
+			for _, digest := range splitLines(digestOutput) {
+				digests[digest] = true
+				serverDigests = append(serverDigests, docker.ShortDigest(digest))
+			}
+		}
+
 		status := fmt.Sprintf("  %s - healthy (%s containers", server.Host, containerCount)
 		if deps != "" {
 			status += fmt.Sprintf(", %s", deps)
 		}
 		status += ")"
+		if len(serverDigests) > 0 {
+			status += fmt.Sprintf(" digest %s", strings.Join(serverDigests, ", "))
+		}
 		
 		fmt.Println(ui.Success(status))
 		srvClient.Close()
 	}
+	if len(digests) > 1 {
+		fmt.Println(ui.Warning("Servers are running different image digests (run: podlift deploy)"))
+	}
 	fmt.Println()
 
 	// Show available commands
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -12,12 +13,16 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var validateOffline bool
+
 func init() {
+	validateCommand.Flags().BoolVar(&validateOffline, "offline", false, "Only check the config file against the schema (no git or server checks)")
 	rootCmd.AddCommand(validateCommand)
 }
 
 var validateCommand = &cobra.Command{
-	Use:   "validate",
+	Use:         "validate",
+	Annotations: withoutConfig, // Loads the config itself to report each step
 	Short: "Validate configuration and verify server readiness",
 	Long:  "Performs pre-flight checks before deployment",
 	RunE:  runValidate,
@@ -27,30 +32,17 @@ func runValidate(cmd *cobra.Command, args []string) error {
 	fmt.Println(ui.Title("Validating configuration..."))
 	fmt.Println()
 
-	// 1. Find and load config
-	configPath, err := config.Find()
-	if err != nil {
-		fmt.Println(ui.Error("podlift.yml not found"))
-		fmt.Println()
-		fmt.Println("Run: podlift init")
-		return err
+	if validateOffline {
+		return runValidateOffline()
 	}
 
-	cfg, err := config.Load(configPath)
+	// 1-2. Find and load config, substitute environment variables
+	cfg, err := loadConfig()
 	if err != nil {
-		fmt.Println(ui.Error("Configuration invalid"))
-		fmt.Println()
-		fmt.Println(err.Error())
 		return err
 	}
 	fmt.Println(ui.Success("Configuration valid"))
 
-	// 2. Load and substitute environment variables
-	if err := cfg.SubstituteConfigEnvVars(); err != nil {
-		fmt.Println(ui.Error("Failed to load environment variables"))
-		return err
-	}
-
 	// 3. Check git state
 	if git.IsRepository() {
 		if err := git.RequireCleanState(); err != nil {
@@ -177,3 +169,39 @@ func splitLines(s string) []string {
 	return lines
 }
 
+// runValidateOffline checks podlift.yml (and the environment overlay) against the JSON Schema
+// and the config rules, without connecting to any server
+func runValidateOffline() error {
+	configPath, err := resolveConfigPath()
+	if err != nil {
+		fmt.Println(ui.Error(err.Error()))
+		return err
+	}
+
+	files := []string{configPath}
+	if environment != "" {
+		files = append(files, config.OverlayPath(configPath, environment))
+	}
+
+	for i, file := range files {
+		// Overlays only contain overrides, so required fields come from the base file
+		if err := config.ValidateSchema(file, i > 0); err != nil {
+			fmt.Println(ui.Error(fmt.Sprintf("%s does not match schema", filepath.Base(file))))
+			fmt.Println()
+			fmt.Println(err.Error())
+			return err
+		}
+		fmt.Println(ui.Success(fmt.Sprintf("%s matches schema", filepath.Base(file))))
+	}
+
+	// No .env files, secrets or unset variables needed: CI often has none of them
+	if err := config.CheckEnvironment(configPath, environment); err != nil {
+		fmt.Println(ui.Error("Configuration invalid"))
+		fmt.Println()
+		fmt.Println(err.Error())
+		return err
+	}
+	fmt.Println(ui.Success("Configuration valid"))
+
+	return nil
+}
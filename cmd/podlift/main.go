@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/ekinertac/podlift/cmd/podlift/commands"
@@ -8,7 +9,11 @@ import (
 
 func main() {
 	if err := commands.Execute(); err != nil {
+		// podlift run, exec and jobs run exit with the exit code of the remote command
+		var exitErr *commands.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 }
-
@@ -0,0 +1,208 @@
+// Package backup backs up and restores dependency containers over SSH
+//
+// Backups stream from the server to a local directory, so no space is needed
+// on the server. Each backup file gets a .sha256 sidecar in sha256sum format.
+package backup
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/ssh"
+)
+
+// timeFormat is the timestamp in backup file names; it sorts chronologically
+const timeFormat = "20060102-150405"
+
+// Target is a dependency container to back up or restore
+type Target struct {
+	Name      string // Dependency name, e.g. postgres
+	Container string // Container name, e.g. myapp-postgres
+	Image     string
+	Env       map[string]string
+	Volume    string // Volume name or host path, e.g. postgres_data
+	MountPath string // Where the volume is mounted in the container
+}
+
+// NewTarget returns the backup target of a dependency
+func NewTarget(cfg *config.Config, name string) (Target, error) {
+	dep, ok := cfg.Dependencies[name]
+	if !ok {
+		return Target{}, fmt.Errorf("unknown dependency '%s'", name)
+	}
+
+	t := Target{
+		Name:      name,
+		Container: fmt.Sprintf("%s-%s", cfg.Service, name),
+		Image:     dep.Image,
+		Env:       dep.Env,
+	}
+	if dep.Volume != "" {
+		t.Volume, t.MountPath, _ = strings.Cut(dep.Volume, ":")
+		t.MountPath, _, _ = strings.Cut(t.MountPath, ":") // Drop :ro and similar
+	}
+	return t, nil
+}
+
+// Prefix returns the file name prefix of the target's backups, e.g. myapp-postgres-
+func (t Target) Prefix() string {
+	return t.Container + "-"
+}
+
+// Run streams a backup of target into dir and returns the path of the file
+// The file is written under a temporary name and only renamed once the backup succeeded.
+func Run(client ssh.SSHClient, strategy Strategy, t Target, dir string, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	path := filepath.Join(dir, t.Prefix()+now.UTC().Format(timeFormat)+strategy.Extension())
+	partial := path + ".partial"
+
+	file, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create backup file: %w", err)
+	}
+
+	hash := sha256.New()
+	err = strategy.Backup(client, t, io.MultiWriter(file, hash))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(partial)
+		return "", err
+	}
+
+	if err := os.Rename(partial, path); err != nil {
+		os.Remove(partial)
+		return "", fmt.Errorf("failed to save backup: %w", err)
+	}
+	if err := writeChecksum(path, hex.EncodeToString(hash.Sum(nil))); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// Restore verifies a backup file and streams it into the target
+func Restore(client ssh.SSHClient, strategy Strategy, t Target, path string) error {
+	if !strings.HasSuffix(path, strategy.Extension()) {
+		return fmt.Errorf("%s is not a %s backup (expected a %s file)", filepath.Base(path), strategy.Name(), strategy.Extension())
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer file.Close()
+
+	return strategy.Restore(client, t, file)
+}
+
+// Verify checks a backup file against its .sha256 sidecar
+// Returns os.ErrNotExist (wrapped) if the backup has no checksum file.
+func Verify(path string) error {
+	want, err := readChecksum(path)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+	if got := hex.EncodeToString(hash.Sum(nil)); got != want {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", filepath.Base(path), got, want)
+	}
+	return nil
+}
+
+// List returns the backups of a target in dir, oldest first
+func List(dir string, t Target, strategy Strategy) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, t.Prefix()) || !strings.HasSuffix(name, strategy.Extension()) {
+			continue
+		}
+		// Only names with a timestamp, so myapp-postgres- doesn't match myapp-postgres-replica-...
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, t.Prefix()), strategy.Extension())
+		if _, err := time.Parse(timeFormat, stamp); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(dir, name))
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// Prune deletes all but the newest keep backups of a target and returns the deleted files
+// keep <= 0 keeps everything.
+func Prune(dir string, t Target, strategy Strategy, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+
+	backups, err := List(dir, t, strategy)
+	if err != nil || len(backups) <= keep {
+		return nil, err
+	}
+
+	removed := backups[:len(backups)-keep]
+	for _, path := range removed {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove old backup: %w", err)
+		}
+		os.Remove(path + ".sha256")
+	}
+	return removed, nil
+}
+
+// writeChecksum writes a sha256sum compatible sidecar next to path
+func writeChecksum(path, sum string) error {
+	line := fmt.Sprintf("%s  %s\n", sum, filepath.Base(path))
+	if err := os.WriteFile(path+".sha256", []byte(line), 0600); err != nil {
+		return fmt.Errorf("failed to write checksum: %w", err)
+	}
+	return nil
+}
+
+// readChecksum reads the hash from the sidecar of path
+func readChecksum(path string) (string, error) {
+	file, err := os.Open(path + ".sha256")
+	if err != nil {
+		return "", fmt.Errorf("no checksum for %s: %w", filepath.Base(path), err)
+	}
+	defer file.Close()
+
+	line, _ := bufio.NewReader(file).ReadString('\n')
+	sum, _, _ := strings.Cut(strings.TrimSpace(line), " ")
+	if len(sum) != sha256.Size*2 {
+		return "", fmt.Errorf("invalid checksum file %s.sha256", filepath.Base(path))
+	}
+	return sum, nil
+}
@@ -0,0 +1,175 @@
+package backup
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/ssh"
+)
+
+func TestNewTarget(t *testing.T) {
+	cfg := &config.Config{
+		Service: "myapp",
+		Dependencies: map[string]config.Dependency{
+			"postgres": {Image: "postgres:16", Volume: "pgdata:/var/lib/postgresql/data:rw"},
+		},
+	}
+
+	target, err := NewTarget(cfg, "postgres")
+	if err != nil {
+		t.Fatalf("NewTarget() error = %v", err)
+	}
+	if target.Container != "myapp-postgres" || target.Volume != "pgdata" || target.MountPath != "/var/lib/postgresql/data" {
+		t.Errorf("NewTarget() = %+v", target)
+	}
+
+	if _, err := NewTarget(cfg, "mysql"); err == nil {
+		t.Error("NewTarget() should fail for an unknown dependency")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		image      string
+		volume     string
+		configured string
+		want       string
+		wantErr    bool
+	}{
+		{image: "postgres:16", want: "postgres"},
+		{image: "postgis/postgis:16-3.4", want: "postgres"},
+		{image: "mariadb:11@sha256:abc", want: "mysql"},
+		{image: "registry.example.com:5000/mysql:8", want: "mysql"},
+		{image: "redis:7-alpine", want: "redis"},
+		{image: "valkey/valkey:8", want: "redis"},
+		{image: "minio/minio", volume: "minio_data", want: "volume"},
+		{image: "postgres:16", configured: "volume", volume: "pgdata", want: "volume"},
+		{image: "minio/minio", wantErr: true},
+		{image: "postgres:16", configured: "pg", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		strategy, err := Detect(Target{Image: tt.image, Volume: tt.volume}, tt.configured)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Detect(%s) error = %v, wantErr %v", tt.image, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && strategy.Name() != tt.want {
+			t.Errorf("Detect(%s) = %s, want %s", tt.image, strategy.Name(), tt.want)
+		}
+	}
+}
+
+// streamingMock answers ExecuteWithOutput with output
+func streamingMock(output string, err error, commands *[]string) *ssh.MockClient {
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		*commands = append(*commands, cmd)
+		return "", nil
+	}
+	client.ExecuteWithOutputFunc = func(cmd string, stdout, stderr io.Writer) error {
+		*commands = append(*commands, cmd)
+		io.WriteString(stdout, output)
+		if err != nil {
+			io.WriteString(stderr, "pg_dump: error: connection failed")
+		}
+		return err
+	}
+	return client
+}
+
+func TestRunAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	target := Target{Name: "postgres", Container: "myapp-postgres", Env: map[string]string{"POSTGRES_USER": "app"}}
+	now := time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)
+
+	var commands []string
+	path, err := Run(streamingMock("PGDMP data", nil, &commands), postgresStrategy{}, target, dir, now)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if want := filepath.Join(dir, "myapp-postgres-20261018-030000.dump"); path != want {
+		t.Errorf("Run() = %s, want %s", path, want)
+	}
+	if want := "sudo docker exec myapp-postgres pg_dump -U app -Fc app"; commands[0] != want {
+		t.Errorf("command = %q, want %q", commands[0], want)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "PGDMP data" {
+		t.Errorf("backup contains %q", data)
+	}
+
+	sidecar, _ := os.ReadFile(path + ".sha256")
+	if !strings.HasSuffix(string(sidecar), "  myapp-postgres-20261018-030000.dump\n") {
+		t.Errorf("checksum file = %q, want sha256sum format", sidecar)
+	}
+	if err := Verify(path); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	os.WriteFile(path, []byte("tampered"), 0600)
+	if err := Verify(path); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Verify() = %v, want checksum mismatch", err)
+	}
+
+	os.Remove(path + ".sha256")
+	if err := Verify(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Verify() = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestRun_FailureLeavesNoFile(t *testing.T) {
+	dir := t.TempDir()
+	target := Target{Name: "postgres", Container: "myapp-postgres"}
+
+	var commands []string
+	_, err := Run(streamingMock("partial", errors.New("command failed"), &commands), postgresStrategy{}, target, dir, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "connection failed") {
+		t.Fatalf("Run() error = %v, want stderr in the error", err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("failed backup left files: %v", entries)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	target := Target{Container: "myapp-postgres"}
+
+	files := []string{
+		"myapp-postgres-20261015-030000.dump",
+		"myapp-postgres-20261016-030000.dump",
+		"myapp-postgres-20261017-030000.dump",
+		"myapp-postgres-20261018-030000.dump",
+		"myapp-postgres-replica-20261001-030000.dump", // Another dependency
+		"myapp-postgres-20261001-030000.sql.gz",       // Another strategy
+	}
+	for _, name := range files {
+		os.WriteFile(filepath.Join(dir, name), nil, 0600)
+		os.WriteFile(filepath.Join(dir, name+".sha256"), nil, 0600)
+	}
+
+	removed, err := Prune(dir, target, postgresStrategy{}, 2)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if len(removed) != 2 || filepath.Base(removed[0]) != files[0] || filepath.Base(removed[1]) != files[1] {
+		t.Errorf("Prune() removed %v, want the two oldest", removed)
+	}
+
+	for i, name := range files {
+		_, err := os.Stat(filepath.Join(dir, name))
+		_, sumErr := os.Stat(filepath.Join(dir, name+".sha256"))
+		if kept := i >= 2; kept != (err == nil) || kept != (sumErr == nil) {
+			t.Errorf("%s: kept = %v, want %v", name, err == nil, kept)
+		}
+	}
+}
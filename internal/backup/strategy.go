@@ -0,0 +1,286 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ekinertac/podlift/internal/docker"
+	"github.com/ekinertac/podlift/internal/shell"
+	"github.com/ekinertac/podlift/internal/ssh"
+)
+
+// Strategy backs up and restores one kind of dependency
+type Strategy interface {
+	Name() string
+	Extension() string // File extension of backups, e.g. .dump
+	Backup(client ssh.SSHClient, t Target, w io.Writer) error
+	Restore(client ssh.SSHClient, t Target, r io.Reader) error
+}
+
+// Strategies are the available strategies by name
+var Strategies = map[string]Strategy{
+	"postgres": postgresStrategy{},
+	"mysql":    mysqlStrategy{},
+	"redis":    redisStrategy{},
+	"volume":   volumeStrategy{},
+}
+
+// imageStrategies maps image names (without registry, owner and tag) to strategies
+var imageStrategies = map[string]string{
+	"postgres":           "postgres",
+	"postgis":            "postgres",
+	"pgvector":           "postgres",
+	"timescaledb":        "postgres",
+	"mysql":              "mysql",
+	"mariadb":            "mysql",
+	"percona-server":     "mysql",
+	"redis":              "redis",
+	"redis-stack-server": "redis",
+	"valkey":             "redis",
+}
+
+// volumeImage is the image used to read and write volumes
+const volumeImage = "alpine:3"
+
+// Detect returns the strategy for a target: the configured one, one matching its image,
+// or a volume tarball if it has a volume
+func Detect(t Target, configured string) (Strategy, error) {
+	if configured != "" {
+		strategy, ok := Strategies[configured]
+		if !ok {
+			return nil, fmt.Errorf("unknown backup strategy '%s' (use postgres, mysql, redis or volume)", configured)
+		}
+		return strategy, nil
+	}
+
+	if name, ok := imageStrategies[docker.ParseImageReference(t.Image).Name()]; ok {
+		return Strategies[name], nil
+	}
+	if t.Volume != "" {
+		return Strategies["volume"], nil
+	}
+	return nil, fmt.Errorf("no backup strategy for %s: it has no volume (set backup: to postgres, mysql, redis or volume)", t.Image)
+}
+
+// stream runs a command on the server and copies its output to w
+func stream(client ssh.SSHClient, cmd string, w io.Writer) error {
+	var stderr bytes.Buffer
+	if err := client.ExecuteWithOutput(cmd, w, &stderr); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// envOr returns an env var of the target, or a default
+func (t Target) envOr(key, fallback string) string {
+	if value := t.Env[key]; value != "" {
+		return value
+	}
+	return fallback
+}
+
+// postgresStrategy uses pg_dump's custom format, which is compressed and restored with pg_restore
+type postgresStrategy struct{}
+
+func (postgresStrategy) Name() string      { return "postgres" }
+func (postgresStrategy) Extension() string { return ".dump" }
+
+func (postgresStrategy) credentials(t Target) (user, db string) {
+	user = t.envOr("POSTGRES_USER", "postgres")
+	return user, t.envOr("POSTGRES_DB", user)
+}
+
+func (s postgresStrategy) Backup(client ssh.SSHClient, t Target, w io.Writer) error {
+	user, db := s.credentials(t)
+	cmd := shell.Join("sudo", "docker", "exec", t.Container, "pg_dump", "-U", user, "-Fc", db)
+	if err := stream(client, cmd, w); err != nil {
+		return fmt.Errorf("pg_dump failed: %w", err)
+	}
+	return nil
+}
+
+func (s postgresStrategy) Restore(client ssh.SSHClient, t Target, r io.Reader) error {
+	user, db := s.credentials(t)
+	cmd := shell.Join("sudo", "docker", "exec", "-i", t.Container,
+		"pg_restore", "-U", user, "-d", db, "--clean", "--if-exists", "--no-owner")
+	if output, err := client.ExecuteWithInput(cmd, r); err != nil {
+		return fmt.Errorf("pg_restore failed: %w\n%s", err, output)
+	}
+	return nil
+}
+
+// mysqlStrategy dumps all databases as SQL, gzipped locally so the dump's exit status isn't lost in a pipe
+type mysqlStrategy struct{}
+
+func (mysqlStrategy) Name() string      { return "mysql" }
+func (mysqlStrategy) Extension() string { return ".sql.gz" }
+
+// mysqlScript runs the first available client (MariaDB 11 only ships mariadb-*) as root
+// The password is read from the container's environment, so it never appears in a command line.
+func mysqlScript(clients, args string) string {
+	return fmt.Sprintf(`exec "$(command -v %s | head -n 1)" %s -uroot -p"${MYSQL_ROOT_PASSWORD:-$MARIADB_ROOT_PASSWORD}"`, clients, args)
+}
+
+func (mysqlStrategy) Backup(client ssh.SSHClient, t Target, w io.Writer) error {
+	script := mysqlScript("mariadb-dump mysqldump", "--all-databases --single-transaction --routines --events --triggers")
+	cmd := shell.Join("sudo", "docker", "exec", t.Container, "sh", "-c", script)
+
+	gz := gzip.NewWriter(w)
+	if err := stream(client, cmd, gz); err != nil {
+		return fmt.Errorf("mysqldump failed: %w", err)
+	}
+	return gz.Close()
+}
+
+func (mysqlStrategy) Restore(client ssh.SSHClient, t Target, r io.Reader) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("backup is not gzipped: %w", err)
+	}
+	defer gz.Close()
+
+	cmd := shell.Join("sudo", "docker", "exec", "-i", t.Container, "sh", "-c", mysqlScript("mariadb mysql", ""))
+	if output, err := client.ExecuteWithInput(cmd, gz); err != nil {
+		return fmt.Errorf("mysql restore failed: %w\n%s", err, output)
+	}
+	return nil
+}
+
+// redisStrategy triggers BGSAVE and copies the RDB file
+type redisStrategy struct{}
+
+func (redisStrategy) Name() string      { return "redis" }
+func (redisStrategy) Extension() string { return ".rdb" }
+
+// redisBackupScript waits for a new BGSAVE to finish (up to 10 minutes) and prints the RDB file
+const redisBackupScript = `before=$(redis-cli LASTSAVE); redis-cli BGSAVE >/dev/null; i=0
+while [ "$(redis-cli LASTSAVE)" = "$before" ]; do
+  i=$((i+1)); if [ $i -gt 600 ]; then echo "BGSAVE did not finish" >&2; exit 1; fi; sleep 1
+done
+exec cat "$(redis-cli CONFIG GET dir | tail -n 1)/$(redis-cli CONFIG GET dbfilename | tail -n 1)"`
+
+func (redisStrategy) Backup(client ssh.SSHClient, t Target, w io.Writer) error {
+	cmd := shell.Join("sudo", "docker", "exec", t.Container, "sh", "-c", redisBackupScript)
+	if err := stream(client, cmd, w); err != nil {
+		return fmt.Errorf("redis backup failed: %w", err)
+	}
+	return nil
+}
+
+// Restore replaces the RDB file while redis is stopped; redis loads it on start
+func (redisStrategy) Restore(client ssh.SSHClient, t Target, r io.Reader) error {
+	config := func(key string) (string, error) {
+		output, err := client.Execute(shell.Join("sudo", "docker", "exec", t.Container, "redis-cli", "CONFIG", "GET", key))
+		if err != nil {
+			return "", fmt.Errorf("failed to read redis %s: %w", key, err)
+		}
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		return strings.TrimSpace(lines[len(lines)-1]), nil
+	}
+
+	appendOnly, err := config("appendonly")
+	if err != nil {
+		return err
+	}
+	if appendOnly == "yes" {
+		return fmt.Errorf("appendonly is enabled: redis would load its AOF instead of the restored RDB")
+	}
+	dir, err := config("dir")
+	if err != nil {
+		return err
+	}
+	dbfilename, err := config("dbfilename")
+	if err != nil {
+		return err
+	}
+
+	// docker cp reads a tar archive, and a tar header needs the file size up front
+	tmp, err := os.CreateTemp("", "podlift-redis-*.rdb")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := tw.WriteHeader(&tar.Header{Name: dbfilename, Mode: 0644, Size: size})
+		if err == nil {
+			_, err = io.Copy(tw, tmp)
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	return withStopped(client, t, func() error {
+		if output, err := client.ExecuteWithInput(shell.Join("sudo", "docker", "cp", "-", t.Container+":"+dir), pr); err != nil {
+			return fmt.Errorf("failed to copy RDB file: %w\n%s", err, output)
+		}
+		return nil
+	})
+}
+
+// volumeStrategy archives the dependency's volume while the container is stopped
+type volumeStrategy struct{}
+
+func (volumeStrategy) Name() string      { return "volume" }
+func (volumeStrategy) Extension() string { return ".tar.gz" }
+
+func (volumeStrategy) Backup(client ssh.SSHClient, t Target, w io.Writer) error {
+	if t.Volume == "" {
+		return fmt.Errorf("%s has no volume", t.Name)
+	}
+
+	cmd := shell.Join("sudo", "docker", "run", "--rm", "-v", t.Volume+":/volume:ro", volumeImage, "tar", "czf", "-", "-C", "/volume", ".")
+	return withStopped(client, t, func() error {
+		if err := stream(client, cmd, w); err != nil {
+			return fmt.Errorf("volume backup failed: %w", err)
+		}
+		return nil
+	})
+}
+
+func (volumeStrategy) Restore(client ssh.SSHClient, t Target, r io.Reader) error {
+	if t.Volume == "" {
+		return fmt.Errorf("%s has no volume", t.Name)
+	}
+
+	cmd := shell.Join("sudo", "docker", "run", "--rm", "-i", "-v", t.Volume+":/volume", volumeImage,
+		"sh", "-c", "find /volume -mindepth 1 -delete && tar xzf - -C /volume")
+	return withStopped(client, t, func() error {
+		if output, err := client.ExecuteWithInput(cmd, r); err != nil {
+			return fmt.Errorf("volume restore failed: %w\n%s", err, output)
+		}
+		return nil
+	})
+}
+
+// withStopped runs fn while the target container is stopped, and starts it again afterwards
+func withStopped(client ssh.SSHClient, t Target, fn func() error) error {
+	if output, err := client.Execute(shell.Join("sudo", "docker", "stop", t.Container)); err != nil {
+		return fmt.Errorf("failed to stop %s: %w\n%s", t.Container, err, output)
+	}
+
+	err := fn()
+
+	if output, startErr := client.Execute(shell.Join("sudo", "docker", "start", t.Container)); startErr != nil && err == nil {
+		err = fmt.Errorf("failed to start %s: %w\n%s", t.Container, startErr, output)
+	}
+	return err
+}
@@ -0,0 +1,119 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ekinertac/podlift/internal/ssh"
+)
+
+func TestMySQLStrategy_RoundTrip(t *testing.T) {
+	target := Target{Name: "mysql", Container: "myapp-mysql"}
+	dump := "CREATE TABLE users (id int);\n"
+
+	var commands []string
+	var backup bytes.Buffer
+	if err := (mysqlStrategy{}).Backup(streamingMock(dump, nil, &commands), target, &backup); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+	if !strings.Contains(commands[0], "mariadb-dump mysqldump") || strings.Contains(commands[0], "secret") {
+		t.Errorf("backup command = %s", commands[0])
+	}
+
+	// Compressed locally
+	gz, err := gzip.NewReader(bytes.NewReader(backup.Bytes()))
+	if err != nil {
+		t.Fatalf("backup is not gzipped: %v", err)
+	}
+	if data, _ := io.ReadAll(gz); string(data) != dump {
+		t.Errorf("backup contains %q", data)
+	}
+
+	// And decompressed before it's sent back
+	var restored string
+	client := ssh.NewMockClient()
+	client.ExecuteWithInputFunc = func(cmd string, stdin io.Reader) (string, error) {
+		data, _ := io.ReadAll(stdin)
+		restored = string(data)
+		return "", nil
+	}
+	if err := (mysqlStrategy{}).Restore(client, target, &backup); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if restored != dump {
+		t.Errorf("restored %q, want %q", restored, dump)
+	}
+}
+
+func TestRedisStrategy_Restore(t *testing.T) {
+	target := Target{Name: "redis", Container: "myapp-redis"}
+
+	config := map[string]string{"appendonly": "no", "dir": "/data", "dbfilename": "dump.rdb"}
+	var commands []string
+	var archive bytes.Buffer
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		commands = append(commands, cmd)
+		if strings.Contains(cmd, "CONFIG GET") {
+			key := cmd[strings.LastIndex(cmd, " ")+1:]
+			return key + "\n" + config[key] + "\n", nil
+		}
+		return "", nil
+	}
+	client.ExecuteWithInputFunc = func(cmd string, stdin io.Reader) (string, error) {
+		commands = append(commands, cmd)
+		io.Copy(&archive, stdin)
+		return "", nil
+	}
+
+	if err := (redisStrategy{}).Restore(client, target, strings.NewReader("REDIS0011")); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	want := []string{
+		"sudo docker stop myapp-redis",
+		"sudo docker cp - myapp-redis:/data",
+		"sudo docker start myapp-redis",
+	}
+	if got := commands[len(commands)-3:]; strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("commands = %q, want %q", got, want)
+	}
+
+	tr := tar.NewReader(&archive)
+	header, err := tr.Next()
+	if err != nil {
+		t.Fatalf("invalid tar archive: %v", err)
+	}
+	if data, _ := io.ReadAll(tr); header.Name != "dump.rdb" || string(data) != "REDIS0011" {
+		t.Errorf("archive has %s = %q", header.Name, data)
+	}
+
+	// With AOF enabled redis would ignore the RDB file
+	config["appendonly"] = "yes"
+	if err := (redisStrategy{}).Restore(client, target, strings.NewReader("REDIS0011")); err == nil {
+		t.Error("Restore() should refuse when appendonly is enabled")
+	}
+}
+
+func TestVolumeStrategy_Backup(t *testing.T) {
+	target := Target{Name: "minio", Container: "myapp-minio", Volume: "minio_data"}
+
+	var commands []string
+	var out bytes.Buffer
+	if err := (volumeStrategy{}).Backup(streamingMock("tarball", nil, &commands), target, &out); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	want := []string{
+		"sudo docker stop myapp-minio",
+		"sudo docker run --rm -v minio_data:/volume:ro alpine:3 tar czf - -C /volume .",
+		"sudo docker start myapp-minio",
+	}
+	if strings.Join(commands, "\n") != strings.Join(want, "\n") {
+		t.Errorf("commands = %q, want %q", commands, want)
+	}
+}
@@ -2,9 +2,16 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ekinertac/podlift/internal/docker"
+	"github.com/ekinertac/podlift/internal/shell"
 	"gopkg.in/yaml.v3"
 )
 
@@ -21,9 +28,12 @@ type Config struct {
 	Proxy        *ProxyConfig           `yaml:"proxy,omitempty"`
 	Hooks        *HooksConfig           `yaml:"hooks,omitempty"`
 	EnvFile      string                 `yaml:"env_file,omitempty"`
+	Logging      *LoggingConfig         `yaml:"logging,omitempty"` // Default logging of services and dependencies
+	Jobs         map[string]Job         `yaml:"jobs,omitempty"`    // Commands run on a cron schedule (see jobs.go)
 	
 	// Internal fields
-	configPath string // Path to the config file (not serialized)
+	configPath  string // Path to the config file (not serialized)
+	environment string // Active environment overlay, e.g. "staging" (not serialized)
 }
 
 // ServersConfig handles both list and map formats for servers
@@ -32,27 +42,28 @@ type ServersConfig struct {
 }
 
 // UnmarshalYAML implements custom YAML unmarshaling
-func (s *ServersConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
-	s.servers = make(map[string][]Server)
-	
-	// Try to unmarshal as map first (role-based)
-	var mapData map[string][]Server
-	if err := unmarshal(&mapData); err == nil {
+func (s *ServersConfig) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.MappingNode:
+		// Role-based: map of role → servers
+		var mapData map[string][]Server
+		if err := value.Decode(&mapData); err != nil {
+			return err
+		}
 		s.servers = mapData
-		return nil
-	}
-	
-	// If that fails, try as list (simple format)
-	var listData []Server
-	if err := unmarshal(&listData); err == nil {
-		// Convert list to map with "web" role
+	case yaml.SequenceNode:
+		// Simple format: list of servers in the "web" role
+		var listData []Server
+		if err := value.Decode(&listData); err != nil {
+			return err
+		}
 		s.servers = map[string][]Server{
 			"web": listData,
 		}
-		return nil
+	default:
+		return fmt.Errorf("line %d: servers must be either a list or a map", value.Line)
 	}
-	
-	return fmt.Errorf("servers must be either a list or a map")
+	return nil
 }
 
 // Get returns the servers map
@@ -81,15 +92,24 @@ type Server struct {
 	SSHKey  string   `yaml:"ssh_key,omitempty"`
 	Port    int      `yaml:"port,omitempty"`
 	Labels  []string `yaml:"labels,omitempty"`
+	PrivateIP string `yaml:"private_ip,omitempty"` // Address other servers use to reach dependencies here
 }
 
 // RegistryConfig contains Docker registry configuration
 type RegistryConfig struct {
-	Server   string `yaml:"server,omitempty"`
-	Username string `yaml:"username,omitempty"`
-	Password string `yaml:"password,omitempty"`
+	Server           string `yaml:"server,omitempty"`
+	Username         string `yaml:"username,omitempty"`
+	Password         string `yaml:"password,omitempty"`
+	PasswordCommand  string `yaml:"password_command,omitempty"`  // Local command that prints the password
+	CredentialHelper string `yaml:"credential_helper,omitempty"` // Docker credential helper (e.g. "ecr-login")
 }
 
+// DefaultRestartPolicy brings containers back after a reboot unless they were stopped on purpose
+const DefaultRestartPolicy = "unless-stopped"
+
+// DefaultBindAddress keeps published dependency ports off public interfaces
+const DefaultBindAddress = "127.0.0.1"
+
 // Dependency represents a service dependency (postgres, redis, etc.)
 type Dependency struct {
 	Image     string            `yaml:"image"`
@@ -97,10 +117,27 @@ type Dependency struct {
 	Role      string            `yaml:"role,omitempty"`     // Server role (e.g., "db", "cache")
 	Labels    []string          `yaml:"labels,omitempty"`   // Match servers with these labels
 	Port      int               `yaml:"port,omitempty"`
+	Publish   bool              `yaml:"publish,omitempty"`      // Publish port on the host (default: only on the private network)
+	BindAddress string          `yaml:"bind_address,omitempty"` // Host address for a published port (default: 127.0.0.1)
 	Volume    string            `yaml:"volume,omitempty"`
 	Env       map[string]string `yaml:"env,omitempty"`
 	Command   string            `yaml:"command,omitempty"`
 	Options   map[string]string `yaml:"options,omitempty"`
+	Exports   map[string]string `yaml:"exports,omitempty"` // Env injected into services, e.g. DATABASE_URL with {{host}}:{{port}}
+	Backup    string            `yaml:"backup,omitempty"`  // Backup strategy (default: detected from the image)
+	Healthcheck *DependencyHealthcheck `yaml:"healthcheck,omitempty"` // Probe run before the app starts (default: built in for known images)
+	DependsOn DependsOn         `yaml:"depends_on,omitempty"` // Dependencies started first
+}
+
+// DependencyHealthcheck is how podlift checks that a dependency accepts connections
+// Without command or port, a built-in probe for well-known images is used.
+type DependencyHealthcheck struct {
+	Command  string `yaml:"command,omitempty"`  // Run in the container with sh -c, e.g. pg_isready -h 127.0.0.1
+	Port     int    `yaml:"port,omitempty"`     // TCP port to connect to on the private network
+	Interval string `yaml:"interval,omitempty"` // Time between probes (default: 2s)
+	Timeout  string `yaml:"timeout,omitempty"`  // Time limit of one probe (default: 5s)
+	Retries  int    `yaml:"retries,omitempty"`  // Probes before giving up (default: 30)
+	Enabled  *bool  `yaml:"enabled,omitempty"`
 }
 
 // Service represents an application service
@@ -108,20 +145,48 @@ type Service struct {
 	Port       int                   `yaml:"port,omitempty"`
 	Replicas   int                   `yaml:"replicas,omitempty"`
 	Command    string                `yaml:"command,omitempty"`
+	Restart    string                `yaml:"restart,omitempty"` // Docker restart policy (default: unless-stopped)
 	Healthcheck *HealthcheckConfig   `yaml:"healthcheck,omitempty"`
 	Env        map[string]string     `yaml:"env,omitempty"`
+	EnvFile    []string              `yaml:"env_file,omitempty"` // Local .env files merged into env
 	Volumes    []string              `yaml:"volumes,omitempty"`
 	Options    map[string]string     `yaml:"options,omitempty"`
+	DependsOn  DependsOn             `yaml:"depends_on,omitempty"` // Services and dependencies started first
+
+	// Resource limits and runtime options (see runtime.go)
+	Memory          string            `yaml:"memory,omitempty"`            // e.g. 512m, 1g
+	CPUs            float64           `yaml:"cpus,omitempty"`              // e.g. 0.5
+	PidsLimit       int               `yaml:"pids_limit,omitempty"`        // -1 for unlimited
+	Ulimits         map[string]string `yaml:"ulimits,omitempty"`           // e.g. nofile: 65536 or "1024:65536"
+	User            string            `yaml:"user,omitempty"`              // user[:group]
+	ReadOnly        bool              `yaml:"read_only,omitempty"`         // Read-only root filesystem
+	CapAdd          []string          `yaml:"cap_add,omitempty"`
+	CapDrop         []string          `yaml:"cap_drop,omitempty"`
+	StopSignal      string            `yaml:"stop_signal,omitempty"`       // e.g. SIGQUIT
+	StopGracePeriod string            `yaml:"stop_grace_period,omitempty"` // e.g. 30s
+	Logging         *LoggingConfig    `yaml:"logging,omitempty"`
 }
 
 // HealthcheckConfig contains health check configuration
+// Path is checked over HTTP by podlift during deploys; Command becomes a docker
+// HEALTHCHECK run inside the container, using the same interval, timeout and retries.
 type HealthcheckConfig struct {
-	Path     string   `yaml:"path,omitempty"`
-	Expect   []int    `yaml:"expect,omitempty"`
-	Timeout  string   `yaml:"timeout,omitempty"`
-	Interval string   `yaml:"interval,omitempty"`
-	Retries  int      `yaml:"retries,omitempty"`
-	Enabled  *bool    `yaml:"enabled,omitempty"` // Use pointer to distinguish unset from false
+	Path        string   `yaml:"path,omitempty"`
+	Expect      []int    `yaml:"expect,omitempty"`
+	Command     string   `yaml:"command,omitempty"` // e.g. curl -f http://localhost:8000/health
+	Timeout     string   `yaml:"timeout,omitempty"`
+	Interval    string   `yaml:"interval,omitempty"`
+	StartPeriod string   `yaml:"start_period,omitempty"`
+	Retries     int      `yaml:"retries,omitempty"`
+	Enabled     *bool    `yaml:"enabled,omitempty"` // Use pointer to distinguish unset from false
+}
+
+// LoggingConfig selects the docker logging driver of a container (see logging.go)
+type LoggingConfig struct {
+	Driver  string            `yaml:"driver,omitempty"`   // e.g. json-file, local, journald, syslog, fluentd
+	MaxSize string            `yaml:"max_size,omitempty"` // json-file and local: rotate at this size, e.g. 10m
+	MaxFile int               `yaml:"max_file,omitempty"` // json-file and local: rotated files to keep
+	Options map[string]string `yaml:"options,omitempty"`  // Driver options, e.g. syslog-address, tag
 }
 
 // ProxyConfig contains nginx proxy configuration
@@ -139,29 +204,80 @@ type HooksConfig struct {
 }
 
 // Load reads and parses the configuration file
+// Environment variables (.env files, secrets) are substituted before it's validated.
 func Load(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
+	config, err := parse(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, err
+	}
+	return finishLoad(config)
+}
+
+// parse reads a configuration file without applying defaults or validating it
+func parse(path string) (*Config, error) {
+	root, err := readYAMLNode(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		return nil, err
 	}
 
 	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	if err := root.Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
 	}
 
 	// Store config path for .env lookup
 	config.configPath = path
 
+	return &config, nil
+}
+
+// readYAMLNode reads a YAML file into its document's root node
+// Unknown keys are rejected with their file:line:column
+func readYAMLNode(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML in %s: %w", path, err)
+	}
+
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		// Empty file
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+	}
+
+	root := doc.Content[0]
+	if err := checkKnownFields(root, filepath.Base(path)); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// finishLoad applies defaults, substitutes environment variables and validates a freshly parsed config
+func finishLoad(config *Config) (*Config, error) {
 	// Apply defaults
 	config.applyDefaults()
 
+	// Substitute before validating: hosts, images and placement can come from ${VAR}
+	if err := config.SubstituteConfigEnvVars(); err != nil {
+		return nil, err
+	}
+
 	// Validate
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("configuration invalid: %w", err)
 	}
 
-	return &config, nil
+	return config, nil
 }
 
 // Find searches for podlift.yml in the current directory and parent directories
@@ -219,6 +335,9 @@ func (c *Config) applyDefaults() {
 		if svc.Replicas == 0 {
 			svc.Replicas = 1
 		}
+		if svc.Restart == "" {
+			svc.Restart = DefaultRestartPolicy
+		}
 		if svc.Healthcheck == nil {
 			svc.Healthcheck = &HealthcheckConfig{
 				Path:     "/health",
@@ -257,72 +376,154 @@ func (c *Config) applyServerDefaults() {
 }
 
 // Validate checks the configuration for errors
+// All problems are reported together as ValidationErrors
 func (c *Config) Validate() error {
+	var errs ValidationErrors
+
 	if c.Service == "" {
-		return fmt.Errorf("service name is required")
+		errs.add("service name is required")
 	}
 
 	if c.Image == "" {
-		return fmt.Errorf("image name is required")
+		errs.add("image name is required")
+	} else if ref := docker.ParseImageReference(c.Image); ref.Tag != "" && ref.Digest == "" {
+		errs.add("image '%s' has a tag: podlift tags the images it builds with the git version (use image: %s and deploy a prebuilt tag with --image-tag %s, or pin a digest)", c.Image, ref.Repository, ref.Tag)
 	}
 
 	servers := c.Servers.Get()
 	if len(servers) == 0 {
-		return fmt.Errorf("at least one server is required")
+		errs.add("at least one server is required")
 	}
 
 	// Validate servers
-	for role, serverList := range servers {
+	for _, role := range sortedKeys(servers) {
+		serverList := servers[role]
 		if len(serverList) == 0 {
-			return fmt.Errorf("role '%s' has no servers", role)
+			errs.add("role '%s' has no servers", role)
 		}
 		for i, server := range serverList {
 			if server.Host == "" {
-				return fmt.Errorf("server %d in role '%s' missing host", i, role)
+				errs.add("server %d in role '%s' missing host", i, role)
+			}
+			if server.PrivateIP != "" && net.ParseIP(server.PrivateIP) == nil {
+				errs.add("server %s private_ip '%s' is not an IP address", server.Host, server.PrivateIP)
 			}
 		}
 	}
 
 	// Validate services
-	for name, svc := range c.Services {
+	for _, name := range sortedKeys(c.Services) {
+		svc := c.Services[name]
 		if svc.Port < 1 || svc.Port > 65535 {
-			return fmt.Errorf("service '%s' has invalid port: %d", name, svc.Port)
+			errs.add("service '%s' has invalid port: %d", name, svc.Port)
 		}
 		if svc.Replicas < 1 {
-			return fmt.Errorf("service '%s' replicas must be >= 1", name)
+			errs.add("service '%s' replicas must be >= 1", name)
+		}
+		if _, err := shell.Split(svc.Command); err != nil {
+			errs.add("service '%s' command: %w", name, err)
 		}
+		if svc.Restart != "" && !ValidRestartPolicy(svc.Restart) {
+			errs.add("service '%s' has invalid restart policy '%s' (use no, always, unless-stopped or on-failure[:N])", name, svc.Restart)
+		}
+		svc.validateRuntime(name, &errs)
 	}
 
 	// Validate dependencies
-	for name, dep := range c.Dependencies {
+	for _, name := range sortedKeys(c.Dependencies) {
+		dep := c.Dependencies[name]
 		if dep.Image == "" {
-			return fmt.Errorf("dependency '%s' missing image", name)
+			errs.add("dependency '%s' missing image", name)
+		}
+		if _, err := shell.Split(dep.Command); err != nil {
+			errs.add("dependency '%s' command: %w", name, err)
+		}
+		switch dep.Backup {
+		case "", "postgres", "mysql", "redis", "volume":
+		default:
+			errs.add("dependency '%s' has unknown backup strategy '%s' (use postgres, mysql, redis or volume)", name, dep.Backup)
+		}
+		if hc := dep.Healthcheck; hc != nil {
+			if _, err := shell.Split(hc.Command); err != nil {
+				errs.add("dependency '%s' healthcheck command: %w", name, err)
+			}
+			if hc.Port < 0 || hc.Port > 65535 {
+				errs.add("dependency '%s' healthcheck has invalid port: %d", name, hc.Port)
+			}
+			for _, d := range []struct{ field, value string }{{"interval", hc.Interval}, {"timeout", hc.Timeout}} {
+				if parsed, err := time.ParseDuration(d.value); d.value != "" && (err != nil || parsed <= 0) {
+					errs.add("dependency '%s' healthcheck %s '%s' is not a duration (e.g. 5s)", name, d.field, d.value)
+				}
+			}
+			if hc.Retries < 0 {
+				errs.add("dependency '%s' healthcheck retries must be >= 0", name)
+			}
+		}
+		if dep.Publish && dep.Port == 0 {
+			errs.add("dependency '%s' publish requires a port", name)
+		}
+		if dep.BindAddress != "" {
+			if !dep.Publish {
+				errs.add("dependency '%s' bind_address requires publish: true", name)
+			} else if net.ParseIP(dep.BindAddress) == nil {
+				errs.add("dependency '%s' bind_address '%s' is not an IP address", name, dep.BindAddress)
+			}
 		}
 		// Validate that specified host/role/labels exist
 		if dep.Host != "" || dep.Role != "" || len(dep.Labels) > 0 {
 			if _, _, err := c.GetDependencyServer(dep); err != nil {
-				return fmt.Errorf("dependency '%s': %w", name, err)
+				errs.add("dependency '%s': %w", name, err)
 			}
 		}
 	}
 
+	c.validateExports(&errs)
+	c.validateDependsOn(&errs)
+	c.validateLogging(&errs)
+	c.validateJobs(&errs)
+
 	// Validate registry if specified
 	if c.Registry != nil {
-		if c.Registry.Server != "" && c.Registry.Username == "" {
-			return fmt.Errorf("registry username required when server is specified")
+		if c.Registry.Server != "" && c.Registry.Username == "" && c.Registry.CredentialHelper == "" {
+			errs.add("registry username required when server is specified (unless credential_helper supplies it)")
 		}
 	}
 
-	return nil
+	return errs.errOrNil()
+}
+
+// ValidRestartPolicy reports whether p is a docker restart policy
+// e.g. no, always, unless-stopped, on-failure or on-failure:5
+func ValidRestartPolicy(p string) bool {
+	switch p {
+	case "no", "always", "unless-stopped", "on-failure":
+		return true
+	}
+	if retries, ok := strings.CutPrefix(p, "on-failure:"); ok {
+		n, err := strconv.Atoi(retries)
+		return err == nil && n > 0
+	}
+	return false
+}
+
+// sortedKeys returns map keys in sorted order so errors and output are deterministic
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 // GetPrimaryServer returns the first server with "primary" label, or first server in first role
+// Roles are visited in sorted order, so the result is the same on every call.
 func (c *Config) GetPrimaryServer() (*Server, string, error) {
 	servers := c.Servers.Get()
 	
 	// First, check all servers for "primary" label
-	for role, serverList := range servers {
-		for _, server := range serverList {
+	for _, role := range sortedKeys(servers) {
+		for _, server := range servers[role] {
 			for _, label := range server.Labels {
 				if label == "primary" {
 					return &server, role, nil
@@ -331,10 +532,10 @@ func (c *Config) GetPrimaryServer() (*Server, string, error) {
 		}
 	}
 	
-	// If no primary label found, return first server from first role
-	// (order is deterministic: iterate map in sorted order)
-	for role, serverList := range servers {
-		if len(serverList) > 0 {
+	// If no primary label found, return first server of the first role in sorted order,
+	// so every caller picks the same server
+	for _, role := range sortedKeys(servers) {
+		if serverList := servers[role]; len(serverList) > 0 {
 			return &serverList[0], role, nil
 		}
 	}
@@ -348,8 +549,8 @@ func (c *Config) GetDependencyServer(dep Dependency) (*Server, string, error) {
 	
 	// If host is specified, find exact match
 	if dep.Host != "" {
-		for role, serverList := range servers {
-			for _, server := range serverList {
+		for _, role := range sortedKeys(servers) {
+			for _, server := range servers[role] {
 				if server.Host == dep.Host {
 					return &server, role, nil
 				}
@@ -368,8 +569,8 @@ func (c *Config) GetDependencyServer(dep Dependency) (*Server, string, error) {
 	
 	// If labels are specified, find server with matching labels
 	if len(dep.Labels) > 0 {
-		for role, serverList := range servers {
-			for _, server := range serverList {
+		for _, role := range sortedKeys(servers) {
+			for _, server := range servers[role] {
 				for _, depLabel := range dep.Labels {
 					for _, serverLabel := range server.Labels {
 						if depLabel == serverLabel {
@@ -386,12 +587,29 @@ func (c *Config) GetDependencyServer(dep Dependency) (*Server, string, error) {
 	return c.GetPrimaryServer()
 }
 
+// DependenciesOn returns the names of the dependencies placed on server's host in start order
+// (a dependency after the ones it depends on). A host listed under several roles gets the
+// same names for each of them.
+func (c *Config) DependenciesOn(server Server) ([]string, error) {
+	var names []string
+	for _, name := range c.DependencyOrder() {
+		depServer, _, err := c.GetDependencyServer(c.Dependencies[name])
+		if err != nil {
+			return nil, fmt.Errorf("dependency '%s': %w", name, err)
+		}
+		if depServer.Host == server.Host {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
 // GetAllServers returns all servers flattened with their roles
 func (c *Config) GetAllServers() []ServerWithRole {
 	var result []ServerWithRole
 	servers := c.Servers.Get()
-	for role, serverList := range servers {
-		for _, server := range serverList {
+	for _, role := range sortedKeys(servers) {
+		for _, server := range servers[role] {
 			result = append(result, ServerWithRole{
 				Server: server,
 				Role:   role,
@@ -2,10 +2,15 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
 func TestLoad(t *testing.T) {
+	t.Setenv("REGISTRY_USER", "testuser")
+	t.Setenv("REGISTRY_PASSWORD", "testpass")
+
 	tests := []struct {
 		name    string
 		yaml    string
@@ -121,6 +126,28 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "tagged image",
+			config: Config{
+				Service: "myapp",
+				Image:   "myapp:latest",
+				Servers: ServersConfig{servers: map[string][]Server{
+					"web": {{Host: "192.168.1.10"}},
+				}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "image pinned to a digest",
+			config: Config{
+				Service: "myapp",
+				Image:   "ghcr.io/org/app@sha256:4f1c2a9b8e7d",
+				Servers: ServersConfig{servers: map[string][]Server{
+					"web": {{Host: "192.168.1.10"}},
+				}},
+			},
+			wantErr: false,
+		},
 		{
 			name: "missing service",
 			config: Config{
@@ -145,6 +172,90 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "publish without port",
+			config: Config{
+				Service: "myapp",
+				Image:   "myapp",
+				Servers: ServersConfig{servers: map[string][]Server{
+					"web": {{Host: "192.168.1.10"}},
+				}},
+				Dependencies: map[string]Dependency{
+					"postgres": {Image: "postgres:16", Publish: true},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "bind address without publish",
+			config: Config{
+				Service: "myapp",
+				Image:   "myapp",
+				Servers: ServersConfig{servers: map[string][]Server{
+					"web": {{Host: "192.168.1.10"}},
+				}},
+				Dependencies: map[string]Dependency{
+					"postgres": {Image: "postgres:16", Port: 5432, BindAddress: "0.0.0.0"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "dependency healthcheck",
+			config: Config{
+				Service: "myapp",
+				Image:   "myapp",
+				Servers: ServersConfig{servers: map[string][]Server{
+					"web": {{Host: "192.168.1.10"}},
+				}},
+				Dependencies: map[string]Dependency{
+					"search": {Image: "elasticsearch:8", Healthcheck: &DependencyHealthcheck{Command: "curl -fs http://127.0.0.1:9200", Interval: "5s", Retries: 60}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "dependency healthcheck interval not a duration",
+			config: Config{
+				Service: "myapp",
+				Image:   "myapp",
+				Servers: ServersConfig{servers: map[string][]Server{
+					"web": {{Host: "192.168.1.10"}},
+				}},
+				Dependencies: map[string]Dependency{
+					"postgres": {Image: "postgres:16", Healthcheck: &DependencyHealthcheck{Interval: "5"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid restart policy",
+			config: Config{
+				Service: "myapp",
+				Image:   "myapp",
+				Servers: ServersConfig{servers: map[string][]Server{
+					"web": {{Host: "192.168.1.10"}},
+				}},
+				Services: map[string]Service{
+					"web": {Port: 8000, Restart: "sometimes"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unterminated quote in command",
+			config: Config{
+				Service: "myapp",
+				Image:   "myapp",
+				Servers: ServersConfig{servers: map[string][]Server{
+					"web": {{Host: "192.168.1.10"}},
+				}},
+				Services: map[string]Service{
+					"worker": {Port: 8000, Command: `sh -c 'echo hi`},
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -184,3 +295,124 @@ func TestGetPrimaryServer(t *testing.T) {
 	}
 }
 
+// Without a primary label the pick must not depend on map iteration order,
+// or dependencies and jobs could be placed on two servers, or none
+func TestGetPrimaryServer_Deterministic(t *testing.T) {
+	config := Config{
+		Service: "myapp",
+		Image:   "myapp",
+		Servers: ServersConfig{servers: map[string][]Server{
+			"web":    {{Host: "192.168.1.10"}, {Host: "192.168.1.11"}},
+			"worker": {{Host: "192.168.1.30"}},
+			"db":     {{Host: "192.168.1.20", Labels: []string{"storage"}}},
+			"cache":  {{Host: "192.168.1.40", Labels: []string{"storage"}}},
+		}},
+		Dependencies: map[string]Dependency{
+			"postgres": {Image: "postgres:16"},
+			"minio":    {Image: "minio/minio", Labels: []string{"storage"}},
+		},
+	}
+
+	for i := 0; i < 100; i++ {
+		server, role, err := config.GetPrimaryServer()
+		if err != nil {
+			t.Fatalf("GetPrimaryServer() error = %v", err)
+		}
+		if server.Host != "192.168.1.40" || role != "cache" {
+			t.Fatalf("GetPrimaryServer() = %s (%s), want the first server of the first role in sorted order", server.Host, role)
+		}
+
+		minio, _, err := config.GetDependencyServer(config.Dependencies["minio"])
+		if err != nil || minio.Host != "192.168.1.40" {
+			t.Fatalf("GetDependencyServer(minio) = %v, %v, want the cache server", minio, err)
+		}
+
+		var hosts []string
+		for _, server := range config.GetAllServers() {
+			hosts = append(hosts, server.Host)
+		}
+		if got := strings.Join(hosts, ","); got != "192.168.1.40,192.168.1.20,192.168.1.10,192.168.1.11,192.168.1.30" {
+			t.Fatalf("GetAllServers() = %s, want roles in sorted order", got)
+		}
+	}
+
+	// Exactly one server gets postgres
+	placed := 0
+	for _, server := range config.GetAllServers() {
+		names, err := config.DependenciesOn(server.Server)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range names {
+			if name == "postgres" {
+				placed++
+			}
+		}
+	}
+	if placed != 1 {
+		t.Errorf("postgres placed on %d servers, want 1", placed)
+	}
+}
+
+func TestValidRestartPolicy(t *testing.T) {
+	valid := []string{"no", "always", "unless-stopped", "on-failure", "on-failure:5"}
+	for _, p := range valid {
+		if !ValidRestartPolicy(p) {
+			t.Errorf("ValidRestartPolicy(%q) = false, want true", p)
+		}
+	}
+
+	invalid := []string{"", "never", "on-failure:", "on-failure:0", "on-failure:x"}
+	for _, p := range invalid {
+		if ValidRestartPolicy(p) {
+			t.Errorf("ValidRestartPolicy(%q) = true, want false", p)
+		}
+	}
+}
+
+func TestLoad_SubstitutesBeforeValidating(t *testing.T) {
+	t.Setenv("PODLIFT_TEST_PRIVATE_IP", "10.0.0.5")
+	t.Setenv("PODLIFT_TEST_DB_HOST", "192.168.1.20")
+	t.Setenv("PODLIFT_TEST_BAD_IP", "not-an-ip")
+
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "podlift.yml")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// Only valid once substituted: private_ip and the dependency's host come from variables
+	path := write(t, `service: myapp
+image: myapp
+servers:
+  web:
+    - host: 192.168.1.10
+      private_ip: ${PODLIFT_TEST_PRIVATE_IP}
+  db:
+    - host: 192.168.1.20
+dependencies:
+  postgres:
+    image: postgres:16
+    host: ${PODLIFT_TEST_DB_HOST}
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Dependencies["postgres"].Host != "192.168.1.20" {
+		t.Errorf("postgres host = %q, want substituted", cfg.Dependencies["postgres"].Host)
+	}
+
+	// Only invalid once substituted
+	path = write(t, `service: myapp
+image: myapp
+servers:
+  - host: 192.168.1.10
+    private_ip: ${PODLIFT_TEST_BAD_IP}
+`)
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "'not-an-ip' is not an IP address") {
+		t.Errorf("Load() error = %v, want invalid private_ip", err)
+	}
+}
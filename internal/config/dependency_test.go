@@ -1,6 +1,9 @@
 package config
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestGetDependencyServer(t *testing.T) {
 	config := Config{
@@ -82,3 +85,43 @@ func TestGetDependencyServer(t *testing.T) {
 	}
 }
 
+
+func TestDependenciesOn(t *testing.T) {
+	config := Config{
+		Service: "myapp",
+		Image:   "myapp",
+		Servers: ServersConfig{servers: map[string][]Server{
+			"web": {
+				{Host: "192.168.1.10", Labels: []string{"primary"}},
+				{Host: "192.168.1.11"},
+			},
+			"db": {
+				{Host: "192.168.1.20"},
+			},
+		}},
+		Dependencies: map[string]Dependency{
+			"postgres": {Image: "postgres:16", Role: "db"},
+			"redis":    {Image: "redis:7"},
+			"mongo":    {Image: "mongo:7", Host: "192.168.1.20"},
+		},
+	}
+
+	tests := []struct {
+		host string
+		want []string
+	}{
+		{"192.168.1.10", []string{"redis"}},
+		{"192.168.1.11", nil},
+		{"192.168.1.20", []string{"mongo", "postgres"}},
+	}
+
+	for _, tt := range tests {
+		got, err := config.DependenciesOn(Server{Host: tt.host})
+		if err != nil {
+			t.Fatalf("DependenciesOn(%s) error = %v", tt.host, err)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("DependenciesOn(%s) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
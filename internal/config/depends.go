@@ -0,0 +1,196 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Conditions a depends_on entry waits for
+const (
+	ConditionStarted   = "started"   // The container was started
+	ConditionHealthy   = "healthy"   // Its health check passed
+	ConditionCompleted = "completed" // It ran to completion with exit code 0 (one-off jobs)
+)
+
+// DependsOn maps service or dependency names to the condition they must reach first
+// In YAML it's either a list of names (condition: started) or a map:
+//
+//	depends_on:
+//	  postgres: {condition: healthy}
+//	  migrate: {condition: completed}
+type DependsOn map[string]DependsOnEntry
+
+// DependsOnEntry is one entry of depends_on
+type DependsOnEntry struct {
+	Condition string `yaml:"condition,omitempty"` // started (default), healthy or completed
+}
+
+var dependsOnType = reflect.TypeOf(DependsOn{})
+
+// Names returns the names in sorted order
+func (d DependsOn) Names() []string {
+	return sortedKeys(d)
+}
+
+// UnmarshalYAML accepts both the list and the map form
+func (d *DependsOn) UnmarshalYAML(node *yaml.Node) error {
+	*d = make(DependsOn)
+
+	if node.Kind == yaml.SequenceNode {
+		var names []string
+		if err := node.Decode(&names); err != nil {
+			return err
+		}
+		for _, name := range names {
+			(*d)[name] = DependsOnEntry{Condition: ConditionStarted}
+		}
+		return nil
+	}
+
+	var entries map[string]DependsOnEntry
+	if err := node.Decode(&entries); err != nil {
+		return fmt.Errorf("depends_on must be a list of names or a map of name: {condition: ...}: %w", err)
+	}
+	for name, entry := range entries {
+		if entry.Condition == "" {
+			entry.Condition = ConditionStarted
+		}
+		(*d)[name] = entry
+	}
+	return nil
+}
+
+// ServiceOrder returns service names in start order: every service after the services it
+// depends on, and alphabetically otherwise
+func (c *Config) ServiceOrder() []string {
+	order, _ := startOrder(c.Services, func(name string) DependsOn { return c.Services[name].DependsOn })
+	return order
+}
+
+// DependencyOrder returns dependency names in start order, like ServiceOrder
+func (c *Config) DependencyOrder() []string {
+	order, _ := startOrder(c.Dependencies, func(name string) DependsOn { return c.Dependencies[name].DependsOn })
+	return order
+}
+
+// IsJob reports whether a service is a one-off job, i.e. another service waits for it to complete
+func (c *Config) IsJob(name string) bool {
+	for _, svc := range c.Services {
+		if entry, ok := svc.DependsOn[name]; ok && entry.Condition == ConditionCompleted {
+			return true
+		}
+	}
+	return false
+}
+
+// validateDependsOn checks depends_on references, conditions and cycles
+// Dependencies start before services, so they can only depend on other dependencies.
+func (c *Config) validateDependsOn(errs *ValidationErrors) {
+	for _, name := range sortedKeys(c.Services) {
+		for _, target := range c.Services[name].DependsOn.Names() {
+			condition := c.Services[name].DependsOn[target].Condition
+			_, isService := c.Services[target]
+			_, isDependency := c.Dependencies[target]
+
+			switch {
+			case target == name:
+				errs.add("service '%s' depends on itself", name)
+			case isService && isDependency:
+				errs.add("service '%s' depends_on '%s' is ambiguous: both a service and a dependency are named '%s'", name, target, target)
+			case !isService && !isDependency:
+				errs.add("service '%s' depends on unknown service or dependency '%s'", name, target)
+			case condition == ConditionCompleted && !isService:
+				errs.add("service '%s' depends_on '%s': condition completed requires a service (dependencies keep running)", name, target)
+			case condition == ConditionCompleted && c.Services[target].Restart != "no":
+				errs.add("service '%s' depends_on '%s': condition completed requires restart: no on '%s'", name, target, target)
+			}
+			validCondition("service", name, target, condition, errs)
+		}
+	}
+
+	for _, name := range sortedKeys(c.Dependencies) {
+		for _, target := range c.Dependencies[name].DependsOn.Names() {
+			condition := c.Dependencies[name].DependsOn[target].Condition
+
+			_, isService := c.Services[target]
+			_, isDependency := c.Dependencies[target]
+
+			switch {
+			case target == name:
+				errs.add("dependency '%s' depends on itself", name)
+			case isDependency:
+				if condition == ConditionCompleted {
+					errs.add("dependency '%s' depends_on '%s': condition completed requires a service (dependencies keep running)", name, target)
+				}
+			case isService:
+				errs.add("dependency '%s' depends on service '%s' (dependencies start before services)", name, target)
+			default:
+				errs.add("dependency '%s' depends on unknown dependency '%s'", name, target)
+			}
+			validCondition("dependency", name, target, condition, errs)
+		}
+	}
+
+	if _, cycle := startOrder(c.Services, func(name string) DependsOn { return c.Services[name].DependsOn }); cycle != nil {
+		errs.add("services depends_on cycle: %s", strings.Join(cycle, " → "))
+	}
+	if _, cycle := startOrder(c.Dependencies, func(name string) DependsOn { return c.Dependencies[name].DependsOn }); cycle != nil {
+		errs.add("dependencies depends_on cycle: %s", strings.Join(cycle, " → "))
+	}
+}
+
+// validCondition reports an unknown depends_on condition
+func validCondition(kind, name, target, condition string, errs *ValidationErrors) {
+	switch condition {
+	case "", ConditionStarted, ConditionHealthy, ConditionCompleted:
+	default:
+		errs.add("%s '%s' depends_on '%s' has unknown condition '%s' (use started, healthy or completed)", kind, name, target, condition)
+	}
+}
+
+// startOrder sorts the keys of nodes topologically by their depends_on entries (depth-first)
+// Entries naming something outside nodes are ignored. The first cycle found is returned,
+// e.g. [a b a]; the order then still contains every node, but can't satisfy the cycle.
+func startOrder[V any](nodes map[string]V, dependsOn func(name string) DependsOn) (order, cycle []string) {
+	visiting := make(map[string]bool, len(nodes))
+	done := make(map[string]bool, len(nodes))
+	var path []string
+
+	var visit func(name string)
+	visit = func(name string) {
+		if done[name] {
+			return
+		}
+		if visiting[name] {
+			if cycle == nil {
+				for i, n := range path {
+					if n == name {
+						cycle = append(append([]string{}, path[i:]...), name)
+						break
+					}
+				}
+			}
+			return
+		}
+
+		visiting[name] = true
+		path = append(path, name)
+		for _, target := range dependsOn(name).Names() {
+			if _, exists := nodes[target]; exists && target != name {
+				visit(target)
+			}
+		}
+		path = path[:len(path)-1]
+		visiting[name] = false
+		done[name] = true
+		order = append(order, name)
+	}
+
+	for _, name := range sortedKeys(nodes) {
+		visit(name)
+	}
+	return order, cycle
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoad_DependsOn(t *testing.T) {
+	yaml := `service: myapp
+image: myapp
+servers:
+  - host: 192.168.1.10
+dependencies:
+  postgres:
+    image: postgres:16
+  pgbouncer:
+    image: edoburu/pgbouncer
+    depends_on: [postgres]
+services:
+  migrate:
+    command: python manage.py migrate
+    restart: "no"
+    depends_on:
+      postgres: {condition: healthy}
+  web:
+    depends_on:
+      migrate: {condition: completed}
+      pgbouncer: {}
+`
+	path := filepath.Join(t.TempDir(), "podlift.yml")
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := DependsOn{
+		"migrate":   {Condition: ConditionCompleted},
+		"pgbouncer": {Condition: ConditionStarted},
+	}
+	if got := cfg.Services["web"].DependsOn; !reflect.DeepEqual(got, want) {
+		t.Errorf("web depends_on = %v, want %v", got, want)
+	}
+	if got := cfg.Dependencies["pgbouncer"].DependsOn; !reflect.DeepEqual(got, DependsOn{"postgres": {Condition: ConditionStarted}}) {
+		t.Errorf("pgbouncer depends_on = %v, want postgres started", got)
+	}
+
+	if got := cfg.ServiceOrder(); !reflect.DeepEqual(got, []string{"migrate", "web"}) {
+		t.Errorf("ServiceOrder() = %v", got)
+	}
+	if got := cfg.DependencyOrder(); !reflect.DeepEqual(got, []string{"postgres", "pgbouncer"}) {
+		t.Errorf("DependencyOrder() = %v", got)
+	}
+	if !cfg.IsJob("migrate") || cfg.IsJob("web") {
+		t.Errorf("IsJob() should only be true for migrate")
+	}
+
+	if err := ValidateSchema(path, false); err != nil {
+		t.Errorf("ValidateSchema() error = %v", err)
+	}
+}
+
+func TestServiceOrder(t *testing.T) {
+	cfg := &Config{Services: map[string]Service{
+		"worker":    {DependsOn: DependsOn{"web": {Condition: ConditionStarted}}},
+		"web":       {DependsOn: DependsOn{"migrate": {Condition: ConditionCompleted}, "postgres": {Condition: ConditionHealthy}}},
+		"migrate":   {},
+		"scheduler": {},
+		"admin":     {DependsOn: DependsOn{"worker": {Condition: ConditionStarted}}},
+	}}
+
+	want := []string{"migrate", "web", "worker", "admin", "scheduler"}
+	if got := cfg.ServiceOrder(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ServiceOrder() = %v, want %v", got, want)
+	}
+}
+
+func TestValidateDependsOn(t *testing.T) {
+	tests := []struct {
+		name         string
+		services     map[string]Service
+		dependencies map[string]Dependency
+		wantErr      string
+	}{
+		{
+			name: "valid",
+			services: map[string]Service{
+				"migrate": {Restart: "no"},
+				"web":     {DependsOn: DependsOn{"migrate": {Condition: ConditionCompleted}, "postgres": {Condition: ConditionHealthy}}},
+			},
+			dependencies: map[string]Dependency{"postgres": {Image: "postgres:16"}},
+		},
+		{
+			name:     "unknown name",
+			services: map[string]Service{"web": {DependsOn: DependsOn{"postgress": {Condition: ConditionStarted}}}},
+			wantErr:  "service 'web' depends on unknown service or dependency 'postgress'",
+		},
+		{
+			name:     "unknown condition",
+			services: map[string]Service{"web": {DependsOn: DependsOn{"worker": {Condition: "ready"}}}, "worker": {}},
+			wantErr:  "unknown condition 'ready'",
+		},
+		{
+			name: "cycle",
+			services: map[string]Service{
+				"a": {DependsOn: DependsOn{"b": {Condition: ConditionStarted}}},
+				"b": {DependsOn: DependsOn{"c": {Condition: ConditionStarted}}},
+				"c": {DependsOn: DependsOn{"a": {Condition: ConditionHealthy}}},
+			},
+			wantErr: "services depends_on cycle: a → b → c → a",
+		},
+		{
+			name:     "itself",
+			services: map[string]Service{"web": {DependsOn: DependsOn{"web": {Condition: ConditionStarted}}}},
+			wantErr:  "service 'web' depends on itself",
+		},
+		{
+			name: "completed on a long-running service",
+			services: map[string]Service{
+				"migrate": {Restart: DefaultRestartPolicy},
+				"web":     {DependsOn: DependsOn{"migrate": {Condition: ConditionCompleted}}},
+			},
+			wantErr: "condition completed requires restart: no on 'migrate'",
+		},
+		{
+			name:         "completed on a dependency",
+			services:     map[string]Service{"web": {DependsOn: DependsOn{"postgres": {Condition: ConditionCompleted}}}},
+			dependencies: map[string]Dependency{"postgres": {Image: "postgres:16"}},
+			wantErr:      "condition completed requires a service",
+		},
+		{
+			name:         "dependency on a service",
+			services:     map[string]Service{"web": {}},
+			dependencies: map[string]Dependency{"postgres": {Image: "postgres:16", DependsOn: DependsOn{"web": {Condition: ConditionStarted}}}},
+			wantErr:      "dependency 'postgres' depends on service 'web' (dependencies start before services)",
+		},
+		{
+			name: "dependency cycle",
+			dependencies: map[string]Dependency{
+				"pgbouncer": {Image: "edoburu/pgbouncer", DependsOn: DependsOn{"postgres": {Condition: ConditionStarted}}},
+				"postgres":  {Image: "postgres:16", DependsOn: DependsOn{"pgbouncer": {Condition: ConditionStarted}}},
+			},
+			wantErr: "dependencies depends_on cycle: pgbouncer → postgres → pgbouncer",
+		},
+		{
+			name:         "ambiguous name",
+			services:     map[string]Service{"redis": {}, "web": {DependsOn: DependsOn{"redis": {Condition: ConditionStarted}}}},
+			dependencies: map[string]Dependency{"redis": {Image: "redis:7"}},
+			wantErr:      "is ambiguous",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Services: tt.services, Dependencies: tt.dependencies}
+			var errs ValidationErrors
+			cfg.validateDependsOn(&errs)
+
+			err := errs.errOrNil()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateDependsOn() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateDependsOn() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -4,13 +4,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"sort"
 	"strings"
+
+	"github.com/ekinertac/podlift/internal/secrets"
 )
 
 // LoadEnv loads environment variables from .env file
 func LoadEnv(path string) error {
-	data, err := os.ReadFile(path)
+	values, err := ParseEnvFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil // .env file is optional
@@ -18,6 +22,21 @@ func LoadEnv(path string) error {
 		return err
 	}
 
+	for key, value := range values {
+		os.Setenv(key, value)
+	}
+
+	return nil
+}
+
+// ParseEnvFile reads KEY=VALUE lines from a .env-style file
+func ParseEnvFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
 	lines := strings.Split(string(data), "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
@@ -41,34 +60,68 @@ func LoadEnv(path string) error {
 			}
 		}
 
-		os.Setenv(key, value)
+		values[key] = value
 	}
 
-	return nil
+	return values, nil
 }
 
+// envVarPattern matches $${VAR} (escaped) and ${VAR}, ${VAR:-default}, ${VAR:?message}
+var envVarPattern = regexp.MustCompile(`\$?\$\{([^}]+)\}`)
+
 // SubstituteEnvVars replaces ${VAR} patterns with environment variables
+// Unresolved variables are left as-is; use expandEnvVars to find them.
 func SubstituteEnvVars(value string) string {
-	re := regexp.MustCompile(`\$\{([^}]+)\}`)
-	return re.ReplaceAllStringFunc(value, func(match string) string {
-		varName := strings.TrimPrefix(strings.TrimSuffix(match, "}"), "${")
-		
-		// Handle default value syntax: ${VAR:-default}
-		if strings.Contains(varName, ":-") {
-			parts := strings.SplitN(varName, ":-", 2)
-			varName = parts[0]
-			defaultValue := parts[1]
-			if val := os.Getenv(varName); val != "" {
+	result, _ := expandEnvVars(value, os.LookupEnv)
+	return result
+}
+
+// expandEnvVars replaces ${VAR} patterns and returns a problem for each unresolved variable
+//
+//	${VAR}          value of VAR (unset is an error)
+//	${VAR:-default} value of VAR, or default if unset or empty
+//	${VAR:?message} value of VAR, or an error with message if unset or empty
+//	$${VAR}         literal ${VAR}
+func expandEnvVars(value string, lookup func(string) (string, bool)) (string, []string) {
+	var problems []string
+
+	result := envVarPattern.ReplaceAllStringFunc(value, func(match string) string {
+		// Escaped: $${VAR} → ${VAR}
+		if strings.HasPrefix(match, "$$") {
+			return match[1:]
+		}
+
+		expr := strings.TrimSuffix(strings.TrimPrefix(match, "${"), "}")
+
+		// Default value syntax: ${VAR:-default}
+		if i := strings.Index(expr, ":-"); i >= 0 {
+			if val, _ := lookup(expr[:i]); val != "" {
 				return val
 			}
-			return defaultValue
+			return expr[i+2:]
 		}
 
-		if val := os.Getenv(varName); val != "" {
+		// Required syntax: ${VAR:?message}
+		if i := strings.Index(expr, ":?"); i >= 0 {
+			if val, _ := lookup(expr[:i]); val != "" {
+				return val
+			}
+			message := expr[i+2:]
+			if message == "" {
+				message = "required but not set"
+			}
+			problems = append(problems, fmt.Sprintf("%s: %s", expr[:i], message))
+			return match
+		}
+
+		if val, ok := lookup(expr); ok {
 			return val
 		}
+		problems = append(problems, fmt.Sprintf("%s is not set", expr))
 		return match // Return original if not found
 	})
+
+	return result, problems
 }
 
 // ExpandPath expands ~ and environment variables in paths
@@ -103,6 +156,28 @@ func FindEnvFile(configPath string) string {
 	return ""
 }
 
+// environmentEnvFile returns the .env.<environment> file for the active environment, if it exists
+// It sits next to the base env file (or next to podlift.yml when there is none)
+func (c *Config) environmentEnvFile(baseEnvPath string) string {
+	if c.environment == "" {
+		return ""
+	}
+
+	var envPath string
+	if baseEnvPath != "" {
+		envPath = baseEnvPath + "." + c.environment
+	} else if c.configPath != "" {
+		envPath = filepath.Join(filepath.Dir(c.configPath), ".env."+c.environment)
+	} else {
+		return ""
+	}
+
+	if _, err := os.Stat(envPath); err != nil {
+		return ""
+	}
+	return envPath
+}
+
 // SubstituteConfigEnvVars substitutes environment variables in config
 func (c *Config) SubstituteConfigEnvVars() error {
 	var envPath string
@@ -128,39 +203,158 @@ func (c *Config) SubstituteConfigEnvVars() error {
 		}
 	}
 
-	// Substitute in registry
-	if c.Registry != nil {
-		c.Registry.Username = SubstituteEnvVars(c.Registry.Username)
-		c.Registry.Password = SubstituteEnvVars(c.Registry.Password)
+	// Environment-specific values (e.g. .env.staging) override the base .env
+	if envPath := c.environmentEnvFile(envPath); envPath != "" {
+		if err := LoadEnv(envPath); err != nil {
+			return fmt.Errorf("failed to load %s: %w", filepath.Base(envPath), err)
+		}
 	}
 
-	// Substitute in servers (SSH key paths)
-	servers := c.Servers.Get()
-	for role, serverList := range servers {
-		for i, server := range serverList {
-			server.SSHKey = ExpandPath(SubstituteEnvVars(server.SSHKey))
-			serverList[i] = server
+	// Decrypted secrets fill in variables that aren't set in the environment
+	secretValues, err := c.loadSecrets()
+	if err != nil {
+		return err
+	}
+	lookup := func(name string) (string, bool) {
+		if val, ok := os.LookupEnv(name); ok {
+			return val, true
 		}
-		servers[role] = serverList
+		val, ok := secretValues[name]
+		return val, ok
 	}
-	c.Servers.Set(servers)
 
-	// Substitute in dependencies
-	for name, dep := range c.Dependencies {
-		for key, value := range dep.Env {
-			dep.Env[key] = SubstituteEnvVars(value)
+	if errs := c.substitute(lookup); len(errs) > 0 {
+		return fmt.Errorf("unresolved environment variables: %w", errs)
+	}
+
+	// Per-service env files are read after substitution, so their values stay literal
+	return c.loadServiceEnvFiles()
+}
+
+// substitute replaces ${VAR} references in every string field using lookup
+// Unresolved variables are left as-is and returned as problems.
+func (c *Config) substitute(lookup func(string) (string, bool)) ValidationErrors {
+	// Every string field (image, domain, hosts, commands, volumes, options, hooks, env...)
+	var errs ValidationErrors
+	substituteValue(reflect.ValueOf(c).Elem(), "", lookup, &errs)
+
+	// Servers are stored in an unexported map, so they're handled separately
+	servers := c.Servers.Get()
+	for _, role := range sortedKeys(servers) {
+		serverList := servers[role]
+		for i := range serverList {
+			substituteValue(reflect.ValueOf(&serverList[i]).Elem(), fmt.Sprintf("servers.%s[%d]", role, i), lookup, &errs)
+			serverList[i].SSHKey = ExpandPath(serverList[i].SSHKey)
 		}
-		c.Dependencies[name] = dep
 	}
+	return errs
+}
+
+// loadServiceEnvFiles merges each service's env_file entries into its env
+// Later files override earlier ones, and values set in env override all files.
+func (c *Config) loadServiceEnvFiles() error {
+	for _, name := range sortedKeys(c.Services) {
+		svc := c.Services[name]
+		if len(svc.EnvFile) == 0 {
+			continue
+		}
+
+		env := make(map[string]string)
+		for _, file := range svc.EnvFile {
+			path := ExpandPath(file)
+			if !filepath.IsAbs(path) && c.configPath != "" {
+				path = filepath.Join(filepath.Dir(c.configPath), path)
+			}
+
+			values, err := ParseEnvFile(path)
+			if err != nil {
+				if os.IsNotExist(err) {
+					return fmt.Errorf("services.%s.env_file: %s not found", name, file)
+				}
+				return fmt.Errorf("services.%s.env_file: %w", name, err)
+			}
+			for key, value := range values {
+				env[key] = value
+			}
+		}
 
-	// Substitute in services
-	for name, svc := range c.Services {
 		for key, value := range svc.Env {
-			svc.Env[key] = SubstituteEnvVars(value)
+			env[key] = value
 		}
+		svc.Env = env
 		c.Services[name] = svc
 	}
 
 	return nil
 }
 
+// substituteValue walks a config value and substitutes env vars in every string it contains
+func substituteValue(v reflect.Value, path string, lookup func(string) (string, bool), errs *ValidationErrors) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			substituteValue(v.Elem(), path, lookup, errs)
+		}
+	case reflect.String:
+		result, problems := expandEnvVars(v.String(), lookup)
+		for _, problem := range problems {
+			errs.add("%s: %s", path, problem)
+		}
+		v.SetString(result)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue // unexported (configPath, ServersConfig.servers)
+			}
+			name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+			substituteValue(v.Field(i), joinPath(path, name), lookup, errs)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			substituteValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), lookup, errs)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			// Map values aren't addressable: substitute a copy and store it back
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			substituteValue(elem, joinPath(path, key.String()), lookup, errs)
+			v.SetMapIndex(key, elem)
+		}
+	}
+}
+
+// loadSecrets decrypts secrets.enc next to podlift.yml, if there is one
+// Values stay in memory; they are never written to the environment or disk.
+func (c *Config) loadSecrets() (map[string]string, error) {
+	if c.configPath == "" {
+		return nil, nil
+	}
+
+	dir := filepath.Dir(c.configPath)
+	path := filepath.Join(dir, secrets.FileName)
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to access %s: %w", secrets.FileName, err)
+	}
+
+	key, err := secrets.LoadKey(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s found but cannot be decrypted: %w", secrets.FileName, err)
+	}
+
+	values, err := secrets.Load(path, key)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
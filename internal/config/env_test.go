@@ -3,7 +3,10 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/ekinertac/podlift/internal/secrets"
 )
 
 func TestSubstituteEnvVars(t *testing.T) {
@@ -257,3 +260,187 @@ func TestCustomEnvFile(t *testing.T) {
 	os.Unsetenv("HOME_VAR")
 }
 
+
+func TestExpandEnvVars(t *testing.T) {
+	t.Setenv("PODLIFT_TEST_SET", "value")
+	t.Setenv("PODLIFT_TEST_EMPTY", "")
+	os.Unsetenv("PODLIFT_TEST_UNSET")
+
+	tests := []struct {
+		input    string
+		want     string
+		problems []string
+	}{
+		{"${PODLIFT_TEST_SET}", "value", nil},
+		{"${PODLIFT_TEST_EMPTY}", "", nil},
+		{"${PODLIFT_TEST_UNSET}", "${PODLIFT_TEST_UNSET}", []string{"PODLIFT_TEST_UNSET is not set"}},
+		{"${PODLIFT_TEST_EMPTY:-fallback}", "fallback", nil},
+		{"${PODLIFT_TEST_SET:?must be set}", "value", nil},
+		{"${PODLIFT_TEST_UNSET:?run podlift secrets set}", "${PODLIFT_TEST_UNSET:?run podlift secrets set}", []string{"PODLIFT_TEST_UNSET: run podlift secrets set"}},
+		{"${PODLIFT_TEST_EMPTY:?}", "${PODLIFT_TEST_EMPTY:?}", []string{"PODLIFT_TEST_EMPTY: required but not set"}},
+		{"echo $${PODLIFT_TEST_UNSET}", "echo ${PODLIFT_TEST_UNSET}", nil},
+		{"$${PODLIFT_TEST_SET}-${PODLIFT_TEST_SET}", "${PODLIFT_TEST_SET}-value", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, problems := expandEnvVars(tt.input, os.LookupEnv)
+			if got != tt.want {
+				t.Errorf("expandEnvVars(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			if strings.Join(problems, "|") != strings.Join(tt.problems, "|") {
+				t.Errorf("expandEnvVars(%q) problems = %v, want %v", tt.input, problems, tt.problems)
+			}
+		})
+	}
+}
+
+func TestSubstituteConfigEnvVars_AllFields(t *testing.T) {
+	t.Setenv("PODLIFT_TEST_IMAGE", "ghcr.io/org/app")
+	t.Setenv("PODLIFT_TEST_HOST", "10.0.0.5")
+	t.Setenv("PODLIFT_TEST_DATA", "/srv/data")
+
+	enabled := true
+	cfg := &Config{
+		Image:  "${PODLIFT_TEST_IMAGE}",
+		Domain: "${PODLIFT_TEST_DOMAIN:-myapp.com}",
+		Services: map[string]Service{
+			"web": {
+				Command:     "gunicorn --bind ${PODLIFT_TEST_HOST}",
+				Volumes:     []string{"${PODLIFT_TEST_DATA}:/data"},
+				Options:     map[string]string{"memory": "${PODLIFT_TEST_MEMORY:-512m}"},
+				Healthcheck: &HealthcheckConfig{Path: "/health", Enabled: &enabled},
+			},
+		},
+		Hooks: &HooksConfig{AfterDeploy: []string{"echo $${HOSTNAME}"}},
+	}
+	cfg.Servers.Set(map[string][]Server{"web": {{Host: "${PODLIFT_TEST_HOST}", SSHKey: "~/.ssh/id_rsa"}}})
+
+	if err := cfg.SubstituteConfigEnvVars(); err != nil {
+		t.Fatalf("SubstituteConfigEnvVars() error = %v", err)
+	}
+
+	if cfg.Image != "ghcr.io/org/app" || cfg.Domain != "myapp.com" {
+		t.Errorf("image/domain = %q/%q", cfg.Image, cfg.Domain)
+	}
+	web := cfg.Services["web"]
+	if web.Command != "gunicorn --bind 10.0.0.5" || web.Volumes[0] != "/srv/data:/data" || web.Options["memory"] != "512m" {
+		t.Errorf("service not substituted: %+v", web)
+	}
+	if cfg.Hooks.AfterDeploy[0] != "echo ${HOSTNAME}" {
+		t.Errorf("hook = %q, want escaped literal", cfg.Hooks.AfterDeploy[0])
+	}
+	server := cfg.Servers.Get()["web"][0]
+	if server.Host != "10.0.0.5" || strings.HasPrefix(server.SSHKey, "~") {
+		t.Errorf("server = %+v, want substituted host and expanded key", server)
+	}
+}
+
+func TestSubstituteConfigEnvVars_Unresolved(t *testing.T) {
+	os.Unsetenv("PODLIFT_TEST_MISSING_A")
+	os.Unsetenv("PODLIFT_TEST_MISSING_B")
+
+	cfg := &Config{
+		Image: "${PODLIFT_TEST_MISSING_A}",
+		Services: map[string]Service{
+			"web": {Env: map[string]string{"SECRET_KEY": "${PODLIFT_TEST_MISSING_B:?generate one with openssl rand}"}},
+		},
+	}
+	cfg.Servers.Set(map[string][]Server{"web": {{Host: "${PODLIFT_TEST_MISSING_A}"}}})
+
+	err := cfg.SubstituteConfigEnvVars()
+	if err == nil {
+		t.Fatal("SubstituteConfigEnvVars() should fail on unresolved variables")
+	}
+
+	for _, want := range []string{
+		"3 problems found",
+		"image: PODLIFT_TEST_MISSING_A is not set",
+		"services.web.env.SECRET_KEY: PODLIFT_TEST_MISSING_B: generate one with openssl rand",
+		"servers.web[0].host: PODLIFT_TEST_MISSING_A is not set",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %q, want it to contain %q", err.Error(), want)
+		}
+	}
+}
+
+func TestSubstituteConfigEnvVars_Secrets(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(secrets.KeyEnvVar, "")
+	t.Setenv("PODLIFT_TEST_OVERRIDE", "from-env")
+	os.Unsetenv("PODLIFT_TEST_SECRET")
+
+	key, _ := secrets.GenerateKey()
+	if err := secrets.WriteKey(dir, key); err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]string{"PODLIFT_TEST_SECRET": "s3cret", "PODLIFT_TEST_OVERRIDE": "from-secrets"}
+	if err := secrets.Save(filepath.Join(dir, secrets.FileName), key, values); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{
+		configPath: filepath.Join(dir, "podlift.yml"),
+		Services: map[string]Service{
+			"web": {Env: map[string]string{
+				"SECRET":   "${PODLIFT_TEST_SECRET}",
+				"OVERRIDE": "${PODLIFT_TEST_OVERRIDE}",
+			}},
+		},
+	}
+
+	if err := cfg.SubstituteConfigEnvVars(); err != nil {
+		t.Fatalf("SubstituteConfigEnvVars() error = %v", err)
+	}
+
+	env := cfg.Services["web"].Env
+	if env["SECRET"] != "s3cret" {
+		t.Errorf("SECRET = %q, want value from secrets.enc", env["SECRET"])
+	}
+	if env["OVERRIDE"] != "from-env" {
+		t.Errorf("OVERRIDE = %q, want environment to take precedence", env["OVERRIDE"])
+	}
+	if _, ok := os.LookupEnv("PODLIFT_TEST_SECRET"); ok {
+		t.Error("secrets should not be exported to the process environment")
+	}
+
+	// Without a key the secrets file can't be used
+	os.Remove(filepath.Join(dir, secrets.KeyFileName))
+	if err := cfg.SubstituteConfigEnvVars(); err == nil || !strings.Contains(err.Error(), secrets.FileName) {
+		t.Errorf("SubstituteConfigEnvVars() error = %v, want missing key error", err)
+	}
+}
+
+func TestSubstituteConfigEnvVars_ServiceEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "web.env"), []byte("LOG_LEVEL=info\nDEBUG=false\nPRICE=$5\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "web.local.env"), []byte("DEBUG=true\n"), 0644)
+
+	cfg := &Config{
+		configPath: filepath.Join(dir, "podlift.yml"),
+		Services: map[string]Service{
+			"web": {
+				EnvFile: []string{"web.env", "web.local.env"},
+				Env:     map[string]string{"LOG_LEVEL": "debug"},
+			},
+		},
+	}
+
+	if err := cfg.SubstituteConfigEnvVars(); err != nil {
+		t.Fatalf("SubstituteConfigEnvVars() error = %v", err)
+	}
+
+	env := cfg.Services["web"].Env
+	want := map[string]string{"LOG_LEVEL": "debug", "DEBUG": "true", "PRICE": "$5"}
+	for k, v := range want {
+		if env[k] != v {
+			t.Errorf("env[%s] = %q, want %q", k, env[k], v)
+		}
+	}
+
+	cfg.Services["web"] = Service{EnvFile: []string{"missing.env"}}
+	if err := cfg.SubstituteConfigEnvVars(); err == nil || !strings.Contains(err.Error(), "missing.env not found") {
+		t.Errorf("SubstituteConfigEnvVars() error = %v, want missing env_file error", err)
+	}
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// environmentNamePattern restricts environment names to safe file/label values
+var environmentNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// LoadEnvironment reads the base config and deep-merges the environment overlay over it
+// e.g. podlift.yml + podlift.staging.yml for environment "staging"
+// An empty environment is the same as Load(path)
+func LoadEnvironment(path, environment string) (*Config, error) {
+	config, err := parseEnvironment(path, environment)
+	if err != nil {
+		return nil, err
+	}
+	return finishLoad(config)
+}
+
+// CheckEnvironment validates the config like LoadEnvironment without .env files or secrets
+// Only variables set in the process environment are substituted. Problems with values that
+// still contain a ${VAR} reference are skipped, since they can't be judged without it.
+func CheckEnvironment(path, environment string) error {
+	config, err := parseEnvironment(path, environment)
+	if err != nil {
+		return err
+	}
+
+	config.applyDefaults()
+	config.substitute(os.LookupEnv) // Unresolved variables stay as ${VAR}
+
+	err = config.Validate()
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+
+	var resolved ValidationErrors
+	for _, problem := range errs {
+		if !envVarPattern.MatchString(problem.Error()) {
+			resolved = append(resolved, problem)
+		}
+	}
+	if err := resolved.errOrNil(); err != nil {
+		return fmt.Errorf("configuration invalid: %w", err)
+	}
+	return nil
+}
+
+// parseEnvironment reads the base config with the environment overlay merged over it
+func parseEnvironment(path, environment string) (*Config, error) {
+	if environment == "" {
+		return parse(path)
+	}
+
+	if !environmentNamePattern.MatchString(environment) {
+		return nil, fmt.Errorf("invalid environment name: %q", environment)
+	}
+
+	base, err := readYAMLNode(path)
+	if err != nil {
+		return nil, err
+	}
+
+	overlayPath := OverlayPath(path, environment)
+	overlay, err := readYAMLNode(overlayPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("environment '%s' not found: %s does not exist", environment, overlayPath)
+		}
+		return nil, err
+	}
+
+	merged := mergeNodes(base, overlay)
+
+	var config Config
+	if err := merged.Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	config.configPath = path
+	config.environment = environment
+
+	return &config, nil
+}
+
+// OverlayPath returns the overlay file for an environment
+// e.g. /app/podlift.yml → /app/podlift.staging.yml
+func OverlayPath(path, environment string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + environment + ext
+}
+
+// Environment returns the active environment name (empty for the base config)
+func (c *Config) Environment() string {
+	return c.environment
+}
+
+// mergeNodes deep-merges overlay over base
+// Mappings are merged key by key; anything else (scalars, lists) is replaced.
+// A null value in the overlay removes the key from the base.
+func mergeNodes(base, overlay *yaml.Node) *yaml.Node {
+	if base == nil || base.Kind != yaml.MappingNode || overlay.Kind != yaml.MappingNode {
+		return overlay
+	}
+
+	merged := &yaml.Node{
+		Kind:   yaml.MappingNode,
+		Tag:    base.Tag,
+		Line:   base.Line,
+		Column: base.Column,
+	}
+	merged.Content = append(merged.Content, base.Content...)
+
+	for i := 0; i+1 < len(overlay.Content); i += 2 {
+		key, value := overlay.Content[i], overlay.Content[i+1]
+		idx := mappingIndex(merged, key.Value)
+
+		isNull := value.Kind == yaml.ScalarNode && value.Tag == "!!null"
+
+		switch {
+		case idx < 0 && isNull:
+			// Nothing to remove
+		case idx < 0:
+			merged.Content = append(merged.Content, key, value)
+		case isNull:
+			merged.Content = append(merged.Content[:idx], merged.Content[idx+2:]...)
+		default:
+			merged.Content[idx+1] = mergeNodes(merged.Content[idx+1], value)
+		}
+	}
+
+	return merged
+}
+
+// mappingIndex returns the index of a key in a mapping node's content, or -1
+func mappingIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const environmentBaseConfig = `service: myapp
+image: myapp
+domain: myapp.com
+servers:
+  - host: 192.168.1.10
+services:
+  web:
+    port: 8000
+    replicas: 2
+    env:
+      DEBUG: "false"
+      LOG_LEVEL: info
+proxy:
+  enabled: true
+`
+
+// writeEnvironmentFiles writes podlift.yml and optional extra files into a temp dir
+func writeEnvironmentFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files["podlift.yml"] = environmentBaseConfig
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "podlift.yml")
+}
+
+func TestLoadEnvironment_Overlay(t *testing.T) {
+	path := writeEnvironmentFiles(t, map[string]string{
+		"podlift.staging.yml": `domain: staging.myapp.com
+servers:
+  - host: 10.0.0.5
+services:
+  web:
+    replicas: 1
+    env:
+      DEBUG: "true"
+`,
+	})
+
+	cfg, err := LoadEnvironment(path, "staging")
+	if err != nil {
+		t.Fatalf("LoadEnvironment() error = %v", err)
+	}
+
+	if cfg.Environment() != "staging" {
+		t.Errorf("Environment() = %q, want staging", cfg.Environment())
+	}
+	if cfg.Domain != "staging.myapp.com" {
+		t.Errorf("Domain = %q, want staging.myapp.com", cfg.Domain)
+	}
+
+	// Lists are replaced, not appended
+	servers := cfg.GetAllServers()
+	if len(servers) != 1 || servers[0].Host != "10.0.0.5" {
+		t.Errorf("servers = %+v, want only 10.0.0.5", servers)
+	}
+
+	// Nested maps are merged key by key
+	web := cfg.Services["web"]
+	if web.Port != 8000 {
+		t.Errorf("web.Port = %d, want 8000 (from base)", web.Port)
+	}
+	if web.Replicas != 1 {
+		t.Errorf("web.Replicas = %d, want 1", web.Replicas)
+	}
+	if web.Env["DEBUG"] != "true" || web.Env["LOG_LEVEL"] != "info" {
+		t.Errorf("web.Env = %v, want DEBUG=true LOG_LEVEL=info", web.Env)
+	}
+}
+
+func TestLoadEnvironment_NullRemovesKey(t *testing.T) {
+	path := writeEnvironmentFiles(t, map[string]string{
+		"podlift.preview.yml": "domain: null\nservices:\n  web:\n    env:\n      LOG_LEVEL: null\n",
+	})
+
+	cfg, err := LoadEnvironment(path, "preview")
+	if err != nil {
+		t.Fatalf("LoadEnvironment() error = %v", err)
+	}
+	if cfg.Domain != "" {
+		t.Errorf("Domain = %q, want empty", cfg.Domain)
+	}
+	if _, ok := cfg.Services["web"].Env["LOG_LEVEL"]; ok {
+		t.Errorf("web.Env = %v, want LOG_LEVEL removed", cfg.Services["web"].Env)
+	}
+}
+
+func TestLoadEnvironment_Errors(t *testing.T) {
+	path := writeEnvironmentFiles(t, map[string]string{})
+
+	if _, err := LoadEnvironment(path, "production"); err == nil || !strings.Contains(err.Error(), "podlift.production.yml") {
+		t.Errorf("missing overlay error = %v, want mention of podlift.production.yml", err)
+	}
+
+	if _, err := LoadEnvironment(path, "../etc"); err == nil || !strings.Contains(err.Error(), "invalid environment name") {
+		t.Errorf("invalid name error = %v, want invalid environment name", err)
+	}
+
+	// No environment loads the base config alone
+	cfg, err := LoadEnvironment(path, "")
+	if err != nil {
+		t.Fatalf("LoadEnvironment() error = %v", err)
+	}
+	if cfg.Environment() != "" || cfg.Domain != "myapp.com" {
+		t.Errorf("base config = %q/%q, want no environment and myapp.com", cfg.Environment(), cfg.Domain)
+	}
+}
+
+func TestSubstituteConfigEnvVars_EnvironmentFile(t *testing.T) {
+	path := writeEnvironmentFiles(t, map[string]string{
+		"podlift.staging.yml": "domain: staging.myapp.com\n",
+		".env":                "PODLIFT_TEST_LEVEL=base\nPODLIFT_TEST_KEEP=kept\n",
+		".env.staging":        "PODLIFT_TEST_LEVEL=staging\n",
+	})
+	t.Setenv("PODLIFT_TEST_LEVEL", "")
+	t.Setenv("PODLIFT_TEST_KEEP", "")
+
+	cfg, err := LoadEnvironment(path, "staging")
+	if err != nil {
+		t.Fatalf("LoadEnvironment() error = %v", err)
+	}
+	if err := cfg.SubstituteConfigEnvVars(); err != nil {
+		t.Fatalf("SubstituteConfigEnvVars() error = %v", err)
+	}
+
+	if got := os.Getenv("PODLIFT_TEST_LEVEL"); got != "staging" {
+		t.Errorf("PODLIFT_TEST_LEVEL = %q, want staging (.env.staging overrides .env)", got)
+	}
+	if got := os.Getenv("PODLIFT_TEST_KEEP"); got != "kept" {
+		t.Errorf("PODLIFT_TEST_KEEP = %q, want kept", got)
+	}
+}
+
+func TestCheckEnvironment(t *testing.T) {
+	t.Setenv("PODLIFT_TEST_UNSET", "")
+	os.Unsetenv("PODLIFT_TEST_UNSET")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "podlift.yml")
+	// secrets.enc without a key: CI running validate --offline has neither the key nor the .env
+	if err := os.WriteFile(filepath.Join(dir, "secrets.enc"), []byte("podlift-secrets:v1\nAAAA\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`service: myapp
+image: myapp
+servers:
+  - host: ${PODLIFT_TEST_UNSET}
+    private_ip: ${PODLIFT_TEST_UNSET}
+services:
+  web:
+    port: 8000
+    env:
+      DATABASE_URL: ${DATABASE_URL}
+`)
+	if err := CheckEnvironment(path, ""); err != nil {
+		t.Errorf("CheckEnvironment() error = %v, want unresolved variables tolerated", err)
+	}
+
+	write(`service: myapp
+image: myapp
+servers:
+  - host: ${PODLIFT_TEST_UNSET}
+services:
+  web:
+    port: 8000
+    restart: sometimes
+`)
+	err := CheckEnvironment(path, "")
+	if err == nil || !strings.Contains(err.Error(), "invalid restart policy") {
+		t.Errorf("CheckEnvironment() error = %v, want invalid restart policy", err)
+	}
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+)
+
+var (
+	// exportPlaceholder matches {{host}}, {{port}} and {{name}} in dependency exports
+	exportPlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z_]*)\s*\}\}`)
+
+	// envNamePattern matches environment variable names
+	envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// validateExports checks dependency exports, and that servers using a dependency can reach it
+func (c *Config) validateExports(errs *ValidationErrors) {
+	exportedBy := make(map[string]string)
+
+	for _, name := range sortedKeys(c.Dependencies) {
+		dep := c.Dependencies[name]
+		if len(dep.Exports) == 0 {
+			continue
+		}
+
+		for _, key := range sortedKeys(dep.Exports) {
+			if !envNamePattern.MatchString(key) {
+				errs.add("dependency '%s' exports invalid variable name '%s'", name, key)
+			}
+			if other, ok := exportedBy[key]; ok {
+				errs.add("dependency '%s' exports %s, which is already exported by '%s'", name, key, other)
+			}
+			exportedBy[key] = name
+
+			for _, match := range exportPlaceholder.FindAllStringSubmatch(dep.Exports[key], -1) {
+				switch match[1] {
+				case "host", "name":
+				case "port":
+					if dep.Port == 0 {
+						errs.add("dependency '%s' export %s uses {{port}} but no port is set", name, key)
+					}
+				default:
+					errs.add("dependency '%s' export %s has unknown placeholder %s (use {{host}}, {{port}} or {{name}})", name, key, match[0])
+				}
+			}
+		}
+
+		// Services run on every server; the private network only spans the dependency's own server
+		depServer, _, err := c.GetDependencyServer(dep)
+		if err != nil {
+			continue // Reported with the placement checks
+		}
+		if !c.hasOtherServers(depServer.Host) || dependencyReachable(dep) {
+			continue
+		}
+		address := depServer.PrivateIP
+		if address == "" {
+			address = "the server's private IP"
+		}
+		errs.add("dependency '%s' is used from other servers: set publish: true and bind_address: %s", name, address)
+	}
+}
+
+// hasOtherServers reports whether any server has a different host
+func (c *Config) hasOtherServers(host string) bool {
+	for _, s := range c.GetAllServers() {
+		if s.Host != host {
+			return true
+		}
+	}
+	return false
+}
+
+// dependencyReachable reports whether a dependency's port is published beyond localhost
+func dependencyReachable(dep Dependency) bool {
+	if !dep.Publish {
+		return false
+	}
+	ip := net.ParseIP(dep.BindAddress)
+	return ip != nil && !ip.IsLoopback()
+}
+
+// ExportedEnv renders the exports of every dependency for services running on server
+// Dependencies on the same server are reached by name on the private network,
+// others by their bind address, the server's private IP, or its host.
+func (c *Config) ExportedEnv(server Server) (map[string]string, error) {
+	env := make(map[string]string)
+
+	for _, name := range sortedKeys(c.Dependencies) {
+		dep := c.Dependencies[name]
+		if len(dep.Exports) == 0 {
+			continue
+		}
+
+		depServer, _, err := c.GetDependencyServer(dep)
+		if err != nil {
+			return nil, fmt.Errorf("dependency '%s': %w", name, err)
+		}
+
+		values := map[string]string{
+			"host": dependencyAddress(name, dep, *depServer, server),
+			"port": strconv.Itoa(dep.Port),
+			"name": name,
+		}
+		for key, value := range dep.Exports {
+			env[key] = exportPlaceholder.ReplaceAllStringFunc(value, func(placeholder string) string {
+				return values[exportPlaceholder.FindStringSubmatch(placeholder)[1]]
+			})
+		}
+	}
+
+	return env, nil
+}
+
+// dependencyAddress returns the address a container on server uses to reach a dependency
+func dependencyAddress(name string, dep Dependency, depServer, server Server) string {
+	if depServer.Host == server.Host {
+		return name // Network alias
+	}
+	if ip := net.ParseIP(dep.BindAddress); dep.Publish && ip != nil && !ip.IsUnspecified() {
+		return dep.BindAddress
+	}
+	if depServer.PrivateIP != "" {
+		return depServer.PrivateIP
+	}
+	return depServer.Host
+}
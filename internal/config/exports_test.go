@@ -0,0 +1,125 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func exportsConfig(dep Dependency) *Config {
+	return &Config{
+		Service: "myapp",
+		Image:   "myapp",
+		Servers: ServersConfig{servers: map[string][]Server{
+			"web": {{Host: "203.0.113.10", Labels: []string{"primary"}}},
+			"db":  {{Host: "203.0.113.20", PrivateIP: "10.0.0.20"}},
+		}},
+		Dependencies: map[string]Dependency{"postgres": dep},
+	}
+}
+
+func TestExportedEnv(t *testing.T) {
+	web := Server{Host: "203.0.113.10"}
+	db := Server{Host: "203.0.113.20", PrivateIP: "10.0.0.20"}
+
+	tests := []struct {
+		name   string
+		dep    Dependency
+		server Server
+		want   string
+	}{
+		{
+			name:   "same server uses network alias",
+			dep:    Dependency{Image: "postgres:16", Port: 5432, Role: "db"},
+			server: db,
+			want:   "postgres://app@postgres:5432/app",
+		},
+		{
+			name:   "other server uses private IP",
+			dep:    Dependency{Image: "postgres:16", Port: 5432, Role: "db", Publish: true, BindAddress: "0.0.0.0"},
+			server: web,
+			want:   "postgres://app@10.0.0.20:5432/app",
+		},
+		{
+			name:   "bind address wins over private IP",
+			dep:    Dependency{Image: "postgres:16", Port: 5432, Role: "db", Publish: true, BindAddress: "10.0.1.20"},
+			server: web,
+			want:   "postgres://app@10.0.1.20:5432/app",
+		},
+		{
+			name:   "host without private IP",
+			dep:    Dependency{Image: "postgres:16", Port: 5432, Publish: true, BindAddress: "0.0.0.0"},
+			server: db,
+			want:   "postgres://app@203.0.113.10:5432/app",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.dep.Exports = map[string]string{"DATABASE_URL": "postgres://app@{{host}}:{{ port }}/app"}
+
+			env, err := exportsConfig(tt.dep).ExportedEnv(tt.server)
+			if err != nil {
+				t.Fatalf("ExportedEnv() error = %v", err)
+			}
+			if env["DATABASE_URL"] != tt.want {
+				t.Errorf("DATABASE_URL = %q, want %q", env["DATABASE_URL"], tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateExports(t *testing.T) {
+	tests := []struct {
+		name    string
+		dep     Dependency
+		wantErr string
+	}{
+		{
+			name: "valid",
+			dep: Dependency{Image: "postgres:16", Port: 5432, Role: "db", Publish: true, BindAddress: "10.0.0.20",
+				Exports: map[string]string{"DATABASE_URL": "postgres://{{host}}:{{port}}/{{name}}"}},
+		},
+		{
+			name:    "unknown placeholder",
+			dep:     Dependency{Image: "postgres:16", Port: 5432, Role: "db", Publish: true, BindAddress: "10.0.0.20", Exports: map[string]string{"URL": "{{hostname}}"}},
+			wantErr: "unknown placeholder {{hostname}}",
+		},
+		{
+			name:    "port placeholder without port",
+			dep:     Dependency{Image: "postgres:16", Exports: map[string]string{"URL": "{{host}}:{{port}}"}},
+			wantErr: "uses {{port}} but no port is set",
+		},
+		{
+			name:    "invalid variable name",
+			dep:     Dependency{Image: "postgres:16", Exports: map[string]string{"DATABASE-URL": "{{host}}"}},
+			wantErr: "invalid variable name 'DATABASE-URL'",
+		},
+		{
+			name:    "not reachable from other servers",
+			dep:     Dependency{Image: "postgres:16", Port: 5432, Role: "db", Exports: map[string]string{"URL": "{{host}}"}},
+			wantErr: "set publish: true and bind_address: 10.0.0.20",
+		},
+		{
+			name:    "published on localhost only",
+			dep:     Dependency{Image: "postgres:16", Port: 5432, Role: "db", Publish: true, BindAddress: "127.0.0.1", Exports: map[string]string{"URL": "{{host}}"}},
+			wantErr: "is used from other servers",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errs ValidationErrors
+			exportsConfig(tt.dep).validateExports(&errs)
+
+			if tt.wantErr == "" {
+				if len(errs) > 0 {
+					t.Errorf("validateExports() = %v, want no errors", errs)
+				}
+				return
+			}
+			if len(errs) == 0 || !strings.Contains(errs.Error(), tt.wantErr) {
+				t.Errorf("validateExports() = %v, want error containing %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
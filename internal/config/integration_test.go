@@ -176,7 +176,6 @@ func TestLoadFullConfig(t *testing.T) {
 	}
 
 	// Environment variables should be substituted
-	config.SubstituteConfigEnvVars()
 	if config.Registry.Username != "testuser" {
 		t.Errorf("Registry username = %v, want testuser", config.Registry.Username)
 	}
@@ -251,6 +250,10 @@ func TestLoadFullConfig(t *testing.T) {
 
 // TestGetAllServers tests getting all servers
 func TestGetAllServers(t *testing.T) {
+	for _, name := range []string{"REGISTRY_USER", "REGISTRY_PASSWORD", "DB_PASSWORD", "SECRET_KEY"} {
+		t.Setenv(name, "test")
+	}
+
 	config, err := Load("../../testdata/full.yml")
 	if err != nil {
 		t.Fatalf("Load() error = %v", err)
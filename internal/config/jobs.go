@@ -0,0 +1,114 @@
+package config
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ekinertac/podlift/internal/shell"
+)
+
+var (
+	// jobNamePattern restricts job names to what cron.d accepts in file names
+	jobNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+	// cronFieldPattern matches one field of a cron schedule, e.g. *, */15, 1-5, mon,wed
+	cronFieldPattern = regexp.MustCompile(`^(\*|[0-9A-Za-z]+(-[0-9A-Za-z]+)?)(/[0-9]+)?(,(\*|[0-9A-Za-z]+(-[0-9A-Za-z]+)?)(/[0-9]+)?)*$`)
+)
+
+// cronMacros are the schedule shortcuts cron accepts instead of five fields
+// @reboot is left out: it isn't a schedule, and deploys don't reboot.
+var cronMacros = map[string]bool{
+	"@yearly": true, "@annually": true, "@monthly": true, "@weekly": true,
+	"@daily": true, "@midnight": true, "@hourly": true,
+}
+
+// Job is a command run on a cron schedule in a one-off container of the current release
+type Job struct {
+	Schedule string   `yaml:"schedule"` // Cron schedule in the server's time zone, e.g. "0 3 * * *" or @daily
+	Command  string   `yaml:"command"`
+	Service  string   `yaml:"service,omitempty"` // Service whose env, volumes and limits the job gets (default: web or the only service)
+	Host     string   `yaml:"host,omitempty"`    // Run on the server with this host
+	Role     string   `yaml:"role,omitempty"`    // Run on the first server in this role
+	Labels   []string `yaml:"labels,omitempty"`  // Run on a server with one of these labels
+}
+
+// JobNames returns the names of all jobs, sorted
+func (c *Config) JobNames() []string {
+	return sortedKeys(c.Jobs)
+}
+
+// JobService returns the service a job runs as: its service, the only service, or web
+func (c *Config) JobService(name string) string {
+	if service := c.Jobs[name].Service; service != "" {
+		return service
+	}
+	if len(c.Services) == 1 {
+		return sortedKeys(c.Services)[0]
+	}
+	return "web"
+}
+
+// GetJobServer returns the server a job is installed on (default: the primary server)
+func (c *Config) GetJobServer(name string) (*Server, error) {
+	job := c.Jobs[name]
+	server, _, err := c.GetDependencyServer(Dependency{Host: job.Host, Role: job.Role, Labels: job.Labels})
+	return server, err
+}
+
+// JobsOn returns the sorted names of the jobs installed on server
+func (c *Config) JobsOn(server Server) ([]string, error) {
+	var names []string
+	for _, name := range c.JobNames() {
+		jobServer, err := c.GetJobServer(name)
+		if err != nil {
+			return nil, err
+		}
+		if jobServer.Host == server.Host {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// validateJobs checks job names, schedules, commands and placement
+func (c *Config) validateJobs(errs *ValidationErrors) {
+	for _, name := range sortedKeys(c.Jobs) {
+		job := c.Jobs[name]
+		if !jobNamePattern.MatchString(name) {
+			errs.add("job '%s' has an invalid name (use lowercase letters, digits, - and _)", name)
+		}
+		if !ValidCronSchedule(job.Schedule) {
+			errs.add("job '%s' has invalid schedule '%s' (e.g. \"0 3 * * *\" or @daily)", name, job.Schedule)
+		}
+		if strings.TrimSpace(job.Command) == "" {
+			errs.add("job '%s' missing command", name)
+		} else if _, err := shell.Split(job.Command); err != nil {
+			errs.add("job '%s' command: %w", name, err)
+		}
+		if _, ok := c.Services[c.JobService(name)]; !ok {
+			errs.add("job '%s' runs as unknown service '%s'", name, c.JobService(name))
+		}
+		if job.Host != "" || job.Role != "" || len(job.Labels) > 0 {
+			if _, err := c.GetJobServer(name); err != nil {
+				errs.add("job '%s': %w", name, err)
+			}
+		}
+	}
+}
+
+// ValidCronSchedule reports whether s is a cron schedule: five fields or a macro like @daily
+func ValidCronSchedule(s string) bool {
+	if cronMacros[s] {
+		return true
+	}
+	fields := strings.Fields(s)
+	if len(fields) != 5 {
+		return false
+	}
+	for _, field := range fields {
+		if !cronFieldPattern.MatchString(field) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoad_Jobs(t *testing.T) {
+	yaml := `service: myapp
+image: myapp
+servers:
+  web:
+    - host: 192.168.1.10
+  worker:
+    - host: 192.168.1.20
+      labels: [batch]
+services:
+  web: {}
+  worker: {}
+jobs:
+  clearsessions:
+    schedule: "0 3 * * *"
+    command: python manage.py clearsessions
+  report:
+    schedule: "@weekly"
+    command: python manage.py send_report --format pdf
+    service: worker
+    labels: [batch]
+`
+	path := filepath.Join(t.TempDir(), "podlift.yml")
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got := cfg.JobNames(); !reflect.DeepEqual(got, []string{"clearsessions", "report"}) {
+		t.Errorf("JobNames() = %v", got)
+	}
+	if got := cfg.JobService("clearsessions"); got != "web" {
+		t.Errorf("JobService(clearsessions) = %s, want web", got)
+	}
+	if got := cfg.JobService("report"); got != "worker" {
+		t.Errorf("JobService(report) = %s, want worker", got)
+	}
+
+	// Without placement a job runs on the primary server
+	if server, err := cfg.GetJobServer("clearsessions"); err != nil || server.Host != "192.168.1.10" {
+		t.Errorf("GetJobServer(clearsessions) = %v, %v", server, err)
+	}
+	names, err := cfg.JobsOn(Server{Host: "192.168.1.20"})
+	if err != nil || !reflect.DeepEqual(names, []string{"report"}) {
+		t.Errorf("JobsOn(192.168.1.20) = %v, %v", names, err)
+	}
+
+	if err := ValidateSchema(path, false); err != nil {
+		t.Errorf("ValidateSchema() error = %v", err)
+	}
+}
+
+func TestJobService_OnlyService(t *testing.T) {
+	cfg := &Config{Services: map[string]Service{"app": {}}, Jobs: map[string]Job{"cleanup": {}}}
+	if got := cfg.JobService("cleanup"); got != "app" {
+		t.Errorf("JobService() = %s, want the only service", got)
+	}
+}
+
+func TestValidCronSchedule(t *testing.T) {
+	valid := []string{"0 3 * * *", "*/15 * * * *", "0 9-17 * * 1-5", "0 0 1,15 * *", "30 4 * jan,jul sun", "@daily", "@hourly"}
+	for _, schedule := range valid {
+		if !ValidCronSchedule(schedule) {
+			t.Errorf("ValidCronSchedule(%q) = false, want true", schedule)
+		}
+	}
+
+	invalid := []string{"", "0 3 * *", "0 3 * * * *", "@reboot", "@every 5m", "0 3 * * * rm -rf /", "0 3 ? * *"}
+	for _, schedule := range invalid {
+		if ValidCronSchedule(schedule) {
+			t.Errorf("ValidCronSchedule(%q) = true, want false", schedule)
+		}
+	}
+}
+
+func TestValidateJobs(t *testing.T) {
+	tests := []struct {
+		name    string
+		job     Job
+		jobName string
+		wantErr string
+	}{
+		{name: "valid", job: Job{Schedule: "@daily", Command: "python manage.py clearsessions"}},
+		{name: "name", jobName: "clear.sessions", job: Job{Schedule: "@daily", Command: "true"}, wantErr: "job 'clear.sessions' has an invalid name"},
+		{name: "schedule", job: Job{Schedule: "daily", Command: "true"}, wantErr: "job 'cleanup' has invalid schedule 'daily'"},
+		{name: "command", job: Job{Schedule: "@daily"}, wantErr: "job 'cleanup' missing command"},
+		{name: "quotes", job: Job{Schedule: "@daily", Command: `echo "unterminated`}, wantErr: "job 'cleanup' command"},
+		{name: "service", job: Job{Schedule: "@daily", Command: "true", Service: "worker"}, wantErr: "job 'cleanup' runs as unknown service 'worker'"},
+		{name: "host", job: Job{Schedule: "@daily", Command: "true", Host: "10.0.0.9"}, wantErr: "job 'cleanup': dependency host '10.0.0.9' not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := tt.jobName
+			if name == "" {
+				name = "cleanup"
+			}
+			cfg := &Config{
+				Servers:  ServersConfig{servers: map[string][]Server{"web": {{Host: "10.0.0.1"}}}},
+				Services: map[string]Service{"web": {}},
+				Jobs:     map[string]Job{name: tt.job},
+			}
+			var errs ValidationErrors
+			cfg.validateJobs(&errs)
+
+			err := errs.errOrNil()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validation error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validation error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
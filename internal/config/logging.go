@@ -0,0 +1,136 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Log rotation podlift setup writes into daemon.json when no global logging is configured
+const (
+	DefaultLogDriver  = "json-file"
+	DefaultLogMaxSize = "10m"
+	DefaultLogMaxFile = 3
+)
+
+// rotatingLogDrivers are the drivers that take max-size and max-file
+var rotatingLogDrivers = map[string]bool{"": true, "json-file": true, "local": true}
+
+// ServiceLogging returns the logging of a service: its own logging on top of the global one
+// A service that picks another driver than the global one doesn't inherit its settings,
+// since options are driver-specific. Returns nil when neither is set.
+func (c *Config) ServiceLogging(name string) *LoggingConfig {
+	return mergeLogging(c.Logging, c.Services[name].Logging)
+}
+
+// DaemonLogging returns the default logging podlift setup writes into /etc/docker/daemon.json:
+// the global logging, or json-file rotated at 10m with 3 files
+func (c *Config) DaemonLogging() LoggingConfig {
+	if c.Logging == nil {
+		return LoggingConfig{Driver: DefaultLogDriver, MaxSize: DefaultLogMaxSize, MaxFile: DefaultLogMaxFile}
+	}
+
+	logging := *c.Logging
+	if !rotatingLogDrivers[logging.Driver] {
+		return logging
+	}
+	if logging.Driver == "" {
+		logging.Driver = DefaultLogDriver
+	}
+	if logging.MaxSize == "" {
+		logging.MaxSize = DefaultLogMaxSize
+	}
+	if logging.MaxFile == 0 {
+		logging.MaxFile = DefaultLogMaxFile
+	}
+	return logging
+}
+
+// mergeLogging returns override on top of base
+func mergeLogging(base, override *LoggingConfig) *LoggingConfig {
+	if base == nil || override == nil || (override.Driver != "" && override.Driver != base.Driver) {
+		if override != nil {
+			return override
+		}
+		return base
+	}
+
+	merged := *base
+	if override.MaxSize != "" {
+		merged.MaxSize = override.MaxSize
+	}
+	if override.MaxFile != 0 {
+		merged.MaxFile = override.MaxFile
+	}
+	if len(override.Options) > 0 {
+		merged.Options = make(map[string]string, len(base.Options)+len(override.Options))
+		for key, value := range base.Options {
+			merged.Options[key] = value
+		}
+		for key, value := range override.Options {
+			merged.Options[key] = value
+		}
+	}
+	return &merged
+}
+
+// DockerOptions returns the --log-opt values, including max_size and max_file
+func (l *LoggingConfig) DockerOptions() map[string]string {
+	if l == nil {
+		return nil
+	}
+	if l.MaxSize == "" && l.MaxFile == 0 {
+		return l.Options
+	}
+
+	options := make(map[string]string, len(l.Options)+2)
+	for key, value := range l.Options {
+		options[key] = value
+	}
+	if l.MaxSize != "" {
+		options["max-size"] = l.MaxSize
+	}
+	if l.MaxFile != 0 {
+		options["max-file"] = strconv.Itoa(l.MaxFile)
+	}
+	return options
+}
+
+// validateLogging checks the global logging and the logging of each service
+func (c *Config) validateLogging(errs *ValidationErrors) {
+	c.Logging.validate("logging", errs)
+
+	// Services without a driver of their own inherit the global one
+	for _, name := range sortedKeys(c.Services) {
+		own := c.Services[name].Logging
+		if own == nil || own.Driver != "" || c.Logging == nil {
+			continue
+		}
+		if (own.MaxSize != "" || own.MaxFile != 0) && !rotatingLogDrivers[c.Logging.Driver] {
+			errs.add("service '%s' logging max_size and max_file don't apply to the global %s driver", name, c.Logging.Driver)
+		}
+	}
+}
+
+// validate checks a logging config; owner names it in errors, e.g. "service 'web'"
+func (l *LoggingConfig) validate(owner string, errs *ValidationErrors) {
+	if l == nil {
+		return
+	}
+	if l.Driver != "" && !logDriverPattern.MatchString(l.Driver) {
+		errs.add("%s has invalid logging driver '%s'", owner, l.Driver)
+	}
+	for _, key := range sortedKeys(l.Options) {
+		if key == "" || strings.ContainsAny(key, "= \t") {
+			errs.add("%s has invalid logging option '%s'", owner, key)
+		}
+	}
+	if l.MaxSize != "" && !memoryPattern.MatchString(l.MaxSize) {
+		errs.add("%s has invalid logging max_size '%s' (e.g. 10m)", owner, l.MaxSize)
+	}
+	if l.MaxFile < 0 {
+		errs.add("%s logging max_file must be >= 1", owner)
+	}
+	if (l.MaxSize != "" || l.MaxFile != 0) && !rotatingLogDrivers[l.Driver] {
+		errs.add("%s logging max_size and max_file only apply to the json-file and local drivers, not %s", owner, l.Driver)
+	}
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoad_Logging(t *testing.T) {
+	yaml := `service: myapp
+image: myapp
+servers:
+  - host: 192.168.1.10
+logging:
+  max_size: 10m
+  max_file: 3
+services:
+  web:
+    logging:
+      max_size: 50m
+  worker:
+    logging:
+      driver: syslog
+      options:
+        syslog-address: udp://10.0.0.5:514
+        tag: worker
+`
+	path := filepath.Join(t.TempDir(), "podlift.yml")
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got := cfg.ServiceLogging("web").DockerOptions(); !reflect.DeepEqual(got, map[string]string{"max-size": "50m", "max-file": "3"}) {
+		t.Errorf("web logging options = %v, want the global max_file with its own max_size", got)
+	}
+
+	// Another driver doesn't inherit rotation meant for json-file
+	worker := cfg.ServiceLogging("worker")
+	want := map[string]string{"syslog-address": "udp://10.0.0.5:514", "tag": "worker"}
+	if worker.Driver != "syslog" || !reflect.DeepEqual(worker.DockerOptions(), want) {
+		t.Errorf("worker logging = %+v", worker)
+	}
+
+	if err := ValidateSchema(path, false); err != nil {
+		t.Errorf("ValidateSchema() error = %v", err)
+	}
+}
+
+func TestServiceLogging(t *testing.T) {
+	global := &LoggingConfig{Driver: "json-file", MaxSize: "10m", Options: map[string]string{"tag": "{{.Name}}"}}
+
+	tests := []struct {
+		name    string
+		global  *LoggingConfig
+		service *LoggingConfig
+		want    *LoggingConfig
+	}{
+		{name: "neither"},
+		{name: "global only", global: global, want: global},
+		{name: "service only", service: &LoggingConfig{Driver: "local"}, want: &LoggingConfig{Driver: "local"}},
+		{
+			name:    "service on top",
+			global:  global,
+			service: &LoggingConfig{MaxFile: 5, Options: map[string]string{"labels": "env"}},
+			want:    &LoggingConfig{Driver: "json-file", MaxSize: "10m", MaxFile: 5, Options: map[string]string{"tag": "{{.Name}}", "labels": "env"}},
+		},
+		{
+			name:    "other driver",
+			global:  global,
+			service: &LoggingConfig{Driver: "journald"},
+			want:    &LoggingConfig{Driver: "journald"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Logging: tt.global, Services: map[string]Service{"web": {Logging: tt.service}}}
+			if got := cfg.ServiceLogging("web"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ServiceLogging() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// Merging never changes the global options
+	if len(global.Options) != 1 {
+		t.Errorf("global options were modified: %v", global.Options)
+	}
+}
+
+func TestValidateLogging(t *testing.T) {
+	tests := []struct {
+		name    string
+		global  *LoggingConfig
+		service *LoggingConfig
+		wantErr string
+	}{
+		{name: "valid", global: &LoggingConfig{Driver: "local", MaxSize: "20m", MaxFile: 5}},
+		{name: "max_size", global: &LoggingConfig{MaxSize: "10 MB"}, wantErr: "logging has invalid logging max_size '10 MB'"},
+		{name: "max_file", service: &LoggingConfig{MaxFile: -1}, wantErr: "service 'web' logging max_file must be >= 1"},
+		{
+			name:    "rotation with syslog",
+			global:  &LoggingConfig{Driver: "syslog", MaxSize: "10m"},
+			wantErr: "logging max_size and max_file only apply to the json-file and local drivers, not syslog",
+		},
+		{
+			name:    "inherited driver",
+			global:  &LoggingConfig{Driver: "journald"},
+			service: &LoggingConfig{MaxFile: 3},
+			wantErr: "service 'web' logging max_size and max_file don't apply to the global journald driver",
+		},
+		{name: "option", service: &LoggingConfig{Options: map[string]string{"max size": "10m"}}, wantErr: "invalid logging option 'max size'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Logging: tt.global, Services: map[string]Service{"web": {Logging: tt.service}}}
+			var errs ValidationErrors
+			cfg.validateLogging(&errs)
+			for _, name := range sortedKeys(cfg.Services) {
+				cfg.Services[name].validateRuntime(name, &errs)
+			}
+
+			err := errs.errOrNil()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validation error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validation error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDaemonLogging(t *testing.T) {
+	cfg := &Config{}
+	if got := cfg.DaemonLogging(); !reflect.DeepEqual(got, LoggingConfig{Driver: "json-file", MaxSize: "10m", MaxFile: 3}) {
+		t.Errorf("DaemonLogging() = %+v, want json-file 10m x 3", got)
+	}
+
+	cfg.Logging = &LoggingConfig{Driver: "local", MaxFile: 10}
+	if got := cfg.DaemonLogging(); !reflect.DeepEqual(got, LoggingConfig{Driver: "local", MaxSize: "10m", MaxFile: 10}) {
+		t.Errorf("DaemonLogging() = %+v", got)
+	}
+
+	cfg.Logging = &LoggingConfig{Driver: "journald", Options: map[string]string{"tag": "{{.Name}}"}}
+	if got := cfg.DaemonLogging(); !reflect.DeepEqual(got, *cfg.Logging) {
+		t.Errorf("DaemonLogging() = %+v, want the global journald config", got)
+	}
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ekinertac/podlift/internal/shell"
+)
+
+var (
+	// memoryPattern matches docker memory sizes, e.g. 512m, 1g, 1.5G
+	memoryPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?[bkmgBKMG]?$`)
+
+	// userPattern matches user[:group] as names or ids
+	userPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+(:[A-Za-z0-9_.-]+)?$`)
+
+	// capabilityPattern matches Linux capabilities with or without the CAP_ prefix, or ALL
+	capabilityPattern = regexp.MustCompile(`^[A-Za-z_]+$`)
+
+	// signalPattern matches signal names (SIGTERM, TERM) or numbers
+	signalPattern = regexp.MustCompile(`^((SIG)?[A-Z][A-Z0-9+-]*|[0-9]+)$`)
+
+	// logDriverPattern matches built-in logging drivers and plugin names
+	logDriverPattern = regexp.MustCompile(`^[A-Za-z0-9_./:-]+$`)
+)
+
+// ulimitNames are the resource limits docker accepts for --ulimit
+var ulimitNames = map[string]bool{
+	"core": true, "cpu": true, "data": true, "fsize": true, "locks": true,
+	"memlock": true, "msgqueue": true, "nice": true, "nofile": true, "nproc": true,
+	"rss": true, "rtprio": true, "rttime": true, "sigpending": true, "stack": true,
+}
+
+// validateRuntime checks resource limits and runtime options of a service
+func (s Service) validateRuntime(name string, errs *ValidationErrors) {
+	if s.Memory != "" && !memoryPattern.MatchString(s.Memory) {
+		errs.add("service '%s' has invalid memory '%s' (e.g. 512m or 1g)", name, s.Memory)
+	}
+	if s.CPUs < 0 {
+		errs.add("service '%s' cpus must be > 0", name)
+	}
+	if s.PidsLimit < -1 {
+		errs.add("service '%s' pids_limit must be > 0 (or -1 for unlimited)", name)
+	}
+
+	for _, limit := range sortedKeys(s.Ulimits) {
+		if !ulimitNames[limit] {
+			errs.add("service '%s' has unknown ulimit '%s'", name, limit)
+			continue
+		}
+		if _, _, err := ParseUlimit(s.Ulimits[limit]); err != nil {
+			errs.add("service '%s' ulimit %s: %w", name, limit, err)
+		}
+	}
+
+	if s.User != "" && !userPattern.MatchString(s.User) {
+		errs.add("service '%s' has invalid user '%s' (use user[:group])", name, s.User)
+	}
+	for _, c := range append(append([]string(nil), s.CapAdd...), s.CapDrop...) {
+		if !capabilityPattern.MatchString(c) {
+			errs.add("service '%s' has invalid capability '%s'", name, c)
+		}
+	}
+	if s.StopSignal != "" && !signalPattern.MatchString(s.StopSignal) {
+		errs.add("service '%s' has invalid stop_signal '%s' (e.g. SIGTERM)", name, s.StopSignal)
+	}
+	if s.StopGracePeriod != "" {
+		if d, err := time.ParseDuration(s.StopGracePeriod); err != nil || d < 0 {
+			errs.add("service '%s' has invalid stop_grace_period '%s' (e.g. 30s)", name, s.StopGracePeriod)
+		}
+	}
+
+	s.Logging.validate(fmt.Sprintf("service '%s'", name), errs)
+
+	if hc := s.Healthcheck; hc != nil {
+		durations := []struct{ field, value string }{
+			{"timeout", hc.Timeout},
+			{"interval", hc.Interval},
+			{"start_period", hc.StartPeriod},
+		}
+		for _, d := range durations {
+			if d.value == "" {
+				continue
+			}
+			if parsed, err := time.ParseDuration(d.value); err != nil || parsed <= 0 {
+				errs.add("service '%s' healthcheck %s '%s' is not a duration (e.g. 10s)", name, d.field, d.value)
+			}
+		}
+		if hc.Retries < 0 {
+			errs.add("service '%s' healthcheck retries must be >= 0", name)
+		}
+		if _, err := shell.Split(hc.Command); err != nil {
+			errs.add("service '%s' healthcheck command: %w", name, err)
+		}
+	}
+}
+
+// ParseUlimit parses a ulimit value: a single limit, or soft:hard (-1 for unlimited)
+func ParseUlimit(value string) (soft, hard int64, err error) {
+	softStr, hardStr, found := strings.Cut(value, ":")
+	if !found {
+		hardStr = softStr
+	}
+
+	if soft, err = strconv.ParseInt(softStr, 10, 64); err != nil || soft < -1 {
+		return 0, 0, errInvalidUlimit(value)
+	}
+	if hard, err = strconv.ParseInt(hardStr, 10, 64); err != nil || hard < -1 {
+		return 0, 0, errInvalidUlimit(value)
+	}
+
+	// -1 means unlimited, which is larger than any soft limit
+	if hard != -1 && (soft == -1 || soft > hard) {
+		return 0, 0, errInvalidUlimit(value)
+	}
+	return soft, hard, nil
+}
+
+func errInvalidUlimit(value string) error {
+	return fmt.Errorf("invalid value '%s' (use a number or soft:hard, soft <= hard)", value)
+}
+
+// StopTimeout returns stop_grace_period in whole seconds (0 when unset)
+func (s Service) StopTimeout() int {
+	d, err := time.ParseDuration(s.StopGracePeriod)
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateRuntime(t *testing.T) {
+	tests := []struct {
+		name    string
+		service Service
+		wantErr string
+	}{
+		{
+			name: "valid",
+			service: Service{
+				Memory:          "512m",
+				CPUs:            0.5,
+				PidsLimit:       200,
+				Ulimits:         map[string]string{"nofile": "1024:65536", "nproc": "-1"},
+				User:            "1000:1000",
+				ReadOnly:        true,
+				CapAdd:          []string{"NET_BIND_SERVICE"},
+				CapDrop:         []string{"ALL"},
+				StopSignal:      "SIGQUIT",
+				StopGracePeriod: "30s",
+				Logging:         &LoggingConfig{Driver: "json-file", Options: map[string]string{"max-size": "10m"}},
+				Healthcheck:     &HealthcheckConfig{Command: "curl -f http://localhost:8000/health", Interval: "10s", StartPeriod: "5s"},
+			},
+		},
+		{"memory", Service{Memory: "lots"}, "invalid memory"},
+		{"cpus", Service{CPUs: -1}, "cpus must be > 0"},
+		{"pids limit", Service{PidsLimit: -2}, "pids_limit"},
+		{"unknown ulimit", Service{Ulimits: map[string]string{"files": "10"}}, "unknown ulimit 'files'"},
+		{"ulimit soft above hard", Service{Ulimits: map[string]string{"nofile": "100:10"}}, "ulimit nofile"},
+		{"ulimit not a number", Service{Ulimits: map[string]string{"nofile": "many"}}, "ulimit nofile"},
+		{"user", Service{User: "app user"}, "invalid user"},
+		{"capability", Service{CapAdd: []string{"NET-ADMIN"}}, "invalid capability"},
+		{"stop signal", Service{StopSignal: "sigterm;"}, "invalid stop_signal"},
+		{"stop grace period", Service{StopGracePeriod: "30"}, "invalid stop_grace_period"},
+		{"logging driver", Service{Logging: &LoggingConfig{Driver: "json file"}}, "invalid logging driver"},
+		{"healthcheck interval", Service{Healthcheck: &HealthcheckConfig{Interval: "often"}}, "healthcheck interval"},
+		{"healthcheck command", Service{Healthcheck: &HealthcheckConfig{Command: `sh -c "exit 1`}}, "healthcheck command"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errs ValidationErrors
+			tt.service.validateRuntime("web", &errs)
+
+			if tt.wantErr == "" {
+				if len(errs) > 0 {
+					t.Errorf("validateRuntime() = %v, want no errors", errs)
+				}
+				return
+			}
+			if len(errs) == 0 || !strings.Contains(errs.Error(), tt.wantErr) {
+				t.Errorf("validateRuntime() = %v, want error containing %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStopTimeout(t *testing.T) {
+	tests := map[string]int{"": 0, "30s": 30, "1m": 60, "1500ms": 2, "bad": 0}
+	for period, want := range tests {
+		if got := (Service{StopGracePeriod: period}).StopTimeout(); got != want {
+			t.Errorf("StopTimeout(%q) = %d, want %d", period, got, want)
+		}
+	}
+}
+
+func TestLoad_RuntimeOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "podlift.yml")
+	content := `service: myapp
+image: myapp
+servers:
+  - host: 192.168.1.10
+services:
+  web:
+    memory: 512m
+    cpus: 1.5
+    ulimits:
+      nofile: 65536
+    read_only: true
+    logging:
+      driver: local
+      options:
+        max-size: 10m
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	web := cfg.Services["web"]
+	if web.Memory != "512m" || web.CPUs != 1.5 || web.Ulimits["nofile"] != "65536" || !web.ReadOnly {
+		t.Errorf("runtime options not loaded: %+v", web)
+	}
+	if web.Logging == nil || web.Logging.Driver != "local" || web.Logging.Options["max-size"] != "10m" {
+		t.Errorf("logging not loaded: %+v", web.Logging)
+	}
+}
@@ -0,0 +1,269 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// SchemaID is the $id of the generated JSON Schema
+const SchemaID = "https://github.com/ekinertac/podlift/podlift.schema.json"
+
+// schemaDescriptions documents fields in the generated schema (keyed by Type.Field)
+var schemaDescriptions = map[string]string{
+	"Config.Service":      "Service name, used as a prefix for container names",
+	"Config.Domain":       "Domain served by the nginx proxy",
+	"Config.Image":        "Image name to build, or a prebuilt image reference",
+	"Config.Git":          "Git repository information",
+	"Config.Servers":      "Servers as a list, or a map of role to list",
+	"Config.Registry":     "Docker registry used to distribute images",
+	"Config.Dependencies": "Supporting containers such as databases and caches",
+	"Config.Services":     "Application services (default: a single web service)",
+	"Config.Proxy":        "nginx proxy and SSL settings",
+	"Config.Hooks":        "Commands run around deployments",
+	"Config.EnvFile":      "Path to the .env file (default: .env next to podlift.yml)",
+	"Config.Logging":      "Default docker logging of services and dependencies; services can override it",
+	"Config.Jobs":         "Commands run on a cron schedule in one-off containers of the current release",
+
+	"Job.Schedule": "Cron schedule in the server's time zone, e.g. \"0 3 * * *\" or @daily",
+	"Job.Command":  "Command run in the container, e.g. python manage.py clearsessions",
+	"Job.Service":  "Service whose env, volumes and limits the job gets (default: web or the only service)",
+	"Job.Host":     "Install on the server with this host",
+	"Job.Role":     "Install on the first server in this role",
+	"Job.Labels":   "Install on a server with one of these labels",
+
+	"Server.Host":      "Hostname or IP address",
+	"Server.User":      "SSH user (default: root)",
+	"Server.SSHKey":    "Path to the SSH private key (default: ~/.ssh/id_rsa)",
+	"Server.Port":      "SSH port (default: 22)",
+	"Server.Labels":    "Labels used for dependency placement, e.g. primary",
+	"Server.PrivateIP": "Private network address other servers use to reach dependencies on this server",
+
+	"RegistryConfig.PasswordCommand":  "Local command that prints the registry password",
+	"RegistryConfig.CredentialHelper": "Docker credential helper name, e.g. ecr-login",
+
+	"Dependency.Host":                "Run on the server with this host",
+	"Dependency.Role":                "Run on the first server in this role",
+	"Dependency.Labels":              "Run on a server with one of these labels",
+	"Dependency.Volume":              "Volume mount, e.g. postgres_data:/var/lib/postgresql/data",
+	"Dependency.Port":                "Port the dependency listens on",
+	"Dependency.Publish":             "Publish the port on the host (default: reachable only on the private network)",
+	"Dependency.BindAddress":         "Host address for the published port (default: 127.0.0.1)",
+	"Dependency.Backup":              "Backup strategy: postgres, mysql, redis or volume (default: detected from the image)",
+	"Dependency.Healthcheck":         "How podlift checks the dependency accepts connections (default: built-in probe for well-known images)",
+	"DependencyHealthcheck.Command":  "Command run in the container with sh -c, e.g. pg_isready -h 127.0.0.1",
+	"DependencyHealthcheck.Port":     "TCP port to connect to on the private network",
+	"DependencyHealthcheck.Interval": "Time between probes (default: 2s)",
+	"DependencyHealthcheck.Timeout":  "Time limit of one probe (default: 5s)",
+	"DependencyHealthcheck.Retries":  "Probes before the dependency counts as unhealthy (default: 30)",
+	"DependencyHealthcheck.Enabled":  "Set to false to only wait for the container to run",
+	"Dependency.DependsOn":           "Dependencies started before this one, as a list or a map of name: {condition: started|healthy}",
+	"DependsOnEntry.Condition":       "What to wait for: started (default), healthy, or completed for one-off jobs",
+	"Dependency.Exports":             "Env vars injected into every service, e.g. DATABASE_URL: postgres://app@{{host}}:{{port}}/app",
+
+	"Service.Port":      "Port the application listens on inside the container",
+	"Service.Replicas":  "Number of containers per server",
+	"Service.EnvFile":   "Local .env files whose variables are added to env",
+	"Service.Restart":   "Docker restart policy: no, always, unless-stopped or on-failure[:N] (default: unless-stopped)",
+	"Service.DependsOn": "Services and dependencies started before this one, as a list or a map of name: {condition: started|healthy|completed}",
+
+	"Service.Memory":          "Memory limit, e.g. 512m or 1g",
+	"Service.CPUs":            "Number of CPUs, e.g. 0.5",
+	"Service.PidsLimit":       "Maximum number of processes (-1 for unlimited)",
+	"Service.Ulimits":         "Resource limits, e.g. nofile: 65536 or \"1024:65536\"",
+	"Service.User":            "User to run as, user[:group]",
+	"Service.ReadOnly":        "Mount the root filesystem read-only",
+	"Service.CapAdd":          "Linux capabilities to add",
+	"Service.CapDrop":         "Linux capabilities to drop",
+	"Service.StopSignal":      "Signal sent to stop the container, e.g. SIGQUIT",
+	"Service.StopGracePeriod": "Time to wait after the stop signal before killing, e.g. 30s",
+	"Service.Logging":         "Docker logging driver and options, on top of the global logging",
+
+	"LoggingConfig.Driver":  "Logging driver, e.g. json-file, local, journald, syslog or fluentd",
+	"LoggingConfig.MaxSize": "Rotate the log file at this size, e.g. 10m (json-file and local)",
+	"LoggingConfig.MaxFile": "Number of rotated log files to keep (json-file and local)",
+	"LoggingConfig.Options": "Driver options, e.g. syslog-address: udp://10.0.0.5:514 or tag: \"{{.Name}}\"",
+
+	"HealthcheckConfig.Path":    "HTTP path to check",
+	"HealthcheckConfig.Command": "Command run inside the container as a docker HEALTHCHECK",
+	"HealthcheckConfig.Expect":  "Accepted HTTP status codes",
+	"HealthcheckConfig.Enabled": "Set to false to skip health checks",
+
+	"ProxyConfig.SSL": "SSL mode, e.g. letsencrypt",
+}
+
+// schemaRanges constrains numeric fields (keyed by Type.Field)
+var schemaRanges = map[string][2]int{
+	"Server.Port":                {1, 65535},
+	"Dependency.Port":            {1, 65535},
+	"DependencyHealthcheck.Port": {1, 65535},
+	"Service.Port":               {1, 65535},
+	"Service.Replicas":           {1, 1000},
+	"Service.PidsLimit":          {-1, 1 << 22},
+	"LoggingConfig.MaxFile":      {1, 100},
+}
+
+// Schema returns the JSON Schema for podlift.yml, generated from the Config types
+func Schema() map[string]interface{} {
+	g := &schemaGenerator{defs: make(map[string]interface{})}
+	root := g.structSchema(reflect.TypeOf(Config{}))
+
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["$id"] = SchemaID
+	root["title"] = "podlift configuration"
+	root["$defs"] = g.defs
+	return root
+}
+
+// SchemaJSON returns the schema as indented JSON
+func SchemaJSON() ([]byte, error) {
+	return json.MarshalIndent(Schema(), "", "  ")
+}
+
+// schemaGenerator builds schemas for Go types, sharing struct definitions under $defs
+type schemaGenerator struct {
+	defs map[string]interface{}
+}
+
+// typeSchema returns the schema for any config field type
+func (g *schemaGenerator) typeSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == serversConfigType {
+		server := g.ref(reflect.TypeOf(Server{}))
+		list := map[string]interface{}{"type": "array", "items": server, "minItems": 1}
+		return map[string]interface{}{
+			"oneOf": []interface{}{
+				list,
+				map[string]interface{}{
+					"type":                 "object",
+					"additionalProperties": list,
+					"minProperties":        1,
+				},
+			},
+		}
+	}
+
+	if t == dependsOnType {
+		names := map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}
+		return map[string]interface{}{
+			"oneOf": []interface{}{
+				names,
+				map[string]interface{}{
+					"type":                 "object",
+					"additionalProperties": g.ref(reflect.TypeOf(DependsOnEntry{})),
+				},
+			},
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": g.typeSchema(t.Elem())}
+	case reflect.Map:
+		values := g.typeSchema(t.Elem())
+		if t.Elem().Kind() == reflect.String {
+			// YAML scalars like 8000 or true decode into string values (env, options)
+			values = map[string]interface{}{"type": []interface{}{"string", "number", "boolean"}}
+		}
+		return map[string]interface{}{"type": "object", "properties": mergeKeyProperty(), "additionalProperties": values}
+	case reflect.Struct:
+		return g.ref(t)
+	}
+
+	return map[string]interface{}{}
+}
+
+// ref registers a struct under $defs and returns a reference to it
+func (g *schemaGenerator) ref(t reflect.Type) map[string]interface{} {
+	if _, ok := g.defs[t.Name()]; !ok {
+		g.defs[t.Name()] = nil // Reserve the name before recursing
+		g.defs[t.Name()] = g.structSchema(t)
+	}
+	return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+}
+
+// mergeKeyProperty allows YAML merge keys (<<: *defaults) in an object, as the
+// config loader does; editors that don't resolve them would otherwise flag them
+func mergeKeyProperty() map[string]interface{} {
+	return map[string]interface{}{
+		"<<": map[string]interface{}{
+			"description": "YAML merge key: fields merged from an anchor (<<: *defaults)",
+			"type":        []interface{}{"object", "array"},
+		},
+	}
+}
+
+// structSchema returns an object schema for a struct
+// Fields without omitempty are required; unknown keys are not allowed
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := mergeKeyProperty()
+	var required []interface{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+
+		tag := strings.Split(f.Tag.Get("yaml"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+
+		prop := g.typeSchema(f.Type)
+		key := t.Name() + "." + f.Name
+		if desc, ok := schemaDescriptions[key]; ok {
+			prop = withKeyword(prop, "description", desc)
+		}
+		if r, ok := schemaRanges[key]; ok {
+			prop = withKeyword(prop, "minimum", r[0])
+			prop = withKeyword(prop, "maximum", r[1])
+		}
+		properties[name] = prop
+
+		if !strings.Contains(f.Tag.Get("yaml"), "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// withKeyword returns a copy of a schema with an extra keyword
+// A $ref is wrapped in allOf so the keyword isn't ignored by older validators
+func withKeyword(schema map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(schema)+1)
+	if ref, ok := schema["$ref"]; ok {
+		result["allOf"] = []interface{}{map[string]interface{}{"$ref": ref}}
+	} else {
+		for k, v := range schema {
+			result[k] = v
+		}
+	}
+	result[key] = value
+	return result
+}
@@ -0,0 +1,230 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	data, err := SchemaJSON()
+	if err != nil {
+		t.Fatalf("SchemaJSON() error = %v", err)
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	required := schema["required"].([]interface{})
+	if len(required) != 3 {
+		t.Errorf("required = %v, want service, image, servers", required)
+	}
+
+	properties := schema["properties"].(map[string]interface{})
+	servers := properties["servers"].(map[string]interface{})
+	if forms := servers["oneOf"].([]interface{}); len(forms) != 2 {
+		t.Errorf("servers should accept a list or a map, got %v", forms)
+	}
+
+	defs := schema["$defs"].(map[string]interface{})
+	for _, name := range []string{"Server", "Dependency", "Service", "HealthcheckConfig", "RegistryConfig"} {
+		if _, ok := defs[name]; !ok {
+			t.Errorf("$defs missing %s", name)
+		}
+	}
+
+	dependency := defs["Dependency"].(map[string]interface{})["properties"].(map[string]interface{})
+	for _, field := range []string{"host", "role", "labels"} {
+		if _, ok := dependency[field]; !ok {
+			t.Errorf("Dependency schema missing placement field %s", field)
+		}
+	}
+}
+
+func TestValidateSchema_Testdata(t *testing.T) {
+	files, _ := filepath.Glob("../../testdata/*.yml")
+	examples, _ := filepath.Glob("../../examples/*/podlift.yml")
+	files = append(files, examples...)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			if err := ValidateSchema(file, false); err != nil {
+				t.Errorf("ValidateSchema(%s) error = %v", file, err)
+			}
+		})
+	}
+}
+
+func TestValidateSchema_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		partial bool
+		wantErr []string
+	}{
+		{
+			name: "missing required fields",
+			yaml: "domain: myapp.com\n",
+			wantErr: []string{
+				`podlift.yml:1:1: missing required field "service"`,
+				`missing required field "image"`,
+				`missing required field "servers"`,
+			},
+		},
+		{
+			name:    "partial overlay skips required",
+			yaml:    "domain: myapp.com\n",
+			partial: true,
+		},
+		{
+			name: "server map form",
+			yaml: `service: myapp
+image: myapp
+servers:
+  web:
+    - user: deploy
+`,
+			wantErr: []string{`podlift.yml:5:7: servers.web[0]: missing required field "host"`},
+		},
+		{
+			name: "servers wrong shape",
+			yaml: `service: myapp
+image: myapp
+servers: 192.168.1.10
+`,
+			wantErr: []string{`podlift.yml:3:10: servers: expected array or object, got string`},
+		},
+		{
+			name: "types and ranges",
+			yaml: `service: myapp
+image: myapp
+servers:
+  - host: 192.168.1.10
+services:
+  web:
+    port: 70000
+    replicas: two
+    healthcheck:
+      expect: 200
+`,
+			wantErr: []string{
+				"podlift.yml:7:11: services.web.port: must be <= 65535 (got: 70000)",
+				"podlift.yml:8:15: services.web.replicas: expected integer, got string",
+				"podlift.yml:10:15: services.web.healthcheck.expect: expected array, got integer",
+			},
+		},
+		{
+			name: "unknown field",
+			yaml: `service: myapp
+image: myapp
+servers:
+  - host: 192.168.1.10
+dependencies:
+  postgres:
+    image: postgres:16
+    volumes: [data:/var/lib/postgresql/data]
+`,
+			wantErr: []string{`podlift.yml:8:5: dependencies.postgres: unknown field "volumes"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "podlift.yml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := ValidateSchema(path, tt.partial)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Errorf("ValidateSchema() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("ValidateSchema() should fail")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("ValidateSchema() error = %q, want it to contain %q", err.Error(), want)
+				}
+			}
+		})
+	}
+}
+
+// The schema and the loader must agree on YAML merge keys
+func TestValidateSchema_MergeKeys(t *testing.T) {
+	yaml := `service: myapp
+image: myapp
+servers:
+  - host: 192.168.1.10
+services:
+  web: &app
+    replicas: 2
+    restart: unless-stopped
+    env: &common-env
+      DEBUG: "false"
+      LOG_LEVEL: info
+  worker:
+    <<: *app
+    command: celery worker
+    env:
+      <<: [*common-env]
+      ROLE: worker
+`
+	path := filepath.Join(t.TempDir(), "podlift.yml")
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ValidateSchema(path, false); err != nil {
+		t.Errorf("ValidateSchema() error = %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if worker := cfg.Services["worker"]; worker.Replicas != 2 || worker.Env["LOG_LEVEL"] != "info" || worker.Env["ROLE"] != "worker" {
+		t.Errorf("worker = %+v, want the merged fields", worker)
+	}
+
+	schema := Schema()
+	service := schema["$defs"].(map[string]interface{})["Service"].(map[string]interface{})
+	if _, ok := service["properties"].(map[string]interface{})["<<"]; !ok {
+		t.Error("Service schema should allow the merge key for editors")
+	}
+}
+
+func TestValidateSchema_MergedFields(t *testing.T) {
+	yaml := `service: myapp
+image: myapp
+servers:
+  - host: 192.168.1.10
+dependencies:
+  postgres: &db
+    image: postgres:16
+  replica:
+    <<: *db
+    port: 5433
+    volumes: [data:/data]
+`
+	path := filepath.Join(t.TempDir(), "podlift.yml")
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The merged image satisfies the required field; keys next to the merge key are still checked
+	err := ValidateSchema(path, false)
+	if err == nil || !strings.Contains(err.Error(), `dependencies.replica: unknown field "volumes"`) {
+		t.Errorf("ValidateSchema() error = %v, want the unknown field next to the merge key", err)
+	}
+	if err != nil && strings.Contains(err.Error(), "missing required field") {
+		t.Errorf("ValidateSchema() error = %v, want required fields taken from the merged anchor", err)
+	}
+}
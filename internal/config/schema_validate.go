@@ -0,0 +1,311 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaError is a schema violation at a position in a YAML file
+type SchemaError struct {
+	File    string
+	Line    int
+	Column  int
+	Path    string // e.g. "services.web.port"
+	Message string
+}
+
+func (e *SchemaError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", e.File, e.Line, e.Column, e.Path, e.Message)
+}
+
+// ValidateSchema checks a YAML file against the podlift JSON Schema without loading it
+// Overlay files (partial) may leave out required fields, since the base config provides them.
+func ValidateSchema(path string, partial bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse YAML in %s: %w", path, err)
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: 1, Column: 1}
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		root = doc.Content[0]
+	}
+
+	v := &schemaValidator{
+		root:    Schema(),
+		file:    filepath.Base(path),
+		partial: partial,
+	}
+	v.validate(root, v.root, "")
+	return v.errs.errOrNil()
+}
+
+// schemaValidator implements the subset of JSON Schema used by Schema()
+type schemaValidator struct {
+	root    map[string]interface{}
+	file    string
+	partial bool
+	errs    ValidationErrors
+}
+
+// fail records an error at a node
+func (v *schemaValidator) fail(node *yaml.Node, path, format string, args ...interface{}) {
+	v.errs = append(v.errs, &SchemaError{
+		File:    v.file,
+		Line:    node.Line,
+		Column:  node.Column,
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// validate checks a node against a schema, recording every violation
+func (v *schemaValidator) validate(node *yaml.Node, schema map[string]interface{}, path string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		v.validate(node, v.resolve(ref), path)
+		return
+	}
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			v.validate(node, sub.(map[string]interface{}), path)
+		}
+	}
+	if one, ok := schema["oneOf"].([]interface{}); ok {
+		v.validateOneOf(node, one, path)
+		return
+	}
+
+	// Null decodes to the zero value, so it's accepted for any field
+	if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null" {
+		return
+	}
+
+	if types, ok := schema["type"]; ok && !matchesType(node, types) {
+		v.fail(node, path, "expected %s, got %s", describeTypes(types), nodeType(node))
+		return
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		v.validateObject(node, schema, path)
+	case yaml.SequenceNode:
+		if min, ok := schema["minItems"].(int); ok && len(node.Content) < min {
+			v.fail(node, path, "must have at least %d item(s)", min)
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range node.Content {
+				v.validate(item, items, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	case yaml.ScalarNode:
+		v.validateRange(node, schema, path)
+	}
+}
+
+// validateObject checks properties, additionalProperties and required fields
+func (v *schemaValidator) validateObject(node *yaml.Node, schema map[string]interface{}, path string) {
+	properties, _ := schema["properties"].(map[string]interface{})
+	seen := make(map[string]bool)
+
+	if min, ok := schema["minProperties"].(int); ok && len(node.Content)/2 < min {
+		v.fail(node, path, "must have at least %d entr(ies)", min)
+	}
+
+	for _, pair := range mappingPairs(node) {
+		key, value := pair[0], pair[1]
+		seen[key.Value] = true
+
+		if prop, ok := properties[key.Value].(map[string]interface{}); ok {
+			v.validate(value, prop, joinPath(path, key.Value))
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.fail(key, path, "unknown field %q", key.Value)
+			}
+		case map[string]interface{}:
+			v.validate(value, additional, joinPath(path, key.Value))
+		}
+	}
+
+	if v.partial {
+		return
+	}
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if !seen[name.(string)] {
+				v.fail(node, path, "missing required field %q", name)
+			}
+		}
+	}
+}
+
+// mappingPairs returns the key/value pairs of a mapping with YAML merge keys
+// (<<: *defaults) resolved, as strict.go accepts them: merged pairs come first,
+// and keys of the mapping itself override them
+func mappingPairs(node *yaml.Node) [][2]*yaml.Node {
+	var merged, own [][2]*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.ShortTag() != "!!merge" {
+			own = append(own, [2]*yaml.Node{key, value})
+			continue
+		}
+
+		sources := []*yaml.Node{value}
+		if value.Kind == yaml.SequenceNode {
+			sources = value.Content
+		}
+		for _, source := range sources {
+			if source.Kind == yaml.AliasNode {
+				source = source.Alias
+			}
+			if source.Kind == yaml.MappingNode {
+				merged = append(merged, mappingPairs(source)...)
+			}
+		}
+	}
+
+	ownKeys := make(map[string]bool, len(own))
+	for _, pair := range own {
+		ownKeys[pair[0].Value] = true
+	}
+	var pairs [][2]*yaml.Node
+	for _, pair := range merged {
+		if !ownKeys[pair[0].Value] {
+			pairs = append(pairs, pair)
+		}
+	}
+	return append(pairs, own...)
+}
+
+// validateOneOf accepts a node matching exactly one alternative
+func (v *schemaValidator) validateOneOf(node *yaml.Node, options []interface{}, path string) {
+	var closest ValidationErrors
+	closestSet := false
+
+	for _, option := range options {
+		sub := &schemaValidator{root: v.root, file: v.file, partial: v.partial}
+		schema := option.(map[string]interface{})
+		sub.validate(node, schema, path)
+		if len(sub.errs) == 0 {
+			return
+		}
+
+		// Report the errors of the alternative with the same shape (list vs map)
+		if types, ok := schema["type"]; ok && matchesType(node, types) {
+			closest = sub.errs
+			closestSet = true
+		}
+	}
+
+	if closestSet {
+		v.errs = append(v.errs, closest...)
+		return
+	}
+
+	var forms []string
+	for _, option := range options {
+		if t, ok := option.(map[string]interface{})["type"]; ok {
+			forms = append(forms, describeTypes(t))
+		}
+	}
+	v.fail(node, path, "expected %s, got %s", strings.Join(forms, " or "), nodeType(node))
+}
+
+// validateRange checks minimum/maximum on numbers
+func (v *schemaValidator) validateRange(node *yaml.Node, schema map[string]interface{}, path string) {
+	n, err := strconv.ParseFloat(node.Value, 64)
+	if err != nil {
+		return
+	}
+	if min, ok := schema["minimum"].(int); ok && n < float64(min) {
+		v.fail(node, path, "must be >= %d (got: %s)", min, node.Value)
+	}
+	if max, ok := schema["maximum"].(int); ok && n > float64(max) {
+		v.fail(node, path, "must be <= %d (got: %s)", max, node.Value)
+	}
+}
+
+// resolve looks up a local "#/$defs/Name" reference
+func (v *schemaValidator) resolve(ref string) map[string]interface{} {
+	name := strings.TrimPrefix(ref, "#/$defs/")
+	defs, _ := v.root["$defs"].(map[string]interface{})
+	schema, _ := defs[name].(map[string]interface{})
+	return schema
+}
+
+// matchesType checks a node against a "type" keyword (string or list of strings)
+func matchesType(node *yaml.Node, types interface{}) bool {
+	actual := nodeType(node)
+	for _, t := range typeList(types) {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeType returns the JSON Schema type name for a YAML node
+func nodeType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+
+	switch node.ShortTag() {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	case "!!null":
+		return "null"
+	}
+	return "string"
+}
+
+// describeTypes formats a "type" keyword for error messages
+func describeTypes(types interface{}) string {
+	list := typeList(types)
+	sort.Strings(list)
+	return strings.Join(list, " or ")
+}
+
+// typeList normalizes a "type" keyword to a list
+func typeList(types interface{}) []string {
+	switch t := types.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		var result []string
+		for _, item := range t {
+			result = append(result, item.(string))
+		}
+		return result
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationErrors collects every problem found in a config instead of stopping at the first
+type ValidationErrors []error
+
+// Error lists all problems, one per line
+func (e ValidationErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	lines := []string{fmt.Sprintf("%d problems found:", len(e))}
+	for _, err := range e {
+		lines = append(lines, "  - "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap exposes the individual errors to errors.Is / errors.As
+func (e ValidationErrors) Unwrap() []error {
+	return e
+}
+
+// add appends a formatted error
+func (e *ValidationErrors) add(format string, args ...interface{}) {
+	*e = append(*e, fmt.Errorf(format, args...))
+}
+
+// errOrNil returns nil when no errors were collected
+func (e ValidationErrors) errOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// UnknownFieldError reports a key that doesn't exist in the config schema
+type UnknownFieldError struct {
+	File       string
+	Line       int
+	Column     int
+	Field      string
+	Path       string // Parent path, e.g. "services.web"
+	Suggestion string // Closest known field, if any
+}
+
+func (e *UnknownFieldError) Error() string {
+	msg := fmt.Sprintf("%s:%d:%d: unknown field %q", e.File, e.Line, e.Column, e.Field)
+	if e.Path != "" {
+		msg += " in " + e.Path
+	}
+	if e.Suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", e.Suggestion)
+	}
+	return msg
+}
+
+var serversConfigType = reflect.TypeOf(ServersConfig{})
+
+// checkKnownFields walks a parsed YAML node against the Config type and reports unknown keys
+// yaml.v3's KnownFields doesn't reach into custom unmarshalers (ServersConfig), so the walk is done here
+func checkKnownFields(root *yaml.Node, file string) error {
+	var errs ValidationErrors
+	walkKnownFields(root, reflect.TypeOf(Config{}), "", file, &errs)
+	return errs.errOrNil()
+}
+
+// walkKnownFields recursively checks a node against a Go type
+func walkKnownFields(node *yaml.Node, t reflect.Type, path, file string, errs *ValidationErrors) {
+	if node == nil {
+		return
+	}
+	if node.Kind == yaml.DocumentNode {
+		for _, child := range node.Content {
+			walkKnownFields(child, t, path, file, errs)
+		}
+		return
+	}
+	if node.Kind == yaml.AliasNode {
+		walkKnownFields(node.Alias, t, path, file, errs)
+		return
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// Servers: either a list of servers or a map of role → list
+	if t == serversConfigType {
+		switch node.Kind {
+		case yaml.MappingNode:
+			walkKnownFields(node, reflect.TypeOf(map[string][]Server{}), path, file, errs)
+		case yaml.SequenceNode:
+			walkKnownFields(node, reflect.TypeOf([]Server{}), path, file, errs)
+		}
+		return
+	}
+
+	// depends_on: either a list of names or a map of name → entry
+	if t == dependsOnType && node.Kind == yaml.SequenceNode {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" {
+				// Merge key: check the merged mapping's fields too
+				walkKnownFields(value, t, path, file, errs)
+				continue
+			}
+			field, ok := fields[key.Value]
+			if !ok {
+				*errs = append(*errs, &UnknownFieldError{
+					File:       file,
+					Line:       key.Line,
+					Column:     key.Column,
+					Field:      key.Value,
+					Path:       path,
+					Suggestion: suggestField(key.Value, fields),
+				})
+				continue
+			}
+			walkKnownFields(value, field, joinPath(path, key.Value), file, errs)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			walkKnownFields(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value), file, errs)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range node.Content {
+			walkKnownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), file, errs)
+		}
+	}
+}
+
+// yamlFields maps YAML keys of a struct to their field types
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// suggestField returns the known field closest to an unknown key, if it's close enough
+func suggestField(key string, fields map[string]reflect.Type) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	best, bestDist := "", -1
+	for _, name := range names {
+		d := levenshtein(strings.ToLower(key), name)
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+
+	// Allow roughly one typo per three characters
+	maxDist := len(key) / 3
+	if maxDist < 2 {
+		maxDist = 2
+	}
+	if bestDist < 0 || bestDist > maxDist {
+		return ""
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// joinPath builds a dotted config path, e.g. "services" + "web" → "services.web"
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad_UnknownFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr []string
+	}{
+		{
+			name: "typo in service",
+			yaml: `service: myapp
+image: myapp
+servers:
+  - host: 192.168.1.10
+services:
+  web:
+    replica: 3
+`,
+			wantErr: []string{`podlift.yml:7:5: unknown field "replica" in services.web (did you mean "replicas"?)`},
+		},
+		{
+			name: "typo in nested healthcheck",
+			yaml: `service: myapp
+image: myapp
+servers:
+  - host: 192.168.1.10
+services:
+  web:
+    healtcheck:
+      path: /health
+`,
+			wantErr: []string{`unknown field "healtcheck" in services.web (did you mean "healthcheck"?)`},
+		},
+		{
+			name: "server list",
+			yaml: `service: myapp
+image: myapp
+servers:
+  - host: 192.168.1.10
+    sshkey: ~/.ssh/deploy
+`,
+			wantErr: []string{`podlift.yml:5:5: unknown field "sshkey" in servers[0] (did you mean "ssh_key"?)`},
+		},
+		{
+			name: "server roles",
+			yaml: `service: myapp
+image: myapp
+servers:
+  db:
+    - host: 192.168.1.20
+      lables: [primary]
+`,
+			wantErr: []string{`unknown field "lables" in servers.db[0] (did you mean "labels"?)`},
+		},
+		{
+			name: "typo in depends_on entry",
+			yaml: `service: myapp
+image: myapp
+servers:
+  - host: 192.168.1.10
+services:
+  web:
+    depends_on:
+      postgres: {conditon: healthy}
+`,
+			wantErr: []string{`unknown field "conditon" in services.web.depends_on.postgres (did you mean "condition"?)`},
+		},
+		{
+			name: "all problems reported, no suggestion when nothing is close",
+			yaml: `service: myapp
+image: myapp
+servers:
+  - host: 192.168.1.10
+flavor: vanilla
+proxy:
+  enabld: true
+`,
+			wantErr: []string{
+				"2 problems found",
+				`podlift.yml:5:1: unknown field "flavor"` + "\n",
+				`unknown field "enabld" in proxy (did you mean "enabled"?)`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "podlift.yml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := Load(path)
+			if err == nil {
+				t.Fatal("Load() should fail on unknown fields")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error()+"\n", want) {
+					t.Errorf("Load() error = %q, want it to contain %q", err.Error(), want)
+				}
+			}
+
+			var unknown *UnknownFieldError
+			if !errors.As(err, &unknown) {
+				t.Errorf("Load() error should contain an UnknownFieldError")
+			}
+		})
+	}
+}
+
+func TestLoadEnvironment_UnknownFieldInOverlay(t *testing.T) {
+	path := writeEnvironmentFiles(t, map[string]string{
+		"podlift.staging.yml": "domian: staging.myapp.com\n",
+	})
+
+	_, err := LoadEnvironment(path, "staging")
+	if err == nil || !strings.Contains(err.Error(), `podlift.staging.yml:1:1: unknown field "domian" (did you mean "domain"?)`) {
+		t.Errorf("LoadEnvironment() error = %v, want unknown field in podlift.staging.yml", err)
+	}
+}
+
+func TestValidate_ReportsAllErrors(t *testing.T) {
+	cfg := &Config{
+		Services: map[string]Service{
+			"worker": {Port: 0, Replicas: 1},
+			"web":    {Port: 8000, Replicas: 0},
+		},
+		Dependencies: map[string]Dependency{
+			"redis": {},
+		},
+	}
+
+	err := cfg.Validate()
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Validate() error = %v, want ValidationErrors", err)
+	}
+
+	want := []string{
+		"service name is required",
+		"image name is required",
+		"at least one server is required",
+		"service 'web' replicas must be >= 1",
+		"service 'worker' has invalid port: 0",
+		"dependency 'redis' missing image",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Validate() returned %d errors, want %d: %v", len(errs), len(want), err)
+	}
+	for i, w := range want {
+		if errs[i].Error() != w {
+			t.Errorf("error %d = %q, want %q", i, errs[i].Error(), w)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"replica", "replicas", 1},
+		{"healtcheck", "healthcheck", 1},
+		{"sshkey", "ssh_key", 1},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
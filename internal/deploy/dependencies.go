@@ -1,87 +1,87 @@
 package deploy
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/ekinertac/podlift/internal/config"
 	"github.com/ekinertac/podlift/internal/docker"
+	"github.com/ekinertac/podlift/internal/shell"
 	"github.com/ekinertac/podlift/internal/ssh"
 	"github.com/ekinertac/podlift/internal/ui"
 )
 
-// DeployDependencies deploys dependency containers (postgres, redis, etc.)
-func DeployDependencies(cfg *config.Config, client ssh.SSHClient, version string) error {
-	if len(cfg.Dependencies) == 0 {
+// DependencyOptions controls how dependencies are started
+type DependencyOptions struct {
+	Version      string // Release version recorded on new containers
+	IgnoreHealth bool   // Warn instead of failing when a dependency isn't healthy
+}
+
+// DeployDependencies starts the dependencies placed on server (postgres, redis, etc.)
+// Dependencies pinned to another server with host, role or labels are left alone.
+func DeployDependencies(cfg *config.Config, client ssh.SSHClient, server config.Server, opts DependencyOptions) error {
+	names, err := cfg.DependenciesOn(server)
+	if err != nil {
+		return err
+	}
+	return StartDependencies(cfg, client, names, opts)
+}
+
+// StartDependencies starts the named dependencies that aren't running on the connected server
+// in depends_on order, and waits until each one passes its health probe.
+// Existing containers are kept; a changed config is reported and applied with `podlift deps upgrade`.
+func StartDependencies(cfg *config.Config, client ssh.SSHClient, names []string, opts DependencyOptions) error {
+	if len(names) == 0 {
 		return nil // No dependencies
 	}
 
-	fmt.Println(ui.Info(fmt.Sprintf("Starting %d dependencies...", len(cfg.Dependencies))))
+	fmt.Println(ui.Info(fmt.Sprintf("Starting %d dependencies...", len(names))))
 	fmt.Println()
 
-	for name, dep := range cfg.Dependencies {
-		// Check if dependency already running
-		checkCmd := fmt.Sprintf(
-			`sudo docker ps --filter "name=%s-%s" --format "{{.Names}}"`,
-			cfg.Service, name,
-		)
-		
-		output, _ := client.Execute(checkCmd)
-		if strings.TrimSpace(output) != "" {
-			fmt.Println(ui.Success(fmt.Sprintf("  %s: already running", name)))
-			continue
-		}
-
-		// Start dependency
-		fmt.Println(ui.Info(fmt.Sprintf("  Starting %s...", name)))
-
-		containerName := fmt.Sprintf("%s-%s", cfg.Service, name)
-
-		// Build docker run command
-		containerCfg := docker.ContainerConfig{
-			Name:    containerName,
-			Image:   dep.Image,
-			Port:    dep.Port,
-			Env:     dep.Env,
-			Command: dep.Command,
-			Labels: map[string]string{
-				"podlift.service":    cfg.Service,
-				"podlift.dependency": name,
-				"podlift.version":    version,
-				"podlift.created_at": time.Now().Format(time.RFC3339),
-			},
-			Options: dep.Options,
-		}
-
-		// Handle volume
-		if dep.Volume != "" {
-			containerCfg.Volumes = []string{dep.Volume}
-			
-			// Create named volume if needed
-			volumeName := strings.Split(dep.Volume, ":")[0]
-			createVolumeCmd := fmt.Sprintf("sudo docker volume create %s 2>/dev/null || true", volumeName)
-			client.Execute(createVolumeCmd)
-		}
+	for _, name := range inDependencyOrder(cfg, names) {
+		containerName := dependencyContainerName(cfg, name)
 
-		// Add restart policy for dependencies
-		if containerCfg.Options == nil {
-			containerCfg.Options = make(map[string]string)
+		// Check if dependency already exists
+		state := dependencyState(client, containerName)
+		if state != "" {
+			if changed := inspectDependency(cfg, client, name); changed.Changed {
+				fmt.Println(ui.Warning(fmt.Sprintf("  %s: config changed since the container was created (run: podlift deps upgrade %s)", name, name)))
+			}
 		}
-		containerCfg.Options["restart"] = "unless-stopped"
+		if state == "running" {
+			// Containers started before the private network existed are attached to it
+			// (docker refuses if they already are)
+			connectCmd := docker.GenerateNetworkConnectCommand(docker.NetworkName(cfg.Service), containerName, name)
+			client.Execute(connectCmd + " 2>/dev/null || true")
 
-		runCmd := docker.GenerateRunCommand(containerCfg)
-		
-		if _, err := client.Execute(runCmd); err != nil {
-			return fmt.Errorf("failed to start dependency %s: %w", name, err)
+			fmt.Println(ui.Success(fmt.Sprintf("  %s: already running", name)))
+		} else if state != "" {
+			// Stopped (e.g. with restart: no after a reboot): keep its data and config
+			fmt.Println(ui.Info(fmt.Sprintf("  Starting %s (was %s)...", name, state)))
+			if output, err := client.Execute(shell.Join("sudo", "docker", "start", containerName)); err != nil {
+				return fmt.Errorf("failed to start dependency %s: %w\n%s", name, err, output)
+			}
+			fmt.Println(ui.Success(fmt.Sprintf("  %s: started", name)))
+		} else {
+			fmt.Println(ui.Info(fmt.Sprintf("  Starting %s...", name)))
+			if err := createDependency(cfg, client, name, opts.Version); err != nil {
+				return err
+			}
+			fmt.Println(ui.Success(fmt.Sprintf("  %s: started", name)))
 		}
 
-		fmt.Println(ui.Success(fmt.Sprintf("  %s: started", name)))
-
-		// Wait for dependency to be healthy
-		if err := waitForDependencyHealth(client, containerName, name, 30); err != nil {
+		// A running container isn't necessarily accepting connections yet,
+		// and the app would crash-loop against it
+		if err := waitForDependency(client, cfg, name); err != nil {
+			if !opts.IgnoreHealth {
+				return fmt.Errorf("dependency %s is not healthy: %w (check: podlift deps status, or deploy with --ignore-dependency-health)", name, err)
+			}
 			fmt.Println(ui.Warning(fmt.Sprintf("  %s: %v", name, err)))
-			fmt.Println(ui.Info(fmt.Sprintf("  %s: continuing anyway (check logs later)", name)))
+			fmt.Println(ui.Info(fmt.Sprintf("  %s: continuing anyway (--ignore-dependency-health)", name)))
 		} else {
 			fmt.Println(ui.Success(fmt.Sprintf("  %s: healthy", name)))
 		}
@@ -94,18 +94,123 @@ func DeployDependencies(cfg *config.Config, client ssh.SSHClient, version string
 	return nil
 }
 
-// StopDependencies stops dependency containers (used during removal)
-func StopDependencies(cfg *config.Config, client ssh.SSHClient) error {
-	if len(cfg.Dependencies) == 0 {
+// createDependency writes the env file of a dependency and runs its container
+func createDependency(cfg *config.Config, client ssh.SSHClient, name, version string) error {
+	dep := cfg.Dependencies[name]
+
+	// Environment goes to a root-only env file, not the command line
+	envFiles, err := writeEnvFile(client, docker.DependencyEnvFilePath(cfg.Service, name), dep.Env, false)
+	if err != nil {
+		return fmt.Errorf("dependency %s: %w", name, err)
+	}
+
+	containerCfg := dependencyContainerConfig(cfg, name)
+	containerCfg.EnvFiles = envFiles
+	containerCfg.Labels["podlift.spec"] = DependencySpec(cfg, name)
+	containerCfg.Labels["podlift.created_at"] = time.Now().Format(time.RFC3339)
+	if version != "" {
+		containerCfg.Labels["podlift.version"] = version
+	}
+
+	// Create named volume if needed
+	if dep.Volume != "" {
+		volumeName := strings.Split(dep.Volume, ":")[0]
+		createVolumeCmd := shell.Join("sudo", "docker", "volume", "create", volumeName) + " 2>/dev/null || true"
+		client.Execute(createVolumeCmd)
+	}
+
+	runCmd := docker.GenerateRunCommand(containerCfg)
+	
+	if _, err := client.Execute(runCmd); err != nil {
+		return fmt.Errorf("failed to start dependency %s: %w", name, err)
+	}
+	return nil
+}
+
+// dependencyContainerConfig returns the container of a dependency as configured
+// Labels that change on every start (created_at, version) are added by createDependency.
+func dependencyContainerConfig(cfg *config.Config, name string) docker.ContainerConfig {
+	dep := cfg.Dependencies[name]
+
+	// Reachable by name on the private network; only published on the host when asked for
+	containerCfg := docker.ContainerConfig{
+		Name:     dependencyContainerName(cfg, name),
+		Image:    dep.Image,
+		Network:  docker.NetworkName(cfg.Service),
+		NetworkAliases: []string{name},
+		Command: dep.Command,
+		Labels: map[string]string{
+			"podlift.service":    cfg.Service,
+			"podlift.dependency": name,
+		},
+		Options: dep.Options,
+	}
+	if cfg.Logging != nil {
+		containerCfg.Runtime.LogDriver = cfg.Logging.Driver
+		containerCfg.Runtime.LogOptions = cfg.Logging.DockerOptions()
+	}
+	if env := cfg.Environment(); env != "" {
+		containerCfg.Labels["podlift.environment"] = env
+	}
+
+	if dep.Publish {
+		containerCfg.Port = dep.Port
+		containerCfg.BindAddress = dep.BindAddress
+		if containerCfg.BindAddress == "" {
+			containerCfg.BindAddress = config.DefaultBindAddress
+		}
+	}
+
+	if dep.Volume != "" {
+		containerCfg.Volumes = []string{dep.Volume}
+	}
+
+	// Dependencies come back after a reboot unless options set another policy
+	if _, ok := dep.Options["restart"]; !ok {
+		containerCfg.Restart = config.DefaultRestartPolicy
+	}
+
+	return containerCfg
+}
+
+// DependencySpec returns a hash of the dependency's own config (image, command, ports,
+// volume, env, options and healthcheck), stored in the podlift.spec label
+// App-wide settings like logging or the environment don't count, so changing them doesn't
+// ask to restart every database.
+func DependencySpec(cfg *config.Config, name string) string {
+	dep := cfg.Dependencies[name]
+
+	// encoding/json sorts map keys, so the same config always gives the same hash
+	spec, _ := json.Marshal(struct {
+		Image       string
+		Command     string
+		Port        int
+		Publish     bool
+		BindAddress string
+		Volume      string
+		Env         map[string]string
+		Options     map[string]string
+		Healthcheck *config.DependencyHealthcheck
+	}{dep.Image, dep.Command, dep.Port, dep.Publish, dep.BindAddress, dep.Volume, dep.Env, dep.Options, dep.Healthcheck})
+
+	sum := sha256.Sum256(spec)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// StopDependencies stops and removes the named dependency containers
+// Volumes are kept, so data survives a later start.
+func StopDependencies(cfg *config.Config, client ssh.SSHClient, names []string) error {
+	if len(names) == 0 {
 		return nil
 	}
 
 	fmt.Println(ui.Info("Stopping dependencies..."))
 
-	for name := range cfg.Dependencies {
-		containerName := fmt.Sprintf("%s-%s", cfg.Service, name)
-		
-		stopCmd := fmt.Sprintf("sudo docker stop %s && sudo docker rm %s", containerName, containerName)
+	// Dependents first
+	ordered := inDependencyOrder(cfg, names)
+	for i := len(ordered) - 1; i >= 0; i-- {
+		name := ordered[i]
+		stopCmd := docker.GenerateStopAndRemoveCommand(dependencyContainerName(cfg, name))
 		client.Execute(stopCmd) // Ignore errors
 		
 		fmt.Println(ui.Info(fmt.Sprintf("  %s: stopped", name)))
@@ -114,31 +219,67 @@ func StopDependencies(cfg *config.Config, client ssh.SSHClient) error {
 	return nil
 }
 
-// CheckDependencies checks if dependencies are healthy
-func CheckDependencies(cfg *config.Config, client ssh.SSHClient) (map[string]bool, error) {
-	status := make(map[string]bool)
+// DependencyStatus is the state of a dependency container
+type DependencyStatus struct {
+	Name    string
+	State   string // Docker state (running, exited, ...), or "missing"
+	Health  string // healthy, unhealthy or starting; empty without a healthcheck
+	Image   string
+	Changed bool // The config differs from the one the container was created with
+}
 
-	for name := range cfg.Dependencies {
-		containerName := fmt.Sprintf("%s-%s", cfg.Service, name)
-		
-		checkCmd := fmt.Sprintf(
-			`sudo docker ps --filter "name=%s" --format "{{.Status}}"`,
-			containerName,
-		)
-		
-		output, err := client.Execute(checkCmd)
-		if err != nil || !strings.Contains(output, "Up") {
-			status[name] = false
-		} else {
-			status[name] = true
-		}
+// InspectDependencies returns the status of the named dependencies on the connected server
+func InspectDependencies(cfg *config.Config, client ssh.SSHClient, names []string) []DependencyStatus {
+	statuses := make([]DependencyStatus, 0, len(names))
+	for _, name := range names {
+		statuses = append(statuses, inspectDependency(cfg, client, name))
+	}
+	return statuses
+}
+
+// inspectDependency returns the status of one dependency container
+func inspectDependency(cfg *config.Config, client ssh.SSHClient, name string) DependencyStatus {
+	status := DependencyStatus{Name: name, State: "missing"}
+
+	inspectCmd := shell.Join("sudo", "docker", "inspect", "--format",
+		`{{.State.Status}}\t{{if .State.Health}}{{.State.Health.Status}}{{end}}\t{{.Config.Image}}\t{{index .Config.Labels "podlift.spec"}}`,
+		dependencyContainerName(cfg, name))
+	output, err := client.Execute(inspectCmd)
+	parts := strings.Split(strings.TrimSpace(output), "\t")
+	if err != nil || parts[0] == "" {
+		return status
+	}
+	for len(parts) < 4 {
+		parts = append(parts, "")
+	}
+
+	status.State, status.Health, status.Image = parts[0], parts[1], parts[2]
+	if spec := parts[3]; spec != "" {
+		status.Changed = spec != DependencySpec(cfg, name)
+	} else {
+		// Created before specs were recorded: only the image can be compared
+		status.Changed = status.Image != cfg.Dependencies[name].Image
 	}
+	return status
+}
+
+// dependencyContainerName returns the container name of a dependency, e.g. myapp-postgres
+func dependencyContainerName(cfg *config.Config, name string) string {
+	return fmt.Sprintf("%s-%s", cfg.Service, name)
+}
 
-	return status, nil
+// dependencyState returns the docker state of a container, or "" if it doesn't exist
+func dependencyState(client ssh.SSHClient, containerName string) string {
+	output, err := client.Execute(shell.Join("sudo", "docker", "inspect", "--format", "{{.State.Status}}", containerName))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(output)
 }
 
-// waitForDependencyHealth waits for a dependency to become healthy
-func waitForDependencyHealth(client ssh.SSHClient, containerName, depName string, timeoutSec int) error {
+// waitForContainerHealth waits for docker to report a container healthy
+// Containers without a docker healthcheck count as healthy once running.
+func waitForContainerHealth(client ssh.SSHClient, containerName, depName string, timeoutSec int) error {
 	fmt.Println(ui.Info(fmt.Sprintf("  %s: waiting for health check...", depName)))
 	
 	startTime := time.Now()
@@ -146,10 +287,7 @@ func waitForDependencyHealth(client ssh.SSHClient, containerName, depName string
 
 	for {
 		// Check if container is running
-		checkCmd := fmt.Sprintf(
-			`sudo docker inspect --format='{{.State.Status}}' %s 2>/dev/null`,
-			containerName,
-		)
+		checkCmd := shell.Join("sudo", "docker", "inspect", "--format", "{{.State.Status}}", containerName) + " 2>/dev/null"
 		
 		status, err := client.Execute(checkCmd)
 		if err != nil {
@@ -165,10 +303,7 @@ func waitForDependencyHealth(client ssh.SSHClient, containerName, depName string
 
 		// If running, check health status
 		if status == "running" {
-			healthCmd := fmt.Sprintf(
-				`sudo docker inspect --format='{{if .State.Health}}{{.State.Health.Status}}{{else}}none{{end}}' %s`,
-				containerName,
-			)
+			healthCmd := shell.Join("sudo", "docker", "inspect", "--format", "{{if .State.Health}}{{.State.Health.Status}}{{else}}none{{end}}", containerName)
 			
 			healthStatus, _ := client.Execute(healthCmd)
 			healthStatus = strings.TrimSpace(healthStatus)
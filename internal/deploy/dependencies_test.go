@@ -0,0 +1,526 @@
+package deploy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/docker"
+	"github.com/ekinertac/podlift/internal/ssh"
+)
+
+// dependencyMock is a server where containers exist once docker run created them
+func dependencyMock(runs *[]string) *ssh.MockClient {
+	created := make(map[string]bool)
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		switch {
+		case strings.Contains(cmd, "docker run"):
+			*runs = append(*runs, cmd)
+			fields := strings.Fields(cmd)
+			for i, field := range fields[:len(fields)-1] {
+				if field == "--name" {
+					created[fields[i+1]] = true
+				}
+			}
+		case strings.Contains(cmd, "{{.State.Status}}"):
+			fields := strings.Fields(strings.TrimSuffix(cmd, " 2>/dev/null"))
+			if created[fields[len(fields)-1]] {
+				return "running", nil
+			}
+			return "", errors.New("No such object")
+		case strings.Contains(cmd, "{{.State.Health"):
+			return "none", nil
+		}
+		return "", nil
+	}
+	return client
+}
+
+func TestDeployDependencies_PrivateNetwork(t *testing.T) {
+	cfg := &config.Config{
+		Service: "myapp",
+		Dependencies: map[string]config.Dependency{
+			"postgres": {Image: "postgres:16", Port: 5432},
+			"redis":    {Image: "redis:7", Port: 6379, Publish: true},
+		},
+	}
+	cfg.Servers.Set(map[string][]config.Server{"web": {{Host: "192.168.1.10"}}})
+
+	var runs []string
+	client := dependencyMock(&runs)
+
+	if err := DeployDependencies(cfg, client, config.Server{Host: "192.168.1.10"}, DependencyOptions{Version: "abc123"}); err != nil {
+		t.Fatalf("DeployDependencies() error = %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("expected 2 docker run commands, got %d", len(runs))
+	}
+
+	for _, run := range runs {
+		if !strings.Contains(run, "--network podlift-myapp") {
+			t.Errorf("dependency not on the private network: %s", run)
+		}
+		switch {
+		case strings.Contains(run, "--name myapp-postgres"):
+			if !strings.Contains(run, "--network-alias postgres") {
+				t.Errorf("postgres missing alias: %s", run)
+			}
+			if strings.Contains(run, " -p ") {
+				t.Errorf("postgres port published without publish: true: %s", run)
+			}
+		case strings.Contains(run, "--name myapp-redis"):
+			if !strings.Contains(run, "-p 127.0.0.1:6379:6379") {
+				t.Errorf("redis should be published on 127.0.0.1: %s", run)
+			}
+		}
+	}
+}
+
+func TestDeployDependencies_Placement(t *testing.T) {
+	cfg := &config.Config{
+		Service: "myapp",
+		Dependencies: map[string]config.Dependency{
+			"postgres": {Image: "postgres:16", Role: "db"},
+			"redis":    {Image: "redis:7"},
+		},
+	}
+	cfg.Servers.Set(map[string][]config.Server{
+		"web": {{Host: "192.168.1.10", Labels: []string{"primary"}}, {Host: "192.168.1.11"}},
+		"db":  {{Host: "192.168.1.20"}},
+	})
+
+	tests := []struct {
+		host string
+		want []string
+	}{
+		{"192.168.1.10", []string{"myapp-redis"}},
+		{"192.168.1.11", nil},
+		{"192.168.1.20", []string{"myapp-postgres"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			var runs []string
+			if err := DeployDependencies(cfg, dependencyMock(&runs), config.Server{Host: tt.host}, DependencyOptions{Version: "abc123"}); err != nil {
+				t.Fatalf("DeployDependencies() error = %v", err)
+			}
+			if len(runs) != len(tt.want) {
+				t.Fatalf("started %d dependencies, want %v", len(runs), tt.want)
+			}
+			for i, name := range tt.want {
+				if !strings.Contains(runs[i], "--name "+name+" ") {
+					t.Errorf("run %d = %s, want %s", i, runs[i], name)
+				}
+			}
+		})
+	}
+}
+
+func TestStartDependencies_StartsStoppedContainer(t *testing.T) {
+	cfg := &config.Config{
+		Service:      "myapp",
+		Dependencies: map[string]config.Dependency{"postgres": {Image: "postgres:16"}},
+	}
+
+	var commands []string
+	state := "exited"
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		commands = append(commands, cmd)
+		switch {
+		case strings.Contains(cmd, "{{.State.Status}}"):
+			return state, nil
+		case strings.Contains(cmd, "docker start"):
+			state = "running"
+		}
+		return "", nil
+	}
+
+	if err := StartDependencies(cfg, client, []string{"postgres"}, DependencyOptions{}); err != nil {
+		t.Fatalf("StartDependencies() error = %v", err)
+	}
+
+	joined := strings.Join(commands, "\n")
+	if !strings.Contains(joined, "sudo docker start myapp-postgres") {
+		t.Errorf("stopped dependency should be started, got:\n%s", joined)
+	}
+	if strings.Contains(joined, "docker run") {
+		t.Errorf("stopped dependency should not be recreated, got:\n%s", joined)
+	}
+	if !strings.Contains(joined, "docker exec myapp-postgres sh -c") {
+		t.Errorf("started dependency should be probed, got:\n%s", joined)
+	}
+}
+
+func TestStartDependencies_Unhealthy(t *testing.T) {
+	cfg := &config.Config{
+		Service: "myapp",
+		Dependencies: map[string]config.Dependency{"postgres": {
+			Image:       "postgres:16",
+			Healthcheck: &config.DependencyHealthcheck{Interval: "1ms", Retries: 2},
+		}},
+	}
+
+	var probes int
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		switch {
+		case strings.Contains(cmd, "{{.State.Status}}"):
+			return "running", nil
+		case strings.Contains(cmd, "pg_isready"):
+			probes++
+			return "no response", errors.New("exit status 2")
+		}
+		return "", nil
+	}
+
+	err := StartDependencies(cfg, client, []string{"postgres"}, DependencyOptions{})
+	if err == nil || !strings.Contains(err.Error(), "--ignore-dependency-health") {
+		t.Fatalf("StartDependencies() error = %v, want unhealthy error", err)
+	}
+	if probes != 2 {
+		t.Errorf("probes = %d, want 2 (retries)", probes)
+	}
+
+	if err := StartDependencies(cfg, client, []string{"postgres"}, DependencyOptions{IgnoreHealth: true}); err != nil {
+		t.Errorf("StartDependencies() with IgnoreHealth error = %v", err)
+	}
+}
+
+func TestStartDependencies_ExitedContainerFailsFast(t *testing.T) {
+	cfg := &config.Config{
+		Service:      "myapp",
+		Dependencies: map[string]config.Dependency{"postgres": {Image: "postgres:16"}},
+	}
+
+	var started bool
+	var checks int
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		switch {
+		case strings.Contains(cmd, "docker start"):
+			started = true
+		case strings.Contains(cmd, "{{.State.Status}}"):
+			if started {
+				checks++
+			}
+			return "exited", nil // Crashes right after docker start
+		}
+		return "", nil
+	}
+
+	err := StartDependencies(cfg, client, []string{"postgres"}, DependencyOptions{})
+	if err == nil || !strings.Contains(err.Error(), "container stopped") {
+		t.Fatalf("StartDependencies() error = %v, want container stopped", err)
+	}
+	if checks != 1 {
+		t.Errorf("state checks after start = %d, want 1 (no retries after exit)", checks)
+	}
+}
+
+func TestDependencyProbeFor(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name string
+		dep  config.Dependency
+		want dependencyProbe
+	}{
+		{
+			name: "built in for image",
+			dep:  config.Dependency{Image: "docker.io/library/postgres:16-alpine", Port: 5432},
+			want: dependencyProbe{Command: builtinProbes["postgres"]},
+		},
+		{
+			name: "tcp port for unknown image",
+			dep:  config.Dependency{Image: "minio/minio", Port: 9000},
+			want: dependencyProbe{Port: 9000},
+		},
+		{
+			name: "docker healthcheck without port",
+			dep:  config.Dependency{Image: "minio/minio"},
+			want: dependencyProbe{},
+		},
+		{
+			name: "configured command",
+			dep:  config.Dependency{Image: "postgres:16", Healthcheck: &config.DependencyHealthcheck{Command: "psql -c 'select 1'"}},
+			want: dependencyProbe{Command: "psql -c 'select 1'"},
+		},
+		{
+			name: "configured port",
+			dep:  config.Dependency{Image: "redis:7", Port: 6379, Healthcheck: &config.DependencyHealthcheck{Port: 6380}},
+			want: dependencyProbe{Port: 6380},
+		},
+		{
+			name: "disabled",
+			dep:  config.Dependency{Image: "postgres:16", Healthcheck: &config.DependencyHealthcheck{Enabled: &disabled}},
+			want: dependencyProbe{Disabled: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dependencyProbeFor(tt.dep)
+			if got.Command != tt.want.Command || got.Port != tt.want.Port || got.Disabled != tt.want.Disabled {
+				t.Errorf("dependencyProbeFor() = %+v, want %+v", got, tt.want)
+			}
+			if got.Interval != defaultProbeInterval || got.Timeout != defaultProbeTimeout || got.Retries != defaultProbeRetries {
+				t.Errorf("dependencyProbeFor() timing = %+v, want defaults", got)
+			}
+		})
+	}
+}
+
+func TestDependencyProbe_TCP(t *testing.T) {
+	cfg := &config.Config{Service: "myapp"}
+
+	var connect string
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		switch {
+		case strings.Contains(cmd, "{{.State.Status}}"):
+			return "running", nil
+		case strings.Contains(cmd, "IPAddress"):
+			return "172.18.0.3\n", nil
+		case strings.Contains(cmd, "/dev/tcp"):
+			connect = cmd
+		}
+		return "", nil
+	}
+
+	probe := dependencyProbe{Port: 9000, Timeout: defaultProbeTimeout}
+	if err := probe.check(client, cfg, "myapp-minio"); err != nil {
+		t.Fatalf("check() error = %v", err)
+	}
+	if want := "timeout 5 bash -c ': </dev/tcp/172.18.0.3/9000'"; connect != want {
+		t.Errorf("connect = %q, want %q", connect, want)
+	}
+}
+
+func TestProbeDependency(t *testing.T) {
+	disabled := false
+	cfg := &config.Config{
+		Service: "myapp",
+		Dependencies: map[string]config.Dependency{
+			"postgres": {Image: "postgres:16"},
+			"minio":    {Image: "minio/minio", Port: 9000},
+			"mailhog":  {Image: "mailhog/mailhog", Healthcheck: &config.DependencyHealthcheck{Enabled: &disabled}},
+		},
+	}
+
+	var probes []string
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		switch {
+		case strings.Contains(cmd, "{{.State.Status}}"):
+			return "running", nil
+		case strings.Contains(cmd, "IPAddress"):
+			return "172.18.0.3\n", nil
+		case strings.Contains(cmd, "pg_isready"):
+			probes = append(probes, cmd)
+			return "", nil
+		case strings.Contains(cmd, "/dev/tcp"):
+			probes = append(probes, cmd)
+			return "", errors.New("exit status 1")
+		}
+		return "", nil
+	}
+
+	want := map[string]string{"postgres": "healthy", "minio": "unhealthy", "mailhog": ""}
+	for name, health := range want {
+		if got := ProbeDependency(cfg, client, name); got != health {
+			t.Errorf("ProbeDependency(%s) = %q, want %q", name, got, health)
+		}
+	}
+	if len(probes) != 2 {
+		t.Errorf("probes = %v, want the built-in postgres probe and a TCP connect to minio", probes)
+	}
+}
+
+func TestInspectDependencies(t *testing.T) {
+	cfg := &config.Config{
+		Service: "myapp",
+		Dependencies: map[string]config.Dependency{
+			"postgres": {Image: "postgres:16"},
+			"redis":    {Image: "redis:7"},
+			"mongo":    {Image: "mongo:7"},
+		},
+	}
+
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		switch {
+		case strings.HasSuffix(cmd, "myapp-postgres"):
+			return "running\thealthy\tpostgres:16\t" + DependencySpec(cfg, "postgres") + "\n", nil
+		case strings.HasSuffix(cmd, "myapp-redis"):
+			return "running\t\tredis:6\t\n", nil // Created before spec labels
+		}
+		return "", errors.New("No such object: myapp-mongo")
+	}
+
+	got := InspectDependencies(cfg, client, []string{"postgres", "redis", "mongo"})
+	want := []DependencyStatus{
+		{Name: "postgres", State: "running", Health: "healthy", Image: "postgres:16"},
+		{Name: "redis", State: "running", Image: "redis:6", Changed: true},
+		{Name: "mongo", State: "missing"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("InspectDependencies() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("status %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDependencySpec(t *testing.T) {
+	base := config.Dependency{
+		Image:  "postgres:15",
+		Volume: "pgdata:/var/lib/postgresql/data",
+		Env:    map[string]string{"POSTGRES_PASSWORD": "secret"},
+	}
+	spec := func(dep config.Dependency) string {
+		cfg := &config.Config{Service: "myapp", Dependencies: map[string]config.Dependency{"postgres": dep}}
+		return DependencySpec(cfg, "postgres")
+	}
+
+	if spec(base) != spec(base) {
+		t.Fatal("DependencySpec() should be stable")
+	}
+
+	changes := map[string]func(d *config.Dependency){
+		"image":   func(d *config.Dependency) { d.Image = "postgres:16" },
+		"env":     func(d *config.Dependency) { d.Env = map[string]string{"POSTGRES_PASSWORD": "other"} },
+		"volume":  func(d *config.Dependency) { d.Volume = "pgdata16:/var/lib/postgresql/data" },
+		"publish": func(d *config.Dependency) { d.Port, d.Publish = 5432, true },
+		"command": func(d *config.Dependency) { d.Command = "postgres -c max_connections=200" },
+		"options": func(d *config.Dependency) { d.Options = map[string]string{"shm-size": "1g"} },
+		"healthcheck": func(d *config.Dependency) {
+			d.Healthcheck = &config.DependencyHealthcheck{Command: "pg_isready -U app"}
+		},
+	}
+	for name, change := range changes {
+		dep := base
+		change(&dep)
+		if spec(dep) == spec(base) {
+			t.Errorf("changing %s should change the spec", name)
+		}
+	}
+
+	// App-wide settings aren't part of a dependency's spec
+	cfg := &config.Config{Service: "myapp", Dependencies: map[string]config.Dependency{"postgres": base}}
+	cfg.Logging = &config.LoggingConfig{Driver: "json-file", MaxSize: "50m", MaxFile: 5}
+	if DependencySpec(cfg, "postgres") != spec(base) {
+		t.Error("changing the global logging should not change the spec")
+	}
+}
+
+func TestUpgradeDependency(t *testing.T) {
+	cfg := &config.Config{
+		Service: "myapp",
+		Dependencies: map[string]config.Dependency{"postgres": {
+			Image:       "postgres:16",
+			Volume:      "pgdata:/var/lib/postgresql/data",
+			Healthcheck: &config.DependencyHealthcheck{Interval: "1ms", Retries: 2},
+		}},
+	}
+
+	tests := []struct {
+		name       string
+		healthy    bool
+		wantErr    bool
+		wantLast   string
+		wantAbsent string
+	}{
+		{
+			name:     "healthy new container",
+			healthy:  true,
+			wantLast: "sudo docker rm myapp-postgres-previous",
+		},
+		{
+			name:       "unhealthy new container is rolled back",
+			healthy:    false,
+			wantErr:    true,
+			wantLast:   "sudo docker rename myapp-postgres-previous myapp-postgres && sudo docker update --restart unless-stopped myapp-postgres && sudo docker start myapp-postgres",
+			wantAbsent: "sudo docker rm myapp-postgres-previous",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commands []string
+			client := ssh.NewMockClient()
+			client.ExecuteFunc = func(cmd string) (string, error) {
+				commands = append(commands, cmd)
+				switch {
+				case strings.Contains(cmd, "{{.State.Status}}"):
+					return "running", nil
+				case strings.Contains(cmd, "RestartPolicy"):
+					return "unless-stopped", nil
+				case strings.Contains(cmd, "pg_isready") && !tt.healthy:
+					return "no response", errors.New("exit status 2")
+				}
+				return "", nil
+			}
+
+			err := UpgradeDependency(cfg, client, "postgres")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpgradeDependency() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			joined := strings.Join(commands, "\n")
+			for _, want := range []string{
+				"sudo docker pull postgres:16",
+				"sudo docker stop myapp-postgres && sudo docker update --restart no myapp-postgres && sudo docker rename myapp-postgres myapp-postgres-previous",
+				"-v pgdata:/var/lib/postgresql/data",
+			} {
+				if !strings.Contains(joined, want) {
+					t.Errorf("missing %q in:\n%s", want, joined)
+				}
+			}
+			if last := commands[len(commands)-1]; last != tt.wantLast {
+				t.Errorf("last command = %q, want %q", last, tt.wantLast)
+			}
+			if tt.wantAbsent != "" && strings.Contains(joined, tt.wantAbsent) {
+				t.Errorf("unexpected %q in:\n%s", tt.wantAbsent, joined)
+			}
+		})
+	}
+}
+
+func TestIsMajorUpgrade(t *testing.T) {
+	tests := []struct {
+		old, new string
+		want     bool
+	}{
+		{"postgres:15", "postgres:16", true},
+		{"postgres:15.4-alpine", "postgres:16.1-alpine", true},
+		{"postgres:16", "postgres:16.2-alpine", false},
+		{"redis:7-alpine", "redis:7.2", false},
+		{"registry.example.com:5000/pg:15", "registry.example.com:5000/pg:16", true},
+		{"mysql:8", "mariadb:11", false},
+		{"postgres:latest", "postgres:16", false},
+		{"postgres:15@sha256:abc", "postgres:16", true},
+	}
+
+	for _, tt := range tests {
+		if got := IsMajorUpgrade(tt.old, tt.new); got != tt.want {
+			t.Errorf("IsMajorUpgrade(%q, %q) = %v, want %v", tt.old, tt.new, got, tt.want)
+		}
+	}
+}
+
+func TestDependencyContainerConfig_Logging(t *testing.T) {
+	cfg := &config.Config{
+		Service:      "myapp",
+		Logging:      &config.LoggingConfig{Driver: "local", MaxSize: "20m", MaxFile: 5},
+		Dependencies: map[string]config.Dependency{"postgres": {Image: "postgres:16"}},
+	}
+
+	runCmd := docker.GenerateRunCommand(dependencyContainerConfig(cfg, "postgres"))
+	if !strings.Contains(runCmd, "--log-driver local --log-opt max-file=5 --log-opt max-size=20m") {
+		t.Errorf("dependency should get the global logging, got %q", runCmd)
+	}
+}
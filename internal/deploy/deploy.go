@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/ekinertac/podlift/internal/config"
@@ -22,12 +23,45 @@ type DeployOptions struct {
 	Parallel     bool
 	DryRun       bool
 	ZeroDowntime bool
+	ImageTag     string // Deploy a prebuilt image tag instead of building
+
+	IgnoreDependencyHealth bool // Deploy even if a dependency fails its health probe
+}
+
+// release describes the image being rolled out
+type release struct {
+	Version  string // Used in container names and labels
+	Image    string // Image reference containers are started from
+	TarPath  string // Local tar for SCP transfer (empty when pulling)
+	Prebuilt bool   // Image is pulled by reference instead of built
+	ImageID  string // Content digest every server must match before starting containers
+}
+
+// UsesPrebuiltImage returns true if the deployment pulls an existing image
+// instead of building one (image pinned to a digest, or --image-tag given)
+// A tag alone in image: is rejected by validation, so myapp:latest never
+// silently stops building.
+func UsesPrebuiltImage(cfg *config.Config, imageTag string) bool {
+	return imageTag != "" || docker.ParseImageReference(cfg.Image).Digest != ""
+}
+
+// prebuiltReference returns the image reference to pull for a prebuilt deployment
+func prebuiltReference(cfg *config.Config, imageTag string) docker.ImageReference {
+	ref := docker.ParseImageReference(cfg.Image)
+	if imageTag != "" {
+		ref = ref.WithTag(imageTag)
+	}
+	return ref
 }
 
 // Deploy executes a deployment
 func Deploy(opts DeployOptions) error {
 	cfg := opts.Config
 
+	if UsesPrebuiltImage(cfg, opts.ImageTag) {
+		return deployPrebuilt(opts)
+	}
+
 	// Get version from git
 	version, err := git.GetVersion()
 	if err != nil {
@@ -64,8 +98,21 @@ func Deploy(opts DeployOptions) error {
 		}
 	}
 
+	// Record the image digest so every server can be verified against it
+	var imageID string
+	if !opts.DryRun {
+		imageID, err = docker.GetImageID(fmt.Sprintf("%s:%s", cfg.Image, version))
+		if err != nil {
+			steps.Fail(0, err.Error())
+			return err
+		}
+	}
+
 	steps.Complete(0, "Built successfully")
 	fmt.Println(ui.Success("Build complete"))
+	if imageID != "" {
+		fmt.Println(ui.Info(fmt.Sprintf("  Digest: %s", imageID)))
+	}
 	fmt.Println()
 
 	// Step 2: Push to registry or save to tar
@@ -119,8 +166,82 @@ func Deploy(opts DeployOptions) error {
 		fmt.Println()
 	}
 
-	// Deploy to each server
+	rel := &release{
+		Version: version,
+		Image:   fmt.Sprintf("%s:%s", cfg.Image, version),
+		TarPath: tarPath,
+		ImageID: imageID,
+	}
+
+	// Servers pull the registry path, so containers must run it too
+	if useRegistry {
+		rel.Image = registry.NewClient(cfg.Registry).GetImagePath(cfg.Image, version)
+	}
+
+	if err := deployServers(opts, rel); err != nil {
+		return err
+	}
+
+	fmt.Println(ui.Title("Deployment successful!"))
+	fmt.Println()
+	fmt.Println(ui.Info(fmt.Sprintf("Deployed: %s", version)))
+	if rel.ImageID != "" {
+		fmt.Println(ui.Info(fmt.Sprintf("Digest: %s", rel.ImageID)))
+	}
+	
+	commitMsg, _ := git.GetCommitMessage()
+	if commitMsg != "" {
+		fmt.Println(ui.Info(fmt.Sprintf("Message: %s", commitMsg)))
+	}
+
+	return nil
+}
+
+// deployPrebuilt deploys an image that was built elsewhere (e.g. CI)
+// Skips the build and git version; the resolved digest becomes the release version
+func deployPrebuilt(opts DeployOptions) error {
+	cfg := opts.Config
+	ref := prebuiltReference(cfg, opts.ImageTag)
+
+	fmt.Println(ui.Title(fmt.Sprintf("Deploying %s", ref.String())))
+	fmt.Println()
+	fmt.Println(ui.Info("Using prebuilt image (skipping build)"))
+	fmt.Println()
+
+	rel := &release{
+		Image:    ref.String(),
+		Prebuilt: true,
+	}
+
+	// Image already pinned to a digest: no need to resolve it on the server
+	if ref.Digest != "" {
+		rel.Version = docker.ShortDigest(ref.Digest)
+	} else if opts.DryRun {
+		rel.Version = ref.Tag
+	}
+
+	if err := deployServers(opts, rel); err != nil {
+		return err
+	}
+
+	fmt.Println(ui.Title("Deployment successful!"))
+	fmt.Println()
+	fmt.Println(ui.Info(fmt.Sprintf("Deployed: %s", rel.Version)))
+	fmt.Println(ui.Info(fmt.Sprintf("Image: %s", rel.Image)))
+	if rel.ImageID != "" {
+		fmt.Println(ui.Info(fmt.Sprintf("Digest: %s", rel.ImageID)))
+	}
+
+	return nil
+}
+
+// deployServers rolls a release out to every configured server
+func deployServers(opts DeployOptions, rel *release) error {
+	cfg := opts.Config
 	allServers := cfg.GetAllServers()
+
+	// A host listed under several roles gets its dependencies once
+	dependenciesDeployed := make(map[string]bool)
 	
 	for i, serverWithRole := range allServers {
 		fmt.Printf("Server %d/%d: %s\n", i+1, len(allServers), serverWithRole.Host)
@@ -144,13 +265,33 @@ func Deploy(opts DeployOptions) error {
 		}
 
 		// Transfer image
-		if err := transferImage(sshClient, serverWithRole.Host, cfg, version, tarPath, opts); err != nil {
-			return err
+		if rel.Prebuilt {
+			if err := pullPrebuiltImage(sshClient, cfg, rel, opts); err != nil {
+				return err
+			}
+		} else {
+			if err := transferImage(sshClient, serverWithRole.Host, cfg, rel.Version, rel.TarPath, opts); err != nil {
+				return err
+			}
 		}
 
-		// Deploy dependencies first (postgres, redis, etc.)
+		// Verify the server has exactly the image we built/resolved
 		if !opts.DryRun {
-			if err := DeployDependencies(cfg, sshClient, version); err != nil {
+			if err := verifyImage(sshClient, rel); err != nil {
+				return fmt.Errorf("image verification failed on %s: %w", serverWithRole.Host, err)
+			}
+		}
+
+		// Deploy dependencies first (postgres, redis, etc.)
+		if !opts.DryRun && !dependenciesDeployed[serverWithRole.Host] {
+			dependenciesDeployed[serverWithRole.Host] = true
+			if err := EnsureNetwork(sshClient, cfg); err != nil {
+				return err
+			}
+			if err := DeployDependencies(cfg, sshClient, serverWithRole.Server, DependencyOptions{
+				Version:      rel.Version,
+				IgnoreHealth: opts.IgnoreDependencyHealth,
+			}); err != nil {
 				return fmt.Errorf("dependency deployment failed: %w", err)
 			}
 		}
@@ -160,46 +301,50 @@ func Deploy(opts DeployOptions) error {
 			// Zero-downtime deployment with nginx
 			zdOpts := ZeroDowntimeDeployOptions{
 				Config:    cfg,
-				Version:   version,
-				ImagePath: tarPath,
+				Version:   rel.Version,
+				Image:     rel.Image,
+				ImageID:   rel.ImageID,
+				ImagePath: rel.TarPath,
 				SSHClient: sshClient,
 				Server:    serverWithRole.Server,
+				DryRun:    opts.DryRun,
 			}
 			if err := ZeroDowntimeDeploy(zdOpts); err != nil {
 				return fmt.Errorf("deployment failed on %s: %w", serverWithRole.Host, err)
 			}
 		} else {
 			// Basic deployment (current method)
-			if err := deployToServer(serverWithRole.Server, cfg, version, tarPath, opts); err != nil {
+			if err := deployToServer(serverWithRole.Server, cfg, rel, opts); err != nil {
 				return fmt.Errorf("deployment failed on %s: %w", serverWithRole.Host, err)
 			}
 		}
 
+		// Scheduled jobs run the new release from now on
+		if !opts.DryRun {
+			current := RunningRelease{Version: rel.Version, Image: rel.Image, ImageID: rel.ImageID}
+			if err := InstallJobs(cfg, sshClient, serverWithRole.Server, current); err != nil {
+				return fmt.Errorf("failed to install jobs on %s: %w", serverWithRole.Host, err)
+			}
+		}
+
 		fmt.Println(ui.Success(fmt.Sprintf("Deployed to %s", serverWithRole.Host)))
 		fmt.Println()
 	}
 
 	// Setup load balancer if multiple servers
 	if len(allServers) > 1 && !opts.DryRun {
-		if err := SetupLoadBalancer(cfg, version); err != nil {
+		if err := SetupLoadBalancer(cfg, rel.Version); err != nil {
 			return fmt.Errorf("load balancer setup failed: %w", err)
 		}
 	}
 
-	fmt.Println(ui.Title("Deployment successful!"))
-	fmt.Println()
-	fmt.Println(ui.Info(fmt.Sprintf("Deployed: %s", version)))
-	
-	commitMsg, _ := git.GetCommitMessage()
-	if commitMsg != "" {
-		fmt.Println(ui.Info(fmt.Sprintf("Message: %s", commitMsg)))
-	}
-
 	return nil
 }
 
 // deployToServer deploys to a single server
-func deployToServer(server config.Server, cfg *config.Config, version, tarPath string, opts DeployOptions) error {
+func deployToServer(server config.Server, cfg *config.Config, rel *release, opts DeployOptions) error {
+	version := rel.Version
+
 	// Create SSH client
 	sshClient, err := ssh.NewClient(ssh.Config{
 		Host:    server.Host,
@@ -218,68 +363,94 @@ func deployToServer(server config.Server, cfg *config.Config, version, tarPath s
 		return fmt.Errorf("SSH connection failed: %w", err)
 	}
 
-	// Step 3: Transfer tar to server
-	fmt.Println(ui.Info("Transferring image..."))
-	remoteTarPath := fmt.Sprintf("/tmp/%s-%s.tar", cfg.Image, version)
+	// Image was already transferred (or pulled) by deployServers
 
-	if !opts.DryRun {
-		// Transfer with progress
-		size, _ := docker.GetImageSize(tarPath)
-		fmt.Println(ui.Info(fmt.Sprintf("  Uploading %.1fMB...", size)))
-		
-		err := sshClient.CopyFileWithProgress(tarPath, remoteTarPath, func(sent, total int64) {
-			// Progress callback - could show progress bar here
-			percent := float64(sent) / float64(total) * 100
-			if int(percent)%10 == 0 { // Show every 10%
-				fmt.Printf("\r  %.0f%% uploaded...", percent)
-			}
-		})
-		if err != nil {
-			return fmt.Errorf("failed to transfer image: %w", err)
+	tempPorts := serviceTempPorts(cfg, 8000)
+
+	// Health check of one service, also used for depends_on condition: healthy
+	checkHealth := func(serviceName string) error {
+		service := cfg.Services[serviceName]
+		if service.Healthcheck == nil || service.Healthcheck.Enabled != nil && !*service.Healthcheck.Enabled {
+			fmt.Println(ui.Info(fmt.Sprintf("  %s: health check disabled", serviceName)))
+			return nil
 		}
-		fmt.Println() // New line after progress
-	}
 
-	fmt.Println(ui.Success("Image transferred"))
+		// Wait a moment for container to start
+		time.Sleep(3 * time.Second)
 
-	// Step 4: Load image on server
-	fmt.Println(ui.Info("Loading image on server..."))
-	
-	if !opts.DryRun {
-		loadCmd := docker.GenerateLoadCommand(remoteTarPath)
-		if _, err := sshClient.Execute(loadCmd); err != nil {
-			return fmt.Errorf("failed to load image: %w", err)
+		// Perform health check
+		healthPath := service.Healthcheck.Path
+		if healthPath == "" {
+			healthPath = "/health"
+		}
+
+		expectedCodes := service.Healthcheck.Expect
+		if len(expectedCodes) == 0 {
+			expectedCodes = []int{200}
+		}
+
+		// Check first replica
+		port := tempPorts[serviceName]
+		
+		healthCfg := docker.HealthCheckConfig{
+			URL:      fmt.Sprintf("http://%s:%d%s", server.Host, port, healthPath),
+			Expect:   expectedCodes,
+			Timeout:  5 * time.Second,
+			Interval: 2 * time.Second,
+			Retries:  10,
 		}
 
-		// Remove tar file
-		sshClient.Execute(fmt.Sprintf("rm %s", remoteTarPath))
+		var err error
+		if service.Healthcheck.Path == "" && service.Healthcheck.Command != "" {
+			// Only a docker healthcheck is configured: wait for docker's verdict
+			err = waitForContainerHealth(sshClient, fmt.Sprintf("%s-%s-%s-1", cfg.Service, serviceName, version), serviceName, 60)
+		} else {
+			err = docker.CheckHealth(healthCfg)
+		}
+		if err != nil {
+			return fmt.Errorf("health check failed for %s: %w", serviceName, err)
+		}
+
+		fmt.Println(ui.Success(fmt.Sprintf("  %s: healthy", serviceName)))
+		return nil
 	}
 
-	fmt.Println(ui.Success("Image loaded"))
+	waitHealth := checkHealth
+	if opts.SkipHealth {
+		waitHealth = nil // healthy conditions only wait for the start
+	}
+	waiter := newDependsOnWaiter(sshClient, cfg, version, waitHealth)
 
-	// Step 5: Start containers
+	// Step 3: Start containers, each service after those it depends on
 	fmt.Println(ui.Info("Starting containers..."))
 
-	for serviceName, service := range cfg.Services {
+	for _, serviceName := range cfg.ServiceOrder() {
+		service := cfg.Services[serviceName]
+		if !opts.DryRun {
+			if err := waiter.wait(serviceName); err != nil {
+				return err
+			}
+		}
+
+		env, err := serviceEnv(cfg, server, service)
+		if err != nil {
+			return err
+		}
+
+		// Environment goes to a root-only env file, not the command line
+		envFiles, err := writeEnvFile(sshClient, docker.EnvFilePath(cfg.Service, serviceName, version), env, opts.DryRun)
+		if err != nil {
+			return err
+		}
+
 		for replica := 1; replica <= service.Replicas; replica++ {
 			containerName := fmt.Sprintf("%s-%s-%s-%d", cfg.Service, serviceName, version, replica)
 			
 			// Generate docker run command
-			containerCfg := docker.ContainerConfig{
-				Name:         containerName,
-				Image:        fmt.Sprintf("%s:%s", cfg.Image, version),
-				Port:         8000 + replica - 1, // Temp ports
-				InternalPort: service.Port,
-				Env:          service.Env,
-				Labels: map[string]string{
-					"podlift.service":  cfg.Service,
-					"podlift.version":  version,
-					"podlift.deployed_at": time.Now().Format(time.RFC3339),
-					"podlift.container_type": serviceName,
-				},
-				Command: service.Command,
-				Volumes: service.Volumes,
-			}
+			containerCfg := serviceContainerConfig(cfg, serviceName, version, rel.Image, rel.ImageID, envFiles)
+			containerCfg.Name = containerName
+			containerCfg.Labels["podlift.replica"] = strconv.Itoa(replica)
+			containerCfg.Port = tempPorts[serviceName] + replica - 1
 
 			runCmd := docker.GenerateRunCommand(containerCfg)
 
@@ -294,50 +465,118 @@ func deployToServer(server config.Server, cfg *config.Config, version, tarPath s
 		}
 	}
 
-	// Step 6: Health check
+	// Step 4: Health check
 	if !opts.SkipHealth && !opts.DryRun {
 		fmt.Println(ui.Info("Waiting for health check..."))
 		
 		// Check each service with health checks
-		for serviceName, service := range cfg.Services {
-			if service.Healthcheck == nil || service.Healthcheck.Enabled != nil && !*service.Healthcheck.Enabled {
-				fmt.Println(ui.Info(fmt.Sprintf("  %s: health check disabled", serviceName)))
-				continue
+		for _, serviceName := range cfg.ServiceOrder() {
+			if waiter.checked(serviceName) || cfg.IsJob(serviceName) {
+				continue // Checked for a depends_on condition, or a one-off job that already exited
+			}
+			if err := checkHealth(serviceName); err != nil {
+				return err
 			}
+		}
+	}
 
-			// Wait a moment for container to start
-			time.Sleep(3 * time.Second)
+	if !opts.DryRun {
+		cleanupEnvFiles(sshClient, cfg)
+	}
 
-			// Perform health check
-			healthPath := service.Healthcheck.Path
-			if healthPath == "" {
-				healthPath = "/health"
-			}
+	return nil
+}
 
-			expectedCodes := service.Healthcheck.Expect
-			if len(expectedCodes) == 0 {
-				expectedCodes = []int{200}
-			}
+// serviceTempPorts returns the first temp port of each service's replicas
+// Each service gets its own range starting at start, one port per replica.
+func serviceTempPorts(cfg *config.Config, start int) map[string]int {
+	ports := make(map[string]int, len(cfg.Services))
+	next := start
+	for _, serviceName := range cfg.ServiceOrder() {
+		ports[serviceName] = next
+		next += cfg.Services[serviceName].Replicas
+	}
+	return ports
+}
 
-			// Check first replica
-			port := 8000 // Temp port for first replica
-			
-			healthCfg := docker.HealthCheckConfig{
-				URL:      fmt.Sprintf("http://%s:%d%s", server.Host, port, healthPath),
-				Expect:   expectedCodes,
-				Timeout:  5 * time.Second,
-				Interval: 2 * time.Second,
-				Retries:  10,
-			}
+// serviceEnv returns a service's environment on a server, including dependency exports
+// Variables set on the service override exported ones.
+func serviceEnv(cfg *config.Config, server config.Server, service config.Service) (map[string]string, error) {
+	exported, err := cfg.ExportedEnv(server)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render dependency exports: %w", err)
+	}
 
-			if err := docker.CheckHealth(healthCfg); err != nil {
-				return fmt.Errorf("health check failed for %s: %w", serviceName, err)
-			}
+	env := make(map[string]string, len(exported)+len(service.Env))
+	for key, value := range exported {
+		env[key] = value
+	}
+	for key, value := range service.Env {
+		env[key] = value
+	}
+	return env, nil
+}
 
-			fmt.Println(ui.Success(fmt.Sprintf("  %s: healthy", serviceName)))
+// serviceContainerConfig returns the container config of a service in a release
+// Callers set the container name and published port.
+func serviceContainerConfig(cfg *config.Config, serviceName, version, image, imageID string, envFiles []string) docker.ContainerConfig {
+	service := cfg.Services[serviceName]
+	service.Logging = cfg.ServiceLogging(serviceName)
+	containerCfg := docker.ContainerConfig{
+		Image:        image,
+		InternalPort: service.Port,
+		EnvFiles:     envFiles,
+		Labels: map[string]string{
+			"podlift.service":        cfg.Service,
+			"podlift.version":        version,
+			"podlift.deployed_at":    time.Now().Format(time.RFC3339),
+			"podlift.container_type": serviceName,
+			"podlift.image":          image,
+			"podlift.image_id":       imageID,
+		},
+		Command:        service.Command,
+		Restart:        service.Restart,
+		Volumes:        service.Volumes,
+		Runtime:        serviceRuntime(service),
+		Network:        docker.NetworkName(cfg.Service),
+		NetworkAliases: []string{serviceName},
+		Options:        service.Options,
+	}
+	if env := cfg.Environment(); env != "" {
+		containerCfg.Labels["podlift.environment"] = env
+	}
+	return containerCfg
+}
+
+// serviceRuntime converts a service's resource limits and runtime options for docker run
+func serviceRuntime(service config.Service) docker.RuntimeOptions {
+	runtime := docker.RuntimeOptions{
+		Memory:      service.Memory,
+		CPUs:        service.CPUs,
+		PidsLimit:   service.PidsLimit,
+		Ulimits:     service.Ulimits,
+		User:        service.User,
+		ReadOnly:    service.ReadOnly,
+		CapAdd:      service.CapAdd,
+		CapDrop:     service.CapDrop,
+		StopSignal:  service.StopSignal,
+		StopTimeout: service.StopTimeout(),
+	}
+
+	if service.Logging != nil {
+		runtime.LogDriver = service.Logging.Driver
+		runtime.LogOptions = service.Logging.DockerOptions()
+	}
+
+	if hc := service.Healthcheck; hc != nil && hc.Command != "" && (hc.Enabled == nil || *hc.Enabled) {
+		runtime.Healthcheck = &docker.Healthcheck{
+			Command:     hc.Command,
+			Interval:    hc.Interval,
+			Timeout:     hc.Timeout,
+			StartPeriod: hc.StartPeriod,
+			Retries:     hc.Retries,
 		}
 	}
 
-	return nil
+	return runtime
 }
-
@@ -1,9 +1,11 @@
 package deploy
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/ssh"
 )
 
 // Test deployToServer with mock SSH client
@@ -50,3 +52,97 @@ func TestDeploy_ValidatesGitState(t *testing.T) {
 	t.Skip("Requires git repository - tested in E2E")
 }
 
+
+func TestUsesPrebuiltImage(t *testing.T) {
+	tests := []struct {
+		image    string
+		imageTag string
+		want     bool
+	}{
+		{"myapp", "", false},
+		{"myapp", "v1.4.2", true},
+		{"ghcr.io/org/app:v1", "", false}, // Rejected by validation, never pulled as is
+		{"ghcr.io/org/app@sha256:abc", "", true},
+		{"ghcr.io/org/app:v1@sha256:abc", "", true},
+		{"localhost:5000/app", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image+"/"+tt.imageTag, func(t *testing.T) {
+			cfg := &config.Config{Image: tt.image}
+			if got := UsesPrebuiltImage(cfg, tt.imageTag); got != tt.want {
+				t.Errorf("UsesPrebuiltImage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPullPrebuiltImage_ResolvesDigest(t *testing.T) {
+	var commands []string
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		commands = append(commands, cmd)
+		if strings.Contains(cmd, "RepoDigests") {
+			return "ghcr.io/org/app@sha256:0123456789abcdef0123\n", nil
+		}
+		return "", nil
+	}
+
+	cfg := &config.Config{Image: "ghcr.io/org/app"}
+	rel := &release{Image: "ghcr.io/org/app:v1.4.2", Prebuilt: true}
+
+	if err := pullPrebuiltImage(client, cfg, rel, DeployOptions{Config: cfg}); err != nil {
+		t.Fatalf("pullPrebuiltImage() error = %v", err)
+	}
+
+	if rel.Version != "0123456789ab" {
+		t.Errorf("Version = %q, want 0123456789ab", rel.Version)
+	}
+	if rel.Image != "ghcr.io/org/app:v1.4.2@sha256:0123456789abcdef0123" {
+		t.Errorf("Image = %q, want digest-pinned reference", rel.Image)
+	}
+	if len(commands) == 0 || commands[0] != "sudo docker pull ghcr.io/org/app:v1.4.2" {
+		t.Errorf("first command = %v, want docker pull", commands)
+	}
+
+	// Second server pulls the pinned reference and doesn't re-resolve
+	commands = nil
+	if err := pullPrebuiltImage(client, cfg, rel, DeployOptions{Config: cfg}); err != nil {
+		t.Fatalf("pullPrebuiltImage() error = %v", err)
+	}
+	if len(commands) != 1 || !strings.Contains(commands[0], "@sha256:") {
+		t.Errorf("second server commands = %v, want single pinned pull", commands)
+	}
+}
+
+func TestVerifyImage(t *testing.T) {
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		if strings.Contains(cmd, "{{.Id}}") {
+			return "sha256:aaaa\n", nil
+		}
+		return "", nil
+	}
+
+	// Matching digest
+	rel := &release{Image: "myapp:v1", ImageID: "sha256:aaaa"}
+	if err := verifyImage(client, rel); err != nil {
+		t.Errorf("verifyImage() error = %v, want nil", err)
+	}
+
+	// Mismatch (e.g. re-pushed tag)
+	rel = &release{Image: "myapp:v1", ImageID: "sha256:bbbb"}
+	err := verifyImage(client, rel)
+	if err == nil || !strings.Contains(err.Error(), "mismatch") {
+		t.Errorf("verifyImage() error = %v, want digest mismatch", err)
+	}
+
+	// No recorded digest: first server defines it
+	rel = &release{Image: "ghcr.io/org/app:v1", Prebuilt: true}
+	if err := verifyImage(client, rel); err != nil {
+		t.Errorf("verifyImage() error = %v, want nil", err)
+	}
+	if rel.ImageID != "sha256:aaaa" {
+		t.Errorf("ImageID = %q, want sha256:aaaa", rel.ImageID)
+	}
+}
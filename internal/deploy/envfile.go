@@ -0,0 +1,100 @@
+package deploy
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/docker"
+	"github.com/ekinertac/podlift/internal/shell"
+	"github.com/ekinertac/podlift/internal/ssh"
+	"github.com/ekinertac/podlift/internal/ui"
+)
+
+// writeEnvFile writes env vars to a mode 600 env file on the server
+// Returns nil (no file) when there are no variables. Values are never printed.
+func writeEnvFile(client ssh.SSHClient, envFile string, env map[string]string, dryRun bool) ([]string, error) {
+	if len(env) == 0 {
+		return nil, nil
+	}
+
+	content, err := docker.FormatEnvFile(env)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		fmt.Println(ui.Code(fmt.Sprintf("  write %s (%d variables, mode 600)", envFile, len(env))))
+		return []string{envFile}, nil
+	}
+
+	if _, err := client.Execute(docker.GenerateEnvFileCreateCommand(envFile)); err != nil {
+		return nil, fmt.Errorf("failed to create env file %s: %w", envFile, err)
+	}
+	if err := client.WriteFile(content, envFile); err != nil {
+		return nil, fmt.Errorf("failed to write env file %s: %w", envFile, err)
+	}
+
+	return []string{envFile}, nil
+}
+
+// cleanupEnvFiles removes release env files that no container uses anymore
+// Env files are only read when a container is created, so files of removed releases are safe to delete.
+func cleanupEnvFiles(client ssh.SSHClient, cfg *config.Config) {
+	versionsCmd := shell.Join("sudo", "docker", "ps", "-a", "--filter", "label=podlift.service="+cfg.Service, "--format", `{{.Label "podlift.version"}}`)
+	output, err := client.Execute(versionsCmd)
+	if err != nil {
+		return // Keep files if we can't tell which releases exist
+	}
+
+	keep := make(map[string]bool)
+	for _, version := range strings.Split(output, "\n") {
+		if version = strings.TrimSpace(version); version != "" {
+			keep[version] = true
+		}
+	}
+
+	listCmd := shell.Join("sudo", "ls", "-1", docker.EnvFileDir(cfg.Service)) + " 2>/dev/null || true"
+	files, err := client.Execute(listCmd)
+	if err != nil {
+		return
+	}
+
+	var stale []string
+	for _, file := range strings.Split(files, "\n") {
+		file = strings.TrimSpace(file)
+		if version, ok := envFileVersion(cfg, file); ok && !keep[version] {
+			stale = append(stale, path.Join(docker.EnvFileDir(cfg.Service), file))
+		}
+	}
+
+	if len(stale) == 0 {
+		return
+	}
+
+	if _, err := client.Execute(shell.Command("sudo", "rm", "-f").Arg(stale...).String()); err != nil {
+		fmt.Println(ui.Warning(fmt.Sprintf("Failed to remove old env files: %v", err)))
+		return
+	}
+	fmt.Println(ui.Info(fmt.Sprintf("Removed %d old env file(s)", len(stale))))
+}
+
+// envFileVersion extracts the release version from a "<service>-<version>.env" file name
+func envFileVersion(cfg *config.Config, file string) (string, bool) {
+	if !strings.HasSuffix(file, ".env") {
+		return "", false
+	}
+	name := strings.TrimSuffix(file, ".env")
+
+	// Longest service name wins, so "web-admin-abc" isn't read as service "web", version "admin-abc"
+	var version string
+	matched := ""
+	for serviceName := range cfg.Services {
+		if strings.HasPrefix(name, serviceName+"-") && len(serviceName) > len(matched) {
+			matched = serviceName
+			version = strings.TrimPrefix(name, serviceName+"-")
+		}
+	}
+	return version, matched != "" && version != ""
+}
@@ -0,0 +1,83 @@
+package deploy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/ssh"
+)
+
+func TestWriteEnvFile(t *testing.T) {
+	var commands []string
+	written := make(map[string]string)
+
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		commands = append(commands, cmd)
+		return "", nil
+	}
+	client.WriteFileFunc = func(content, remotePath string) error {
+		written[remotePath] = content
+		return nil
+	}
+
+	path := "/etc/podlift/myapp/env/web-abc123.env"
+	files, err := writeEnvFile(client, path, map[string]string{"SECRET_KEY": "s3cr$t"}, false)
+	if err != nil {
+		t.Fatalf("writeEnvFile() error = %v", err)
+	}
+	if len(files) != 1 || files[0] != path {
+		t.Errorf("writeEnvFile() = %v, want [%s]", files, path)
+	}
+
+	// File is created with mode 600 before the content is written
+	if len(commands) != 1 || !strings.Contains(commands[0], "install -m 600 /dev/null "+path) {
+		t.Errorf("commands = %v, want install -m 600", commands)
+	}
+	if written[path] != "SECRET_KEY=s3cr$t\n" {
+		t.Errorf("written = %q", written[path])
+	}
+	for _, cmd := range commands {
+		if strings.Contains(cmd, "s3cr$t") {
+			t.Errorf("secret value leaked into command: %s", cmd)
+		}
+	}
+
+	// No env, no file
+	files, err = writeEnvFile(client, path, nil, false)
+	if err != nil || files != nil {
+		t.Errorf("writeEnvFile() with empty env = %v, %v, want no file", files, err)
+	}
+}
+
+func TestCleanupEnvFiles(t *testing.T) {
+	cfg := &config.Config{
+		Service: "myapp",
+		Services: map[string]config.Service{
+			"web":       {},
+			"web-admin": {},
+		},
+	}
+
+	var removed string
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		switch {
+		case strings.Contains(cmd, "docker ps -a"):
+			return "def456\ndef456\nabc123\n", nil
+		case strings.Contains(cmd, "ls -1"):
+			return "web-abc123.env\nweb-def456.env\nweb-old999.env\nweb-admin-old999.env\nweb-admin-def456.env\ndependencies\n", nil
+		case strings.HasPrefix(cmd, "sudo rm -f"):
+			removed = cmd
+		}
+		return "", nil
+	}
+
+	cleanupEnvFiles(client, cfg)
+
+	want := "sudo rm -f /etc/podlift/myapp/env/web-old999.env /etc/podlift/myapp/env/web-admin-old999.env"
+	if removed != want {
+		t.Errorf("cleanup command = %q, want %q", removed, want)
+	}
+}
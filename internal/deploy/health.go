@@ -0,0 +1,184 @@
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/docker"
+	"github.com/ekinertac/podlift/internal/shell"
+	"github.com/ekinertac/podlift/internal/ssh"
+	"github.com/ekinertac/podlift/internal/ui"
+)
+
+// Dependency probe defaults
+const (
+	defaultProbeInterval = 2 * time.Second
+	defaultProbeTimeout  = 5 * time.Second
+	defaultProbeRetries  = 30
+)
+
+// builtinProbes are health commands for well-known images, keyed by image name
+// They connect over TCP on purpose: database images run their init scripts on a
+// socket-only server first, which must not count as ready.
+var builtinProbes = map[string]string{
+	"postgres":           `pg_isready -q -h 127.0.0.1 -U "${POSTGRES_USER:-postgres}"`,
+	"postgis":            `pg_isready -q -h 127.0.0.1 -U "${POSTGRES_USER:-postgres}"`,
+	"pgvector":           `pg_isready -q -h 127.0.0.1 -U "${POSTGRES_USER:-postgres}"`,
+	"timescaledb":        `pg_isready -q -h 127.0.0.1 -U "${POSTGRES_USER:-postgres}"`,
+	"mysql":              `"$(command -v mariadb-admin mysqladmin | head -n 1)" ping -h 127.0.0.1 --silent`,
+	"mariadb":            `"$(command -v mariadb-admin mysqladmin | head -n 1)" ping -h 127.0.0.1 --silent`,
+	"percona-server":     `mysqladmin ping -h 127.0.0.1 --silent`,
+	"redis":              `redis-cli -h 127.0.0.1 ping | grep -q PONG`,
+	"redis-stack-server": `redis-cli -h 127.0.0.1 ping | grep -q PONG`,
+	"valkey":             `valkey-cli -h 127.0.0.1 ping | grep -q PONG`,
+	"mongo":              `mongosh --quiet --eval 'db.adminCommand("ping").ok' | grep -q 1`,
+	"rabbitmq":           `rabbitmq-diagnostics -q ping`,
+}
+
+// errContainerStopped means the container exited, so probing again won't help
+var errContainerStopped = errors.New("container stopped")
+
+// dependencyProbe checks that a dependency accepts connections
+// With neither Command nor Port, docker's own HEALTHCHECK is used if the image has one.
+type dependencyProbe struct {
+	Command  string // Run in the container with sh -c
+	Port     int    // TCP port on the container's private network address
+	Interval time.Duration
+	Timeout  time.Duration
+	Retries  int
+	Disabled bool // Only wait for the container to run
+}
+
+// dependencyProbeFor returns the probe of a dependency: configured, built in for its image,
+// or a TCP connect to its port
+func dependencyProbeFor(dep config.Dependency) dependencyProbe {
+	probe := dependencyProbe{Interval: defaultProbeInterval, Timeout: defaultProbeTimeout, Retries: defaultProbeRetries}
+
+	hc := dep.Healthcheck
+	if hc == nil {
+		hc = &config.DependencyHealthcheck{}
+	}
+	if d, err := time.ParseDuration(hc.Interval); err == nil && d > 0 {
+		probe.Interval = d
+	}
+	if d, err := time.ParseDuration(hc.Timeout); err == nil && d > 0 {
+		probe.Timeout = d
+	}
+	if hc.Retries > 0 {
+		probe.Retries = hc.Retries
+	}
+
+	switch {
+	case hc.Enabled != nil && !*hc.Enabled:
+		probe.Disabled = true
+	case hc.Command != "":
+		probe.Command = hc.Command
+	case hc.Port != 0:
+		probe.Port = hc.Port
+	case builtinProbes[docker.ParseImageReference(dep.Image).Name()] != "":
+		probe.Command = builtinProbes[docker.ParseImageReference(dep.Image).Name()]
+	default:
+		probe.Port = dep.Port
+	}
+	return probe
+}
+
+// String describes the probe for output
+func (p dependencyProbe) String() string {
+	switch {
+	case p.Disabled:
+		return "running"
+	case p.Command != "":
+		return p.Command
+	case p.Port != 0:
+		return fmt.Sprintf("tcp port %d", p.Port)
+	}
+	return "docker healthcheck"
+}
+
+// waitForDependency waits until a dependency container runs and its probe succeeds
+func waitForDependency(client ssh.SSHClient, cfg *config.Config, name string) error {
+	probe := dependencyProbeFor(cfg.Dependencies[name])
+	containerName := dependencyContainerName(cfg, name)
+
+	fmt.Println(ui.Info(fmt.Sprintf("  %s: waiting for health check (%s)...", name, probe)))
+
+	var err error
+	for attempt := 1; attempt <= probe.Retries; attempt++ {
+		if attempt > 1 {
+			time.Sleep(probe.Interval)
+		}
+		if err = probe.check(client, cfg, containerName); err == nil || errors.Is(err, errContainerStopped) {
+			return err
+		}
+	}
+	return fmt.Errorf("not healthy after %d checks: %w", probe.Retries, err)
+}
+
+// ProbeDependency runs a dependency's probe once: the check deploys wait for
+// It returns "healthy" or "unhealthy", or "" when the probe is disabled.
+func ProbeDependency(cfg *config.Config, client ssh.SSHClient, name string) string {
+	probe := dependencyProbeFor(cfg.Dependencies[name])
+	if probe.Disabled {
+		return ""
+	}
+	if err := probe.check(client, cfg, dependencyContainerName(cfg, name)); err != nil {
+		return "unhealthy"
+	}
+	return "healthy"
+}
+
+// check probes a container once
+func (p dependencyProbe) check(client ssh.SSHClient, cfg *config.Config, containerName string) error {
+	switch state := dependencyState(client, containerName); state {
+	case "running":
+	case "":
+		return fmt.Errorf("container not found")
+	case "exited", "dead":
+		return fmt.Errorf("%w (status: %s)", errContainerStopped, state)
+	default:
+		return fmt.Errorf("container is %s", state)
+	}
+
+	// The host's timeout limits a probe that hangs, e.g. on a firewalled port
+	limit := strconv.Itoa(int(math.Ceil(p.Timeout.Seconds())))
+
+	switch {
+	case p.Disabled:
+		return nil
+
+	case p.Command != "":
+		cmd := shell.Join("timeout", limit, "sudo", "docker", "exec", containerName, "sh", "-c", p.Command)
+		if output, err := client.Execute(cmd); err != nil {
+			return fmt.Errorf("%s failed: %w\n%s", p.Command, err, output)
+		}
+		return nil
+
+	case p.Port != 0:
+		ipCmd := shell.Join("sudo", "docker", "inspect", "--format",
+			fmt.Sprintf(`{{(index .NetworkSettings.Networks %q).IPAddress}}`, docker.NetworkName(cfg.Service)), containerName)
+		ip, err := client.Execute(ipCmd)
+		if ip = strings.TrimSpace(ip); err != nil || ip == "" {
+			return fmt.Errorf("container has no address on %s", docker.NetworkName(cfg.Service))
+		}
+		connectCmd := shell.Join("timeout", limit, "bash", "-c", fmt.Sprintf(": </dev/tcp/%s/%d", ip, p.Port))
+		if _, err := client.Execute(connectCmd); err != nil {
+			return fmt.Errorf("port %d not accepting connections", p.Port)
+		}
+		return nil
+	}
+
+	// No probe of our own: use docker's HEALTHCHECK, or running if the image has none
+	health, _ := client.Execute(shell.Join("sudo", "docker", "inspect", "--format", "{{if .State.Health}}{{.State.Health.Status}}{{else}}none{{end}}", containerName))
+	switch health = strings.TrimSpace(health); health {
+	case "healthy", "none":
+		return nil
+	default:
+		return fmt.Errorf("docker health status: %s", health)
+	}
+}
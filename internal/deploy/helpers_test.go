@@ -1,7 +1,10 @@
 package deploy
 
 import (
+	"reflect"
 	"testing"
+
+	"github.com/ekinertac/podlift/internal/config"
 )
 
 // Most deploy functions require real infrastructure
@@ -35,3 +38,95 @@ func TestDeployOptions_Structure(t *testing.T) {
 // - Real server
 // These are tested in E2E tests
 
+
+func TestServiceRuntime(t *testing.T) {
+	disabled := false
+	service := config.Service{
+		Memory:          "1g",
+		StopGracePeriod: "45s",
+		Logging:         &config.LoggingConfig{Driver: "local"},
+		Healthcheck:     &config.HealthcheckConfig{Command: "pg_isready", Interval: "5s"},
+	}
+
+	runtime := serviceRuntime(service)
+	if runtime.Memory != "1g" || runtime.StopTimeout != 45 || runtime.LogDriver != "local" {
+		t.Errorf("serviceRuntime() = %+v", runtime)
+	}
+	if runtime.Healthcheck == nil || runtime.Healthcheck.Command != "pg_isready" || runtime.Healthcheck.Interval != "5s" {
+		t.Errorf("Healthcheck = %+v, want pg_isready every 5s", runtime.Healthcheck)
+	}
+
+	// HTTP-only and disabled health checks don't become docker healthchecks
+	service.Healthcheck = &config.HealthcheckConfig{Path: "/health"}
+	if serviceRuntime(service).Healthcheck != nil {
+		t.Error("HTTP health check should not set --health-cmd")
+	}
+	service.Healthcheck = &config.HealthcheckConfig{Command: "pg_isready", Enabled: &disabled}
+	if serviceRuntime(service).Healthcheck != nil {
+		t.Error("disabled health check should not set --health-cmd")
+	}
+}
+
+func TestServiceContainerConfig_Logging(t *testing.T) {
+	cfg := &config.Config{
+		Service: "myapp",
+		Logging: &config.LoggingConfig{MaxSize: "10m", MaxFile: 3},
+		Services: map[string]config.Service{
+			"web":    {Port: 8000},
+			"worker": {Logging: &config.LoggingConfig{Driver: "journald", Options: map[string]string{"tag": "worker"}}},
+		},
+	}
+
+	web := serviceContainerConfig(cfg, "web", "abc123", "myapp:abc123", "", nil).Runtime
+	if web.LogDriver != "" || !reflect.DeepEqual(web.LogOptions, map[string]string{"max-size": "10m", "max-file": "3"}) {
+		t.Errorf("web logging = %s %v, want the global rotation", web.LogDriver, web.LogOptions)
+	}
+
+	worker := serviceContainerConfig(cfg, "worker", "abc123", "myapp:abc123", "", nil).Runtime
+	if worker.LogDriver != "journald" || !reflect.DeepEqual(worker.LogOptions, map[string]string{"tag": "worker"}) {
+		t.Errorf("worker logging = %s %v, want its own journald config", worker.LogDriver, worker.LogOptions)
+	}
+}
+
+func TestServiceEnv(t *testing.T) {
+	cfg := &config.Config{Service: "myapp"}
+	cfg.Servers.Set(map[string][]config.Server{"web": {{Host: "192.168.1.10"}}})
+	cfg.Dependencies = map[string]config.Dependency{
+		"redis": {Image: "redis:7", Port: 6379, Exports: map[string]string{
+			"REDIS_URL": "redis://{{host}}:{{port}}/0",
+			"CACHE_URL": "redis://{{host}}:{{port}}/1",
+		}},
+	}
+	service := config.Service{Env: map[string]string{"CACHE_URL": "redis://cache:6379", "DEBUG": "false"}}
+
+	env, err := serviceEnv(cfg, config.Server{Host: "192.168.1.10"}, service)
+	if err != nil {
+		t.Fatalf("serviceEnv() error = %v", err)
+	}
+	if env["REDIS_URL"] != "redis://redis:6379/0" {
+		t.Errorf("REDIS_URL = %q, want the exported value", env["REDIS_URL"])
+	}
+	if env["CACHE_URL"] != "redis://cache:6379" || env["DEBUG"] != "false" {
+		t.Errorf("service env should override exports, got %v", env)
+	}
+	if len(service.Env) != 2 {
+		t.Errorf("serviceEnv() modified the service env: %v", service.Env)
+	}
+}
+
+func TestServiceTempPorts(t *testing.T) {
+	cfg := &config.Config{
+		Service: "myapp",
+		Services: map[string]config.Service{
+			"web":    {Replicas: 2, Port: 8000},
+			"worker": {Replicas: 1, DependsOn: config.DependsOn{"web": {}}},
+			"beat":   {Replicas: 1, DependsOn: config.DependsOn{"worker": {}}},
+		},
+	}
+
+	got := serviceTempPorts(cfg, 8000)
+	want := map[string]int{"web": 8000, "worker": 8002, "beat": 8003}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("serviceTempPorts() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,186 @@
+package deploy
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/docker"
+	"github.com/ekinertac/podlift/internal/shell"
+	"github.com/ekinertac/podlift/internal/ssh"
+	"github.com/ekinertac/podlift/internal/ui"
+)
+
+// jobLogMaxBytes is the size above which a job's log is rotated to <name>.log.1
+const jobLogMaxBytes = 10 * 1024 * 1024
+
+// CronFilePath returns the crontab podlift manages for an app, e.g. /etc/cron.d/podlift-myapp
+func CronFilePath(service string) string {
+	return "/etc/cron.d/podlift-" + service
+}
+
+// JobScriptDir returns the directory holding the run scripts of an app's jobs
+func JobScriptDir(service string) string {
+	return fmt.Sprintf("/etc/podlift/%s/jobs", service)
+}
+
+// JobLogPath returns the log of a job's runs, e.g. /var/log/podlift/myapp/cleanup.log
+func JobLogPath(service, job string) string {
+	return fmt.Sprintf("/var/log/podlift/%s/%s.log", service, job)
+}
+
+// jobContainerName is fixed per job, so a run that's still going blocks the next one
+func jobContainerName(service, job string) string {
+	return fmt.Sprintf("%s-job-%s", service, job)
+}
+
+// jobContainerConfig returns the container config of a job's runs for a release
+func jobContainerConfig(cfg *config.Config, name string, rel RunningRelease, envFiles []string) (docker.ContainerConfig, error) {
+	command, err := shell.Split(cfg.Jobs[name].Command)
+	if err != nil {
+		return docker.ContainerConfig{}, fmt.Errorf("job '%s' command: %w", name, err)
+	}
+	opts := TaskOptions{Service: cfg.JobService(name), Command: command}
+	containerCfg := taskContainerConfig(cfg, rel, opts, envFiles, time.Now())
+	containerCfg.Name = jobContainerName(cfg.Service, name)
+	containerCfg.Labels["podlift.job"] = name
+	return containerCfg, nil
+}
+
+// jobScript returns the script cron runs for a job
+// Output goes to the job's log between start and exit markers. A run is skipped
+// while the previous one is still going.
+func jobScript(cfg *config.Config, name string, rel RunningRelease, containerCfg docker.ContainerConfig) string {
+	logPath := JobLogPath(cfg.Service, name)
+	// cron runs the script as root
+	runCmd := strings.TrimPrefix(docker.GenerateRunCommand(containerCfg), "sudo ")
+
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&b, "# Job %s of %s, release %s. Managed by podlift: overwritten on deploy.\n", name, cfg.Service, rel.Version)
+	fmt.Fprintf(&b, "log=%s\n", shell.Quote(logPath))
+	fmt.Fprintf(&b, "mkdir -p %s\n", shell.Quote(path.Dir(logPath)))
+	fmt.Fprintf(&b, "if [ \"$(stat -c %%s \"$log\" 2>/dev/null || echo 0)\" -gt %d ]; then mv \"$log\" \"$log.1\"; fi\n", jobLogMaxBytes)
+	b.WriteString("exec >>\"$log\" 2>&1\n")
+	fmt.Fprintf(&b, "if [ -n \"$(docker ps -q --filter %s)\" ]; then\n", shell.Quote("name=^"+containerCfg.Name+"$"))
+	b.WriteString("  echo \"=== $(date -u +%Y-%m-%dT%H:%M:%SZ) skipped: previous run still running\"\n")
+	b.WriteString("  exit 0\n")
+	b.WriteString("fi\n")
+	b.WriteString("echo \"=== $(date -u +%Y-%m-%dT%H:%M:%SZ) start\"\n")
+	b.WriteString(runCmd + "\n")
+	b.WriteString("status=$?\n")
+	b.WriteString("echo \"=== $(date -u +%Y-%m-%dT%H:%M:%SZ) exit $status\"\n")
+	b.WriteString("exit $status\n")
+	return b.String()
+}
+
+// cronFile returns the crontab running the given jobs of an app
+func cronFile(cfg *config.Config, names []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Scheduled jobs of %s. Managed by podlift: overwritten on deploy.\n", cfg.Service)
+	b.WriteString("SHELL=/bin/sh\n")
+	b.WriteString("PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s root %s\n", cfg.Jobs[name].Schedule, path.Join(JobScriptDir(cfg.Service), name+".sh"))
+	}
+	return b.String()
+}
+
+// InstallJobs makes the server's crontab run the jobs placed on it with the given release
+// Scripts of removed jobs are deleted; a server without jobs has the crontab removed.
+// Job logs are kept.
+func InstallJobs(cfg *config.Config, client ssh.SSHClient, server config.Server, rel RunningRelease) error {
+	names, err := cfg.JobsOn(server)
+	if err != nil {
+		return err
+	}
+	scriptDir := JobScriptDir(cfg.Service)
+	cronPath := CronFilePath(cfg.Service)
+
+	if len(names) == 0 {
+		// Nothing to do on servers that never had jobs, but clean up after removed ones
+		cleanupCmd := shell.Join("sudo", "rm", "-rf", cronPath, scriptDir)
+		if _, err := client.Execute(cleanupCmd); err != nil {
+			return fmt.Errorf("failed to remove jobs: %w", err)
+		}
+		return nil
+	}
+
+	if _, err := client.Execute(shell.Join("test", "-d", "/etc/cron.d")); err != nil {
+		fmt.Println(ui.Warning(fmt.Sprintf("%s has no /etc/cron.d, jobs won't run until cron is installed", server.Host)))
+	}
+	if _, err := client.Execute(shell.Join("sudo", "mkdir", "-p", scriptDir, "/etc/cron.d")); err != nil {
+		return fmt.Errorf("failed to create %s: %w", scriptDir, err)
+	}
+
+	for _, name := range names {
+		var envFiles []string
+		envFile := docker.EnvFilePath(cfg.Service, cfg.JobService(name), rel.Version)
+		if _, err := client.Execute(shell.Join("sudo", "test", "-f", envFile)); err == nil {
+			envFiles = []string{envFile}
+		}
+
+		containerCfg, err := jobContainerConfig(cfg, name, rel, envFiles)
+		if err != nil {
+			return err
+		}
+		scriptPath := path.Join(scriptDir, name+".sh")
+		if err := client.WriteFile(jobScript(cfg, name, rel, containerCfg), scriptPath); err != nil {
+			return fmt.Errorf("failed to write %s: %w", scriptPath, err)
+		}
+		if _, err := client.Execute(shell.Join("sudo", "chmod", "700", scriptPath)); err != nil {
+			return fmt.Errorf("failed to make %s executable: %w", scriptPath, err)
+		}
+	}
+
+	// Scripts of jobs that were removed or moved to another server
+	output, err := client.Execute(shell.Join("sudo", "ls", "-1", scriptDir))
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", scriptDir, err)
+	}
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name+".sh"] = true
+	}
+	for _, file := range strings.Fields(output) {
+		if !wanted[file] {
+			if _, err := client.Execute(shell.Join("sudo", "rm", "-f", path.Join(scriptDir, file))); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", file, err)
+			}
+		}
+	}
+
+	// The crontab goes last, so cron never runs a script that isn't written yet
+	if err := client.WriteFile(cronFile(cfg, names), cronPath); err != nil {
+		return fmt.Errorf("failed to write %s: %w", cronPath, err)
+	}
+	if _, err := client.Execute(shell.Join("sudo", "chmod", "644", cronPath)); err != nil {
+		return fmt.Errorf("failed to set permissions of %s: %w", cronPath, err)
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Installed %d scheduled job(s): %s", len(names), strings.Join(names, ", "))))
+	return nil
+}
+
+// JobRun is the outcome of a job's last run, read from its log
+type JobRun struct {
+	Time   string // UTC time the run ended, or "" if it never ran
+	Status string // "exit N", "start" while running, or "skipped: ..."
+}
+
+// LastJobRun reads the last marker from a job's log on the connected server
+func LastJobRun(cfg *config.Config, client ssh.SSHClient, name string) (JobRun, error) {
+	logPath := JobLogPath(cfg.Service, name)
+	output, err := client.Execute(shell.Join("sudo", "sh", "-c", fmt.Sprintf("grep '^=== ' %s 2>/dev/null | tail -n 1", shell.Quote(logPath))))
+	if err != nil {
+		return JobRun{}, fmt.Errorf("failed to read %s: %w", logPath, err)
+	}
+	marker := strings.TrimPrefix(strings.TrimSpace(output), "=== ")
+	if marker == "" {
+		return JobRun{}, nil
+	}
+	when, status, _ := strings.Cut(marker, " ")
+	return JobRun{Time: when, Status: status}, nil
+}
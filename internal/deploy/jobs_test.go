@@ -0,0 +1,153 @@
+package deploy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/ssh"
+)
+
+func jobsTestConfig() *config.Config {
+	cfg := runTestConfig()
+	cfg.Servers.Set(map[string][]config.Server{
+		"web":    {{Host: "192.168.1.10"}},
+		"worker": {{Host: "192.168.1.20"}},
+	})
+	cfg.Jobs = map[string]config.Job{
+		"clearsessions": {Schedule: "0 3 * * *", Command: "python manage.py clearsessions"},
+		"report":        {Schedule: "@weekly", Command: "python manage.py report --format 'pdf a4'", Role: "worker"},
+	}
+	return cfg
+}
+
+// jobsClient returns a mock server recording commands and written files
+// ls of the scripts directory lists existing.
+func jobsClient(existing string) (*ssh.MockClient, *[]string, map[string]string) {
+	var commands []string
+	files := map[string]string{}
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		commands = append(commands, cmd)
+		switch {
+		case strings.HasPrefix(cmd, "sudo ls -1 "):
+			return existing, nil
+		case strings.HasPrefix(cmd, "sudo test -f /etc/podlift/myapp/env/"):
+			return "", errors.New("exit status 1")
+		}
+		return "", nil
+	}
+	client.WriteFileFunc = func(content, path string) error {
+		commands = append(commands, "write "+path)
+		files[path] = content
+		return nil
+	}
+	return client, &commands, files
+}
+
+func TestInstallJobs(t *testing.T) {
+	cfg := jobsTestConfig()
+	rel := RunningRelease{Version: "abc123", Image: "myapp:abc123"}
+	client, commands, files := jobsClient("clearsessions.sh\nold.sh\n")
+
+	if err := InstallJobs(cfg, client, config.Server{Host: "192.168.1.10"}, rel); err != nil {
+		t.Fatalf("InstallJobs() error = %v", err)
+	}
+
+	crontab := files["/etc/cron.d/podlift-myapp"]
+	if !strings.Contains(crontab, "0 3 * * * root /etc/podlift/myapp/jobs/clearsessions.sh\n") {
+		t.Errorf("crontab should run clearsessions, got:\n%s", crontab)
+	}
+	if strings.Contains(crontab, "report") {
+		t.Errorf("crontab should only have the jobs of this server, got:\n%s", crontab)
+	}
+
+	script := files["/etc/podlift/myapp/jobs/clearsessions.sh"]
+	for _, want := range []string{
+		"#!/bin/sh\n",
+		"log=/var/log/podlift/myapp/clearsessions.log\n",
+		"exec >>\"$log\" 2>&1\n",
+		"docker ps -q --filter 'name=^myapp-job-clearsessions$'",
+		"\ndocker run --rm --name myapp-job-clearsessions",
+		"--label podlift.job=clearsessions",
+		"myapp:abc123 python manage.py clearsessions\n",
+		"exit $status\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script should contain %q, got:\n%s", want, script)
+		}
+	}
+	for _, unwanted := range []string{"sudo docker run", " -d ", "--restart", "--publish", "--env-file"} {
+		if strings.Contains(script, unwanted) {
+			t.Errorf("script should not contain %q, got:\n%s", unwanted, script)
+		}
+	}
+
+	joined := strings.Join(*commands, "\n")
+	if !strings.Contains(joined, "sudo rm -f /etc/podlift/myapp/jobs/old.sh") {
+		t.Errorf("script of a removed job should be deleted, commands:\n%s", joined)
+	}
+	if strings.Contains(joined, "rm -f /etc/podlift/myapp/jobs/clearsessions.sh") {
+		t.Errorf("script of a configured job must not be deleted, commands:\n%s", joined)
+	}
+	if last := (*commands)[len(*commands)-2]; last != "write /etc/cron.d/podlift-myapp" {
+		t.Errorf("crontab should be written after the scripts, commands:\n%s", joined)
+	}
+}
+
+func TestInstallJobs_Placement(t *testing.T) {
+	cfg := jobsTestConfig()
+	rel := RunningRelease{Version: "abc123", Image: "myapp:abc123"}
+	client, _, files := jobsClient("")
+
+	if err := InstallJobs(cfg, client, config.Server{Host: "192.168.1.20"}, rel); err != nil {
+		t.Fatalf("InstallJobs() error = %v", err)
+	}
+	if crontab := files["/etc/cron.d/podlift-myapp"]; !strings.Contains(crontab, "@weekly root /etc/podlift/myapp/jobs/report.sh\n") {
+		t.Errorf("crontab should run report, got:\n%s", crontab)
+	}
+	if script := files["/etc/podlift/myapp/jobs/report.sh"]; !strings.Contains(script, "report --format 'pdf a4'\n") {
+		t.Errorf("script should keep the quoted argument, got:\n%s", script)
+	}
+}
+
+func TestInstallJobs_NoJobs(t *testing.T) {
+	cfg := jobsTestConfig()
+	cfg.Jobs = nil
+	client, commands, files := jobsClient("")
+
+	if err := InstallJobs(cfg, client, config.Server{Host: "192.168.1.10"}, RunningRelease{Version: "abc123"}); err != nil {
+		t.Fatalf("InstallJobs() error = %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("no files should be written, got %v", files)
+	}
+	if len(*commands) != 1 || (*commands)[0] != "sudo rm -rf /etc/cron.d/podlift-myapp /etc/podlift/myapp/jobs" {
+		t.Errorf("commands = %v, want the crontab and scripts removed", *commands)
+	}
+}
+
+func TestLastJobRun(t *testing.T) {
+	cfg := jobsTestConfig()
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		if !strings.Contains(cmd, "/var/log/podlift/myapp/clearsessions.log") {
+			t.Errorf("command = %q, want the job's log", cmd)
+		}
+		return "=== 2024-01-01T03:00:12Z exit 0\n", nil
+	}
+
+	run, err := LastJobRun(cfg, client, "clearsessions")
+	if err != nil {
+		t.Fatalf("LastJobRun() error = %v", err)
+	}
+	if run.Time != "2024-01-01T03:00:12Z" || run.Status != "exit 0" {
+		t.Errorf("LastJobRun() = %+v", run)
+	}
+
+	client.ExecuteFunc = func(cmd string) (string, error) { return "", nil }
+	if run, _ := LastJobRun(cfg, client, "clearsessions"); run.Time != "" {
+		t.Errorf("LastJobRun() = %+v, want no run", run)
+	}
+}
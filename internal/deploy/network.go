@@ -0,0 +1,18 @@
+package deploy
+
+import (
+	"fmt"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/docker"
+	"github.com/ekinertac/podlift/internal/ssh"
+)
+
+// EnsureNetwork creates the private network of the service on a server
+// App containers and dependencies join it, so apps reach dependencies by name (e.g. postgres:5432).
+func EnsureNetwork(client ssh.SSHClient, cfg *config.Config) error {
+	if output, err := client.Execute(docker.GenerateNetworkCreateCommand(cfg.Service)); err != nil {
+		return fmt.Errorf("failed to create network %s: %w\n%s", docker.NetworkName(cfg.Service), err, output)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package deploy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/shell"
+	"github.com/ekinertac/podlift/internal/ssh"
+	"github.com/ekinertac/podlift/internal/ui"
+)
+
+// dependsOnWaiter waits for the depends_on conditions of services, which are started in cfg.ServiceOrder()
+// Dependencies are started and probed before any service, so only services are waited for here.
+type dependsOnWaiter struct {
+	client      ssh.SSHClient
+	cfg         *config.Config
+	version     string
+	checkHealth func(serviceName string) error // Health check of the deploy strategy; nil skips healthy conditions
+
+	healthy   map[string]bool
+	completed map[string]bool
+}
+
+func newDependsOnWaiter(client ssh.SSHClient, cfg *config.Config, version string, checkHealth func(string) error) *dependsOnWaiter {
+	return &dependsOnWaiter{
+		client:      client,
+		cfg:         cfg,
+		version:     version,
+		checkHealth: checkHealth,
+		healthy:     make(map[string]bool),
+		completed:   make(map[string]bool),
+	}
+}
+
+// wait blocks until every service that serviceName depends on reached its condition
+func (w *dependsOnWaiter) wait(serviceName string) error {
+	dependsOn := w.cfg.Services[serviceName].DependsOn
+	for _, target := range dependsOn.Names() {
+		if _, ok := w.cfg.Services[target]; !ok {
+			continue
+		}
+
+		switch dependsOn[target].Condition {
+		case config.ConditionHealthy:
+			if w.healthy[target] || w.checkHealth == nil {
+				continue
+			}
+			fmt.Println(ui.Info(fmt.Sprintf("  %s: waiting for %s to be healthy...", serviceName, target)))
+			if err := w.checkHealth(target); err != nil {
+				return fmt.Errorf("%s depends on %s: %w", serviceName, target, err)
+			}
+			w.healthy[target] = true
+
+		case config.ConditionCompleted:
+			if w.completed[target] {
+				continue
+			}
+			fmt.Println(ui.Info(fmt.Sprintf("  %s: waiting for %s to complete...", serviceName, target)))
+			if err := waitForJob(w.client, w.cfg, target, w.version); err != nil {
+				return fmt.Errorf("%s depends on %s: %w", serviceName, target, err)
+			}
+			w.completed[target] = true
+		}
+	}
+	return nil
+}
+
+// checked reports whether a service's health was already checked for a condition
+func (w *dependsOnWaiter) checked(serviceName string) bool {
+	return w.healthy[serviceName]
+}
+
+// inDependencyOrder returns the named dependencies in start order (see config.DependencyOrder)
+func inDependencyOrder(cfg *config.Config, names []string) []string {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	ordered := make([]string, 0, len(names))
+	for _, name := range cfg.DependencyOrder() {
+		if wanted[name] {
+			ordered = append(ordered, name)
+			delete(wanted, name)
+		}
+	}
+	for _, name := range names {
+		if wanted[name] {
+			ordered = append(ordered, name) // Not in the config; reported by the caller
+			delete(wanted, name)
+		}
+	}
+	return ordered
+}
+
+// waitForJob waits for the containers of a one-off job service to exit
+// Containers that exit with 0 are removed; a failed one is kept for `docker logs`.
+func waitForJob(client ssh.SSHClient, cfg *config.Config, serviceName, version string) error {
+	for replica := 1; replica <= cfg.Services[serviceName].Replicas; replica++ {
+		containerName := fmt.Sprintf("%s-%s-%s-%d", cfg.Service, serviceName, version, replica)
+
+		output, err := client.Execute(shell.Join("sudo", "docker", "wait", containerName))
+		if err != nil {
+			return fmt.Errorf("failed to wait for %s: %w\n%s", containerName, err, output)
+		}
+		if code := strings.TrimSpace(output); code != "0" {
+			logs, _ := client.Execute(shell.Join("sudo", "docker", "logs", "--tail", "20", containerName) + " 2>&1")
+			return fmt.Errorf("%s exited with code %s:\n%s", containerName, code, strings.TrimRight(logs, "\n"))
+		}
+
+		client.Execute(shell.Join("sudo", "docker", "rm", containerName))
+		fmt.Println(ui.Success(fmt.Sprintf("  %s completed", containerName)))
+	}
+	return nil
+}
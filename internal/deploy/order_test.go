@@ -0,0 +1,122 @@
+package deploy
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/ssh"
+)
+
+func TestDependsOnWaiter(t *testing.T) {
+	cfg := &config.Config{
+		Service: "myapp",
+		Services: map[string]config.Service{
+			"migrate": {Replicas: 1, Restart: "no"},
+			"web": {Replicas: 1, DependsOn: config.DependsOn{
+				"migrate":  {Condition: config.ConditionCompleted},
+				"postgres": {Condition: config.ConditionHealthy},
+			}},
+			"worker": {Replicas: 1, DependsOn: config.DependsOn{
+				"migrate": {Condition: config.ConditionCompleted},
+				"web":     {Condition: config.ConditionHealthy},
+			}},
+		},
+		Dependencies: map[string]config.Dependency{"postgres": {Image: "postgres:16"}},
+	}
+
+	var commands []string
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		commands = append(commands, cmd)
+		if strings.Contains(cmd, "docker wait") {
+			return "0\n", nil
+		}
+		return "", nil
+	}
+
+	var checked []string
+	waiter := newDependsOnWaiter(client, cfg, "abc123", func(name string) error {
+		checked = append(checked, name)
+		return nil
+	})
+
+	for _, name := range cfg.ServiceOrder() {
+		if err := waiter.wait(name); err != nil {
+			t.Fatalf("wait(%s) error = %v", name, err)
+		}
+	}
+
+	// The job is waited for and removed once; dependencies were probed before the services
+	want := []string{"sudo docker wait myapp-migrate-abc123-1", "sudo docker rm myapp-migrate-abc123-1"}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("commands = %v, want %v", commands, want)
+	}
+	if !reflect.DeepEqual(checked, []string{"web"}) {
+		t.Errorf("health checked = %v, want [web]", checked)
+	}
+	if !waiter.checked("web") || waiter.checked("worker") {
+		t.Errorf("checked() should only report web")
+	}
+}
+
+func TestWaitForJob_Failed(t *testing.T) {
+	cfg := &config.Config{
+		Service:  "myapp",
+		Services: map[string]config.Service{"migrate": {Replicas: 1, Restart: "no"}},
+	}
+
+	var commands []string
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		commands = append(commands, cmd)
+		switch {
+		case strings.Contains(cmd, "docker wait"):
+			return "1\n", nil
+		case strings.Contains(cmd, "docker logs"):
+			return "django.db.utils.OperationalError: connection refused\n", nil
+		}
+		return "", nil
+	}
+
+	err := waitForJob(client, cfg, "migrate", "abc123")
+	if err == nil || !strings.Contains(err.Error(), "exited with code 1") || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("waitForJob() error = %v, want exit code and logs", err)
+	}
+	for _, cmd := range commands {
+		if strings.Contains(cmd, "docker rm") {
+			t.Errorf("failed job container should be kept, got %q", cmd)
+		}
+	}
+}
+
+func TestWaitForJob_WaitError(t *testing.T) {
+	cfg := &config.Config{
+		Service:  "myapp",
+		Services: map[string]config.Service{"migrate": {Replicas: 1, Restart: "no"}},
+	}
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		return "Error: No such container", errors.New("exit status 1")
+	}
+
+	if err := waitForJob(client, cfg, "migrate", "abc123"); err == nil {
+		t.Fatal("waitForJob() should fail when the container is missing")
+	}
+}
+
+func TestInDependencyOrder(t *testing.T) {
+	cfg := &config.Config{Dependencies: map[string]config.Dependency{
+		"postgres":  {Image: "postgres:16"},
+		"pgbouncer": {Image: "edoburu/pgbouncer", DependsOn: config.DependsOn{"postgres": {Condition: config.ConditionHealthy}}},
+		"redis":     {Image: "redis:7"},
+	}}
+
+	got := inDependencyOrder(cfg, []string{"redis", "pgbouncer", "postgres"})
+	want := []string{"postgres", "pgbouncer", "redis"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("inDependencyOrder() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,242 @@
+package deploy
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/nginx"
+	"github.com/ekinertac/podlift/internal/shell"
+	"github.com/ekinertac/podlift/internal/ssh"
+)
+
+// RecoveryCheck is one check of a server after docker or the host restarted
+type RecoveryCheck struct {
+	Name   string
+	OK     bool
+	Detail string
+}
+
+// RecoveredContainer is an application container running after a restart
+type RecoveredContainer struct {
+	Name    string
+	Service string // Service name from podlift.container_type, e.g. web
+	Ports   []int  // Published host ports
+}
+
+// RecoveryReport is the state of a server after a restart
+type RecoveryReport struct {
+	Checks     []RecoveryCheck
+	Containers []RecoveredContainer
+}
+
+// OK returns true if every check passed
+func (r *RecoveryReport) OK() bool {
+	for _, check := range r.Checks {
+		if !check.OK {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *RecoveryReport) add(name string, ok bool, detail string) {
+	r.Checks = append(r.Checks, RecoveryCheck{Name: name, OK: ok, Detail: detail})
+}
+
+// VerifyRecovery checks that docker, the application containers, dependencies
+// and the nginx upstream all came back on a server
+func VerifyRecovery(client ssh.SSHClient, server config.Server, cfg *config.Config) *RecoveryReport {
+	report := &RecoveryReport{}
+
+	version, err := client.Execute("sudo docker info --format '{{.ServerVersion}}'")
+	if err != nil {
+		report.add("docker", false, "daemon not running")
+		return report
+	}
+	report.add("docker", true, "running "+strings.TrimSpace(version))
+
+	// Application containers
+	listCmd := shell.Join("sudo", "docker", "ps", "--filter", "label=podlift.service="+cfg.Service,
+		"--filter", "label=podlift.container_type", "--format", `{{.Names}}\t{{.Label "podlift.container_type"}}\t{{.Ports}}`)
+	output, err := client.Execute(listCmd)
+	if err != nil {
+		report.add("containers", false, fmt.Sprintf("failed to list containers: %v", err))
+		return report
+	}
+	report.Containers = parseRecoveredContainers(output)
+
+	running := make(map[string]int)
+	for _, c := range report.Containers {
+		running[c.Service]++
+	}
+	for _, name := range cfg.ServiceOrder() {
+		if cfg.IsJob(name) {
+			continue // One-off jobs exit and are removed
+		}
+		want := cfg.Services[name].Replicas
+		report.add("service "+name, running[name] == want, fmt.Sprintf("%d/%d containers running", running[name], want))
+	}
+
+	// Dependencies placed on this server
+	names, _ := cfg.DependenciesOn(server)
+	for _, status := range InspectDependencies(cfg, client, names) {
+		report.add("dependency "+status.Name, status.State == "running", status.State)
+	}
+
+	// nginx must be up and send traffic to exactly the containers that came back
+	if cfg.Proxy != nil && cfg.Proxy.Enabled {
+		nginxMgr := nginx.NewManager(client)
+		if !nginxMgr.IsActive() {
+			report.add("nginx", false, "not running")
+			return report
+		}
+
+		siteConfig, err := nginxMgr.ReadConfig(cfg.Service)
+		if err != nil {
+			report.add("nginx", true, "running (no site configured)")
+			return report
+		}
+		report.add("nginx", true, "running")
+
+		missing, stale := compareUpstreams(nginx.ParseUpstreams(siteConfig), report.Containers)
+		switch {
+		case len(missing) > 0:
+			report.add("upstream", false, fmt.Sprintf("no container on port(s) %s", joinPorts(missing)))
+		case len(stale) > 0:
+			report.add("upstream", false, fmt.Sprintf("container port(s) %s not in nginx upstream", joinPorts(stale)))
+		default:
+			report.add("upstream", true, "matches running containers")
+		}
+	}
+
+	return report
+}
+
+// CheckRecoveredHealth runs the health check of each recovered container on the server itself
+func CheckRecoveredHealth(client ssh.SSHClient, cfg *config.Config, containers []RecoveredContainer) []RecoveryCheck {
+	var checks []RecoveryCheck
+
+	for _, c := range containers {
+		service, ok := cfg.Services[c.Service]
+		if !ok || len(c.Ports) == 0 || service.Healthcheck == nil ||
+			service.Healthcheck.Enabled != nil && !*service.Healthcheck.Enabled {
+			continue
+		}
+
+		if service.Healthcheck.Path == "" && service.Healthcheck.Command != "" {
+			// Only a docker healthcheck is configured: use docker's verdict
+			status, _ := client.Execute(shell.Join("sudo", "docker", "inspect", "--format", "{{if .State.Health}}{{.State.Health.Status}}{{end}}", c.Name))
+			status = strings.TrimSpace(status)
+			checks = append(checks, RecoveryCheck{Name: "health " + c.Name, OK: status == "healthy", Detail: "docker healthcheck " + status})
+			continue
+		}
+
+		path := service.Healthcheck.Path
+		if path == "" {
+			path = "/health"
+		}
+		expect := service.Healthcheck.Expect
+		if len(expect) == 0 {
+			expect = []int{200}
+		}
+
+		url := fmt.Sprintf("http://localhost:%d%s", c.Ports[0], path)
+		output, _ := client.Execute(shell.Join("curl", "-s", "-o", "/dev/null", "-m", "5", "-w", "%{http_code}", url))
+		code, _ := strconv.Atoi(strings.TrimSpace(output))
+
+		healthy := false
+		for _, expected := range expect {
+			if code == expected {
+				healthy = true
+			}
+		}
+		checks = append(checks, RecoveryCheck{
+			Name:   "health " + c.Name,
+			OK:     healthy,
+			Detail: fmt.Sprintf("%s → %d", path, code),
+		})
+	}
+
+	return checks
+}
+
+// parseRecoveredContainers parses `docker ps` lines of name, container type and ports
+func parseRecoveredContainers(output string) []RecoveredContainer {
+	var containers []RecoveredContainer
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.Split(line, "\t")
+		if len(parts) < 2 || parts[0] == "" {
+			continue
+		}
+		c := RecoveredContainer{Name: parts[0], Service: parts[1]}
+		if len(parts) > 2 {
+			c.Ports = parseHostPorts(parts[2])
+		}
+		containers = append(containers, c)
+	}
+	return containers
+}
+
+// parseHostPorts extracts published host ports from docker's Ports column
+// e.g. "0.0.0.0:9100->8000/tcp, [::]:9100->8000/tcp" → [9100]
+func parseHostPorts(ports string) []int {
+	var result []int
+	seen := make(map[int]bool)
+
+	for _, mapping := range strings.Split(ports, ",") {
+		host, _, ok := strings.Cut(strings.TrimSpace(mapping), "->")
+		if !ok {
+			continue // Exposed but not published
+		}
+		port, err := strconv.Atoi(host[strings.LastIndex(host, ":")+1:])
+		if err != nil || seen[port] {
+			continue
+		}
+		seen[port] = true
+		result = append(result, port)
+	}
+	return result
+}
+
+// compareUpstreams returns local upstream ports without a container, and container ports nginx doesn't use
+// Upstreams on other hosts belong to the load balancer and are checked on their own server.
+func compareUpstreams(upstreams []nginx.Upstream, containers []RecoveredContainer) (missing, stale []int) {
+	upstreamPorts := make(map[int]bool)
+	for _, u := range upstreams {
+		if u.Host == "localhost" || u.Host == "127.0.0.1" {
+			upstreamPorts[u.Port] = true
+		}
+	}
+
+	containerPorts := make(map[int]bool)
+	for _, c := range containers {
+		for _, port := range c.Ports {
+			containerPorts[port] = true
+		}
+	}
+
+	for port := range upstreamPorts {
+		if !containerPorts[port] {
+			missing = append(missing, port)
+		}
+	}
+	for port := range containerPorts {
+		if !upstreamPorts[port] {
+			stale = append(stale, port)
+		}
+	}
+	sort.Ints(missing)
+	sort.Ints(stale)
+	return missing, stale
+}
+
+func joinPorts(ports []int) string {
+	parts := make([]string, len(ports))
+	for i, port := range ports {
+		parts[i] = strconv.Itoa(port)
+	}
+	return strings.Join(parts, ", ")
+}
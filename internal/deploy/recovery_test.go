@@ -0,0 +1,146 @@
+package deploy
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/nginx"
+	"github.com/ekinertac/podlift/internal/ssh"
+)
+
+func TestParseHostPorts(t *testing.T) {
+	tests := []struct {
+		ports string
+		want  []int
+	}{
+		{"0.0.0.0:9100->8000/tcp, [::]:9100->8000/tcp", []int{9100}},
+		{"0.0.0.0:8000->8000/tcp, 0.0.0.0:8001->8001/tcp", []int{8000, 8001}},
+		{"127.0.0.1:5432->5432/tcp", []int{5432}},
+		{"8000/tcp", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		if got := parseHostPorts(tt.ports); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseHostPorts(%q) = %v, want %v", tt.ports, got, tt.want)
+		}
+	}
+}
+
+func TestCompareUpstreams(t *testing.T) {
+	upstreams := []nginx.Upstream{
+		{Host: "localhost", Port: 9100},
+		{Host: "localhost", Port: 9101},
+		{Host: "10.0.0.2", Port: 9100}, // Other server behind the load balancer
+	}
+	containers := []RecoveredContainer{
+		{Name: "myapp-web-abc-1", Ports: []int{9100}},
+		{Name: "myapp-web-old-1", Ports: []int{9000}},
+	}
+
+	missing, stale := compareUpstreams(upstreams, containers)
+	if !reflect.DeepEqual(missing, []int{9101}) {
+		t.Errorf("missing = %v, want [9101]", missing)
+	}
+	if !reflect.DeepEqual(stale, []int{9000}) {
+		t.Errorf("stale = %v, want [9000]", stale)
+	}
+}
+
+func TestVerifyRecovery(t *testing.T) {
+	server := config.Server{Host: "192.168.1.10"}
+	cfg := &config.Config{
+		Service: "myapp",
+		Servers: config.ServersConfig{},
+		Services: map[string]config.Service{
+			"web": {Port: 8000, Replicas: 2},
+		},
+		Proxy: &config.ProxyConfig{Enabled: true},
+	}
+
+	siteConfig, err := nginx.GenerateConfig(nginx.Config{
+		ServiceName: "myapp",
+		Domain:      "example.com",
+		Upstreams: []nginx.Upstream{
+			{Host: "localhost", Port: 9100},
+			{Host: "localhost", Port: 9101},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		containers string
+		nginx      string
+		wantOK     bool
+		wantFailed string
+	}{
+		{
+			name:       "recovered",
+			containers: "myapp-web-abc-1\tweb\t0.0.0.0:9100->8000/tcp\nmyapp-web-abc-2\tweb\t0.0.0.0:9101->8000/tcp\n",
+			nginx:      "active",
+			wantOK:     true,
+		},
+		{
+			name:       "container missing",
+			containers: "myapp-web-abc-1\tweb\t0.0.0.0:9100->8000/tcp\n",
+			nginx:      "active",
+			wantFailed: "service web",
+		},
+		{
+			name:       "nginx down",
+			containers: "myapp-web-abc-1\tweb\t0.0.0.0:9100->8000/tcp\nmyapp-web-abc-2\tweb\t0.0.0.0:9101->8000/tcp\n",
+			nginx:      "inactive",
+			wantFailed: "nginx",
+		},
+		{
+			name:       "old release came back",
+			containers: "myapp-web-abc-1\tweb\t0.0.0.0:9100->8000/tcp\nmyapp-web-abc-2\tweb\t0.0.0.0:9101->8000/tcp\nmyapp-web-old-1\tweb\t0.0.0.0:9000->8000/tcp\n",
+			nginx:      "active",
+			wantFailed: "service web",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := ssh.NewMockClient()
+			client.ExecuteFunc = func(cmd string) (string, error) {
+				switch {
+				case strings.Contains(cmd, "docker info"):
+					return "24.0.5\n", nil
+				case strings.Contains(cmd, "docker ps"):
+					return tt.containers, nil
+				case strings.Contains(cmd, "is-active nginx"):
+					if tt.nginx != "active" {
+						return tt.nginx, fmt.Errorf("exit status 3")
+					}
+					return tt.nginx, nil
+				case strings.Contains(cmd, "cat /etc/nginx/sites-available/myapp"):
+					return siteConfig, nil
+				}
+				return "", nil
+			}
+
+			report := VerifyRecovery(client, server, cfg)
+			if report.OK() != tt.wantOK {
+				t.Errorf("OK() = %v, want %v (checks: %+v)", report.OK(), tt.wantOK, report.Checks)
+			}
+			if tt.wantFailed != "" {
+				found := false
+				for _, check := range report.Checks {
+					if check.Name == tt.wantFailed && !check.OK {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("expected failed check %q, got %+v", tt.wantFailed, report.Checks)
+				}
+			}
+		})
+	}
+}
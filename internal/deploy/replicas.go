@@ -0,0 +1,59 @@
+package deploy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/shell"
+	"github.com/ekinertac/podlift/internal/ssh"
+)
+
+// Replica is a running container of a service
+type Replica struct {
+	Container string
+	Number    int
+	Version   string
+}
+
+// ListReplicas returns the running replicas of a service on the connected server, newest first
+// Replica numbers come from the podlift.replica label, or from the name suffix for
+// containers deployed before the label existed. During a deploy a number can appear
+// twice, once per release.
+func ListReplicas(cfg *config.Config, client ssh.SSHClient, serviceName string) ([]Replica, error) {
+	listCmd := shell.Join("sudo", "docker", "ps",
+		"--filter", "label=podlift.service="+cfg.Service,
+		"--filter", "label=podlift.container_type="+serviceName,
+		"--format", `{{.Names}}`+"\t"+`{{.Label "podlift.replica"}}`+"\t"+`{{.Label "podlift.version"}}`)
+	output, err := client.Execute(listCmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	var replicas []Replica
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		name := fields[0]
+		if name == "" {
+			continue
+		}
+		label := ""
+		if len(fields) > 1 {
+			label = fields[1]
+		}
+		if label == "" {
+			label = name[strings.LastIndex(name, "-")+1:]
+		}
+		n, err := strconv.Atoi(label)
+		if err != nil {
+			continue
+		}
+		replica := Replica{Container: name, Number: n}
+		if len(fields) > 2 {
+			replica.Version = fields[2]
+		}
+		replicas = append(replicas, replica)
+	}
+	return replicas, nil
+}
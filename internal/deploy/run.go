@@ -0,0 +1,150 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/docker"
+	"github.com/ekinertac/podlift/internal/shell"
+	"github.com/ekinertac/podlift/internal/ssh"
+)
+
+// RunningRelease is the release of the app running on a server
+type RunningRelease struct {
+	Version string
+	Image   string
+	ImageID string
+}
+
+// CurrentRelease returns the release of the newest running container of the app
+func CurrentRelease(cfg *config.Config, client ssh.SSHClient) (RunningRelease, error) {
+	listCmd := shell.Join("sudo", "docker", "ps", "--filter", "label=podlift.service="+cfg.Service, "--filter", "label=podlift.container_type",
+		"--format", `{{.Label "podlift.version"}}`+"\t"+`{{.Label "podlift.image"}}`+"\t"+`{{.Label "podlift.image_id"}}`)
+	output, err := client.Execute(listCmd)
+	if err != nil {
+		return RunningRelease{}, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	// docker ps lists the newest container first
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) < 2 || fields[0] == "" || fields[1] == "" {
+			continue
+		}
+		rel := RunningRelease{Version: fields[0], Image: fields[1]}
+		if len(fields) > 2 {
+			rel.ImageID = fields[2]
+		}
+		return rel, nil
+	}
+	return RunningRelease{}, fmt.Errorf("%s is not running on this server (deploy first)", cfg.Service)
+}
+
+// TaskOptions configures a one-off task container
+type TaskOptions struct {
+	Service   string           // Service whose config the task runs with
+	Command   []string         // Replaces the service's command; empty runs the service's command
+	Detach    bool             // Start in the background and return
+	Interrupt <-chan os.Signal // Stops the task in the foreground, e.g. on Ctrl-C without a terminal
+}
+
+// taskContainerConfig returns the config of a one-off task container for a release
+// It's the service's container config without published ports, restart policy or
+// network alias, so it never receives traffic meant for the service.
+func taskContainerConfig(cfg *config.Config, rel RunningRelease, opts TaskOptions, envFiles []string, now time.Time) docker.ContainerConfig {
+	containerCfg := serviceContainerConfig(cfg, opts.Service, rel.Version, rel.Image, rel.ImageID, envFiles)
+	containerCfg.Name = fmt.Sprintf("%s-%s-run-%s", cfg.Service, opts.Service, now.UTC().Format("20060102-150405"))
+	containerCfg.Labels = map[string]string{
+		"podlift.run":            cfg.Service,
+		"podlift.container_type": opts.Service,
+		"podlift.version":        rel.Version,
+	}
+	containerCfg.Restart = ""
+	containerCfg.NetworkAliases = nil
+	containerCfg.Foreground = !opts.Detach
+	containerCfg.Remove = true
+
+	// docker refuses --rm with a restart policy; ports would clash with the service's
+	options := make(map[string]string, len(containerCfg.Options))
+	for key, value := range containerCfg.Options {
+		switch key {
+		case "restart", "publish", "p", "network-alias":
+			continue
+		}
+		options[key] = value
+	}
+	containerCfg.Options = options
+
+	if len(opts.Command) > 0 {
+		containerCfg.Command = shell.Join(opts.Command...)
+	}
+	return containerCfg
+}
+
+// TaskResult is the outcome of a one-off task
+type TaskResult struct {
+	Container string
+	ExitCode  int // Exit code of the task in the foreground; docker's own errors are 125
+}
+
+// RunTask runs a one-off task in a fresh container from the release on the connected server
+// The task gets the service's env file, volumes and limits. In the foreground it's attached to
+// streams: stdin is forwarded, with a terminal when streams.TTY is set, and a non-zero exit
+// code is a result, not an error.
+func RunTask(cfg *config.Config, client ssh.SSHClient, opts TaskOptions, streams ssh.InteractiveOptions) (TaskResult, error) {
+	if _, ok := cfg.Services[opts.Service]; !ok {
+		return TaskResult{}, fmt.Errorf("unknown service '%s'", opts.Service)
+	}
+
+	rel, err := CurrentRelease(cfg, client)
+	if err != nil {
+		return TaskResult{}, err
+	}
+
+	// The env file the release was started with, so the task sees the same env
+	var envFiles []string
+	envFile := docker.EnvFilePath(cfg.Service, opts.Service, rel.Version)
+	if _, err := client.Execute(shell.Join("sudo", "test", "-f", envFile)); err == nil {
+		envFiles = []string{envFile}
+	}
+
+	containerCfg := taskContainerConfig(cfg, rel, opts, envFiles, time.Now())
+	result := TaskResult{Container: containerCfg.Name}
+
+	if opts.Detach {
+		if output, err := client.Execute(docker.GenerateRunCommand(containerCfg)); err != nil {
+			return result, fmt.Errorf("failed to start %s: %w\n%s", containerCfg.Name, err, output)
+		}
+		return result, nil
+	}
+
+	// Shells and REPLs need stdin, and a terminal when there is one
+	containerCfg.Interactive = true
+	containerCfg.TTY = streams.TTY
+	runCmd := docker.GenerateRunCommand(containerCfg)
+
+	// Without a terminal, Ctrl-C doesn't reach the remote docker client: stop the container instead
+	if opts.Interrupt != nil {
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-opts.Interrupt:
+				client.Execute(docker.GenerateStopCommand(containerCfg.Name))
+			case <-done:
+			}
+		}()
+	}
+
+	if err := client.ExecuteInteractive(runCmd, streams); err != nil {
+		code, ok := ssh.ExitStatus(err)
+		if !ok {
+			return result, fmt.Errorf("failed to run %s: %w", containerCfg.Name, err)
+		}
+		result.ExitCode = code
+	}
+	return result, nil
+}
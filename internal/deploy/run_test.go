@@ -0,0 +1,208 @@
+package deploy
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/docker"
+	"github.com/ekinertac/podlift/internal/ssh"
+)
+
+// exitError mimics the exit status error of an SSH session
+type exitError struct{ status int }
+
+func (e *exitError) Error() string   { return "Process exited with status" }
+func (e *exitError) ExitStatus() int { return e.status }
+
+func runTestConfig() *config.Config {
+	return &config.Config{
+		Service: "myapp",
+		Services: map[string]config.Service{
+			"web": {
+				Replicas: 2,
+				Port:     8000,
+				Command:  "gunicorn app:app",
+				Restart:  config.DefaultRestartPolicy,
+				Volumes:  []string{"media:/app/media"},
+				Options:  map[string]string{"memory": "512m", "publish": "9000:9000"},
+			},
+		},
+	}
+}
+
+func TestTaskContainerConfig(t *testing.T) {
+	cfg := runTestConfig()
+	rel := RunningRelease{Version: "abc123", Image: "myapp:abc123", ImageID: "sha256:1234"}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	opts := TaskOptions{Service: "web", Command: []string{"python", "manage.py", "migrate"}}
+	containerCfg := taskContainerConfig(cfg, rel, opts, []string{"/opt/podlift/myapp/web-abc123.env"}, now)
+
+	if containerCfg.Name != "myapp-web-run-20240101-120000" {
+		t.Errorf("Name = %q", containerCfg.Name)
+	}
+	if containerCfg.Labels["podlift.service"] != "" {
+		t.Errorf("task container must not be listed as a service replica")
+	}
+
+	runCmd := docker.GenerateRunCommand(containerCfg)
+	for _, want := range []string{"--rm", "--env-file /opt/podlift/myapp/web-abc123.env", "media:/app/media", "--memory=512m", "myapp:abc123 python manage.py migrate"} {
+		if !strings.Contains(runCmd, want) {
+			t.Errorf("run command %q should contain %q", runCmd, want)
+		}
+	}
+	for _, unwanted := range []string{" -d ", "--restart", "-p ", "--publish", "--network-alias", "gunicorn"} {
+		if strings.Contains(runCmd, unwanted) {
+			t.Errorf("run command %q should not contain %q", runCmd, unwanted)
+		}
+	}
+
+	// Without a command the service's command runs
+	containerCfg = taskContainerConfig(cfg, rel, TaskOptions{Service: "web", Detach: true}, nil, now)
+	if containerCfg.Command != "gunicorn app:app" || containerCfg.Foreground {
+		t.Errorf("Command = %q, Foreground = %v", containerCfg.Command, containerCfg.Foreground)
+	}
+}
+
+func TestCurrentRelease(t *testing.T) {
+	cfg := runTestConfig()
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		return "def456\tmyapp:def456\tsha256:5678\nabc123\tmyapp:abc123\tsha256:1234\n", nil
+	}
+
+	rel, err := CurrentRelease(cfg, client)
+	if err != nil {
+		t.Fatalf("CurrentRelease() error = %v", err)
+	}
+	want := RunningRelease{Version: "def456", Image: "myapp:def456", ImageID: "sha256:5678"}
+	if rel != want {
+		t.Errorf("CurrentRelease() = %+v, want %+v", rel, want)
+	}
+
+	client.ExecuteFunc = func(cmd string) (string, error) { return "\n", nil }
+	if _, err := CurrentRelease(cfg, client); err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Errorf("CurrentRelease() error = %v, want not running", err)
+	}
+}
+
+func TestRunTask(t *testing.T) {
+	cfg := runTestConfig()
+	discard := ssh.InteractiveOptions{Stdout: io.Discard, Stderr: io.Discard}
+
+	newClient := func(runErr error) (*ssh.MockClient, *[]string) {
+		var commands []string
+		client := ssh.NewMockClient()
+		client.ExecuteFunc = func(cmd string) (string, error) {
+			commands = append(commands, cmd)
+			if strings.Contains(cmd, "docker ps") {
+				return "abc123\tmyapp:abc123\tsha256:1234\n", nil
+			}
+			if strings.Contains(cmd, "test -f") {
+				return "", errors.New("exit status 1")
+			}
+			return "", nil
+		}
+		client.ExecuteInteractiveFunc = func(cmd string, streams ssh.InteractiveOptions) error {
+			commands = append(commands, cmd)
+			io.WriteString(streams.Stdout, "Applying migrations... OK\n")
+			return runErr
+		}
+		return client, &commands
+	}
+
+	t.Run("foreground", func(t *testing.T) {
+		client, commands := newClient(nil)
+		var stdout bytes.Buffer
+		result, err := RunTask(cfg, client, TaskOptions{Service: "web", Command: []string{"python", "manage.py", "migrate"}}, ssh.InteractiveOptions{Stdout: &stdout, Stderr: io.Discard})
+		if err != nil || result.ExitCode != 0 {
+			t.Fatalf("RunTask() = %+v, %v", result, err)
+		}
+		if !strings.Contains(stdout.String(), "Applying migrations") {
+			t.Errorf("output should be streamed, got %q", stdout.String())
+		}
+		last := (*commands)[len(*commands)-1]
+		if !strings.HasPrefix(last, "sudo docker run -i --rm --name myapp-web-run-") || strings.Contains(last, "--env-file") {
+			t.Errorf("run command = %q", last)
+		}
+	})
+
+	t.Run("terminal", func(t *testing.T) {
+		client, commands := newClient(nil)
+		streams := discard
+		streams.TTY = true
+		if _, err := RunTask(cfg, client, TaskOptions{Service: "web", Command: []string{"bash"}}, streams); err != nil {
+			t.Fatalf("RunTask() error = %v", err)
+		}
+		last := (*commands)[len(*commands)-1]
+		if !strings.HasPrefix(last, "sudo docker run -it --rm --name myapp-web-run-") || !strings.HasSuffix(last, " bash") {
+			t.Errorf("run command = %q, want a terminal", last)
+		}
+	})
+
+	t.Run("interrupt", func(t *testing.T) {
+		client, commands := newClient(nil)
+		interrupt := make(chan os.Signal, 1)
+		stopped := make(chan string, 1)
+		execute := client.ExecuteFunc
+		client.ExecuteFunc = func(cmd string) (string, error) {
+			if strings.Contains(cmd, "docker stop") {
+				stopped <- cmd
+				return "", nil
+			}
+			return execute(cmd)
+		}
+		// The task runs until its container is stopped
+		client.ExecuteInteractiveFunc = func(cmd string, streams ssh.InteractiveOptions) error {
+			*commands = append(*commands, cmd)
+			interrupt <- os.Interrupt
+			<-stopped
+			return &exitError{status: 143}
+		}
+
+		result, err := RunTask(cfg, client, TaskOptions{Service: "web", Command: []string{"sleep", "600"}, Interrupt: interrupt}, discard)
+		if err != nil || result.ExitCode != 143 {
+			t.Errorf("RunTask() = %+v, %v, want exit code 143 after the container is stopped", result, err)
+		}
+	})
+
+	t.Run("exit status", func(t *testing.T) {
+		client, _ := newClient(&exitError{status: 3})
+		result, err := RunTask(cfg, client, TaskOptions{Service: "web", Command: []string{"false"}}, discard)
+		if err != nil || result.ExitCode != 3 {
+			t.Errorf("RunTask() = %+v, %v, want exit code 3", result, err)
+		}
+	})
+
+	t.Run("connection error", func(t *testing.T) {
+		client, _ := newClient(errors.New("connection reset"))
+		if _, err := RunTask(cfg, client, TaskOptions{Service: "web"}, discard); err == nil {
+			t.Error("RunTask() should fail when the command doesn't run to an exit status")
+		}
+	})
+
+	t.Run("detach", func(t *testing.T) {
+		client, commands := newClient(nil)
+		result, err := RunTask(cfg, client, TaskOptions{Service: "web", Detach: true}, discard)
+		if err != nil {
+			t.Fatalf("RunTask() error = %v", err)
+		}
+		last := (*commands)[len(*commands)-1]
+		if !strings.HasPrefix(last, "sudo docker run -d --rm --name "+result.Container) {
+			t.Errorf("run command = %q", last)
+		}
+	})
+
+	t.Run("unknown service", func(t *testing.T) {
+		client, _ := newClient(nil)
+		if _, err := RunTask(cfg, client, TaskOptions{Service: "api"}, discard); err == nil {
+			t.Error("RunTask() should reject an unknown service")
+		}
+	})
+}
@@ -2,10 +2,12 @@ package deploy
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/ekinertac/podlift/internal/config"
 	"github.com/ekinertac/podlift/internal/docker"
 	"github.com/ekinertac/podlift/internal/registry"
+	"github.com/ekinertac/podlift/internal/shell"
 	"github.com/ekinertac/podlift/internal/ssh"
 	"github.com/ekinertac/podlift/internal/ui"
 )
@@ -69,10 +71,79 @@ func transferImage(client ssh.SSHClient, host string, cfg *config.Config, versio
 		}
 
 		// Remove tar file
-		client.Execute(fmt.Sprintf("rm %s", remoteTarPath))
+		client.Execute(shell.Join("rm", remoteTarPath))
 	}
 
 	fmt.Println(ui.Success("Image loaded"))
 	return nil
 }
 
+
+// pullPrebuiltImage pulls a prebuilt image on the server
+// The first pull resolves the repository digest, which pins the image
+// (and the release version) for every following server
+func pullPrebuiltImage(client ssh.SSHClient, cfg *config.Config, rel *release, opts DeployOptions) error {
+	fmt.Println(ui.Info(fmt.Sprintf("Pulling %s...", rel.Image)))
+
+	if opts.DryRun {
+		fmt.Println(ui.Code("  " + docker.GeneratePullCommand(rel.Image)))
+		return nil
+	}
+
+	// Login on server if a registry is configured (private images)
+	if registry.IsConfigured(cfg) {
+		regClient := registry.NewClient(cfg.Registry)
+		if err := regClient.LoginRemote(client); err != nil {
+			return err
+		}
+	}
+
+	output, err := client.Execute(docker.GeneratePullCommand(rel.Image))
+	if err != nil {
+		return fmt.Errorf("failed to pull image: %w\nOutput: %s", err, output)
+	}
+
+	// Resolve digest once, then pin all servers to it
+	if rel.Version == "" {
+		digests, err := client.Execute(docker.GenerateRepoDigestsCommand(rel.Image))
+		if err != nil {
+			return fmt.Errorf("failed to inspect image: %w", err)
+		}
+
+		ref := docker.ParseImageReference(rel.Image)
+		digest, err := docker.FindRepoDigest(digests, ref.Repository)
+		if err != nil {
+			return err
+		}
+
+		rel.Image = ref.WithDigest(digest).String()
+		rel.Version = docker.ShortDigest(digest)
+		fmt.Println(ui.Info(fmt.Sprintf("  Resolved digest: %s", digest)))
+	}
+
+	fmt.Println(ui.Success("Image pulled"))
+	return nil
+}
+
+// verifyImage checks the image on the server matches the recorded image ID
+// If no ID is recorded yet (prebuilt images), the first server's ID becomes the reference
+func verifyImage(client ssh.SSHClient, rel *release) error {
+	output, err := client.Execute(docker.GenerateImageIDCommand(rel.Image))
+	if err != nil {
+		return fmt.Errorf("failed to inspect image %s: %w", rel.Image, err)
+	}
+
+	imageID := strings.TrimSpace(output)
+	if imageID == "" {
+		return fmt.Errorf("image %s not found on server", rel.Image)
+	}
+
+	if rel.ImageID == "" {
+		rel.ImageID = imageID
+	} else if imageID != rel.ImageID {
+		return fmt.Errorf("image digest mismatch for %s: expected %s, server has %s", rel.Image, rel.ImageID, imageID)
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Image verified (%s)", docker.ShortDigest(imageID))))
+	return nil
+}
@@ -0,0 +1,101 @@
+package deploy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/docker"
+	"github.com/ekinertac/podlift/internal/shell"
+	"github.com/ekinertac/podlift/internal/ssh"
+	"github.com/ekinertac/podlift/internal/ui"
+)
+
+// UpgradeDependency replaces a dependency container with one created from the current config
+// The named volume is kept. If the new container doesn't pass its health probe, the old one is restored.
+func UpgradeDependency(cfg *config.Config, client ssh.SSHClient, name string) error {
+	containerName := dependencyContainerName(cfg, name)
+	previous := containerName + "-previous"
+	dep := cfg.Dependencies[name]
+
+	state := dependencyState(client, containerName)
+	if state == "" {
+		return StartDependencies(cfg, client, []string{name}, DependencyOptions{})
+	}
+
+	// Pull first, so the dependency is only down while the containers swap
+	fmt.Println(ui.Info(fmt.Sprintf("Pulling %s...", dep.Image)))
+	if output, err := client.Execute(shell.Join("sudo", "docker", "pull", dep.Image)); err != nil {
+		return fmt.Errorf("failed to pull %s: %w\n%s", dep.Image, err, output)
+	}
+
+	restartPolicy, _ := client.Execute(shell.Join("sudo", "docker", "inspect", "--format", "{{.HostConfig.RestartPolicy.Name}}", containerName))
+	restartPolicy = strings.TrimSpace(restartPolicy)
+
+	// The old container must be stopped before the new one opens the same volume,
+	// and must not come back on a reboot while it's kept as a fallback
+	fmt.Println(ui.Info(fmt.Sprintf("Stopping %s...", containerName)))
+	client.Execute(shell.Join("sudo", "docker", "rm", "-f", previous) + " 2>/dev/null || true")
+	stopCmd := strings.Join([]string{
+		shell.Join("sudo", "docker", "stop", containerName),
+		docker.GenerateUpdateRestartCommand("no", containerName),
+		shell.Join("sudo", "docker", "rename", containerName, previous),
+	}, " && ")
+	if output, err := client.Execute(stopCmd); err != nil {
+		return fmt.Errorf("failed to stop %s: %w\n%s", containerName, err, output)
+	}
+
+	fmt.Println(ui.Info(fmt.Sprintf("Starting %s...", dep.Image)))
+	err := createDependency(cfg, client, name, "")
+	if err == nil {
+		err = waitForDependency(client, cfg, name)
+	}
+	if err != nil {
+		fmt.Println(ui.Warning(fmt.Sprintf("%s: %v, restoring the previous container", name, err)))
+		if restoreErr := restoreDependency(client, containerName, previous, restartPolicy); restoreErr != nil {
+			return fmt.Errorf("upgrade failed (%v) and restore failed: %w", err, restoreErr)
+		}
+		return fmt.Errorf("upgrade of %s failed: %w (previous container restored)", name, err)
+	}
+
+	client.Execute(shell.Join("sudo", "docker", "rm", previous))
+	fmt.Println(ui.Success(fmt.Sprintf("%s upgraded to %s", name, dep.Image)))
+	return nil
+}
+
+// restoreDependency removes a failed new container and brings back the previous one
+func restoreDependency(client ssh.SSHClient, containerName, previous, restartPolicy string) error {
+	client.Execute(shell.Join("sudo", "docker", "rm", "-f", containerName) + " 2>/dev/null || true")
+
+	restoreCmd := shell.Join("sudo", "docker", "rename", previous, containerName)
+	if restartPolicy != "" {
+		restoreCmd += " && " + docker.GenerateUpdateRestartCommand(restartPolicy, containerName)
+	}
+	restoreCmd += " && " + shell.Join("sudo", "docker", "start", containerName)
+
+	if output, err := client.Execute(restoreCmd); err != nil {
+		return fmt.Errorf("%w\n%s", err, output)
+	}
+	return nil
+}
+
+// IsMajorUpgrade reports whether two references of the same image differ in major version
+// e.g. postgres:15 → postgres:16.1 is; postgres:16 → postgres:16.2-alpine and mysql → mariadb aren't.
+func IsMajorUpgrade(oldImage, newImage string) bool {
+	oldRepo, oldMajor := imageMajorVersion(oldImage)
+	newRepo, newMajor := imageMajorVersion(newImage)
+	return oldRepo == newRepo && oldMajor != "" && newMajor != "" && oldMajor != newMajor
+}
+
+// imageMajorVersion splits an image into repository and the leading number of its tag
+// e.g. postgres:16.2-alpine → postgres, 16. The major is empty for tags like latest.
+func imageMajorVersion(image string) (repo, major string) {
+	ref := docker.ParseImageReference(image)
+	tag := strings.TrimPrefix(ref.Tag, "v")
+
+	end := 0
+	for end < len(tag) && tag[end] >= '0' && tag[end] <= '9' {
+		end++
+	}
+	return ref.Repository, strings.TrimLeft(tag[:end], "0")
+}
@@ -2,12 +2,14 @@ package deploy
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ekinertac/podlift/internal/config"
 	"github.com/ekinertac/podlift/internal/docker"
 	"github.com/ekinertac/podlift/internal/nginx"
+	"github.com/ekinertac/podlift/internal/shell"
 	"github.com/ekinertac/podlift/internal/ssh"
 	"github.com/ekinertac/podlift/internal/ssl"
 	"github.com/ekinertac/podlift/internal/ui"
@@ -17,17 +19,31 @@ import (
 type ZeroDowntimeDeployOptions struct {
 	Config      *config.Config
 	Version     string
+	Image       string // Image reference to run (defaults to image:version)
+	ImageID     string // Verified image digest, recorded in container labels
 	ImagePath   string
 	SSHClient   ssh.SSHClient
 	Server      config.Server
+	DryRun      bool // Print the commands, change nothing on the server
 }
 
+// Pauses of a zero-downtime deploy (variables so tests can skip them)
+var (
+	zeroDowntimeStartDelay = 3 * time.Second // Before health checking new containers
+	zeroDowntimeDrainDelay = 5 * time.Second // For nginx to finish requests to old containers
+)
+
 // ZeroDowntimeDeploy performs zero-downtime deployment with nginx
 func ZeroDowntimeDeploy(opts ZeroDowntimeDeployOptions) error {
 	cfg := opts.Config
 	version := opts.Version
 	client := opts.SSHClient
 
+	image := opts.Image
+	if image == "" {
+		image = fmt.Sprintf("%s:%s", cfg.Image, version)
+	}
+
 	fmt.Println(ui.Info("Starting zero-downtime deployment..."))
 
 	// Step 1: Get existing containers
@@ -35,7 +51,9 @@ func ZeroDowntimeDeploy(opts ZeroDowntimeDeployOptions) error {
 	var oldContainers []string
 	if existing {
 		// Will stop these later
-		psCmd := fmt.Sprintf(`sudo docker ps --filter "label=podlift.service=%s" --format "{{.Names}}"`, cfg.Service)
+		// Only app containers: dependencies share the service label but outlive releases
+		// Exited ones too, so finished jobs of the old release don't pile up (and keep their env files)
+		psCmd := shell.Join("sudo", "docker", "ps", "-a", "--filter", "label=podlift.service="+cfg.Service, "--filter", "label=podlift.container_type", "--format", "{{.Names}}")
 		output, _ := client.Execute(psCmd)
 		if output != "" {
 			for _, name := range strings.Split(strings.TrimSpace(output), "\n") {
@@ -59,34 +77,107 @@ func ZeroDowntimeDeploy(opts ZeroDowntimeDeployOptions) error {
 		tempPortStart = 9100
 	}
 
-	for serviceName, service := range cfg.Services {
+	tempPorts := serviceTempPorts(cfg, tempPortStart)
+
+	// Health check of a service's first new container, also used for depends_on condition: healthy
+	checkHealth := func(serviceName string) error {
+		service := cfg.Services[serviceName]
+		if service.Healthcheck == nil || (service.Healthcheck.Enabled != nil && !*service.Healthcheck.Enabled) {
+			return nil
+		}
+
+		healthPath := service.Healthcheck.Path
+		if healthPath == "" {
+			healthPath = "/health"
+		}
+
+		// Check first new container of the service
+		firstPort := tempPorts[serviceName]
+
+		healthCfg := docker.HealthCheckConfig{
+			URL:      fmt.Sprintf("http://%s:%d%s", opts.Server.Host, firstPort, healthPath),
+			Expect:   service.Healthcheck.Expect,
+			Timeout:  5 * time.Second,
+			Interval: 2 * time.Second,
+			Retries:  15,
+		}
+
+		var err error
+		if service.Healthcheck.Path == "" && service.Healthcheck.Command != "" {
+			// Only a docker healthcheck is configured: wait for docker's verdict
+			err = waitForContainerHealth(client, fmt.Sprintf("%s-%s-%s-1", cfg.Service, serviceName, version), serviceName, 60)
+		} else {
+			err = docker.CheckHealth(healthCfg)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(ui.Success("  New containers healthy"))
+		return nil
+	}
+	waiter := newDependsOnWaiter(client, cfg, version, checkHealth)
+
+	// Rollback: stop new containers, including ones that don't serve traffic
+	var started []string
+	rollback := func() {
+		if len(started) == 0 {
+			return
+		}
+		fmt.Println(ui.Warning("Rolling back (stopping new containers)..."))
+		for _, containerName := range started {
+			client.Execute(docker.GenerateStopAndRemoveCommand(containerName))
+		}
+	}
+
+	for _, serviceName := range cfg.ServiceOrder() {
+		service := cfg.Services[serviceName]
+		if !opts.DryRun {
+			if err := waiter.wait(serviceName); err != nil {
+				rollback()
+				return err
+			}
+		}
+
+		env, err := serviceEnv(cfg, opts.Server, service)
+		if err != nil {
+			rollback()
+			return err
+		}
+
+		// Environment goes to a root-only env file, not the command line
+		envFiles, err := writeEnvFile(client, docker.EnvFilePath(cfg.Service, serviceName, version), env, opts.DryRun)
+		if err != nil {
+			rollback()
+			return err
+		}
+
 		for replica := 1; replica <= service.Replicas; replica++ {
 			containerName := fmt.Sprintf("%s-%s-%s-%d", cfg.Service, serviceName, version, replica)
-			tempPort := tempPortStart + replica - 1
-
-			containerCfg := docker.ContainerConfig{
-				Name:         containerName,
-				Image:        fmt.Sprintf("%s:%s", cfg.Image, version),
-				Port:         tempPort,
-				InternalPort: service.Port,
-				Env:          service.Env,
-				Labels: map[string]string{
-					"podlift.service":       cfg.Service,
-					"podlift.version":       version,
-					"podlift.deployed_at":   time.Now().Format(time.RFC3339),
-					"podlift.container_type": serviceName,
-				},
-				Command: service.Command,
-				Volumes: service.Volumes,
-			}
+			tempPort := tempPorts[serviceName] + replica - 1
+
+			containerCfg := serviceContainerConfig(cfg, serviceName, version, image, opts.ImageID, envFiles)
+			containerCfg.Name = containerName
+			containerCfg.Labels["podlift.replica"] = strconv.Itoa(replica)
+			containerCfg.Port = tempPort
 
 			runCmd := docker.GenerateRunCommand(containerCfg)
+			if opts.DryRun {
+				fmt.Println(ui.Code("  " + runCmd))
+				continue
+			}
 			if _, err := client.Execute(runCmd); err != nil {
+				rollback()
 				return fmt.Errorf("failed to start container %s: %w", containerName, err)
 			}
+			started = append(started, containerName)
 
 			fmt.Println(ui.Success(fmt.Sprintf("  %s started on :%d", containerName, tempPort)))
 
+			if cfg.IsJob(serviceName) {
+				continue // One-off jobs don't serve traffic
+			}
+
 			// Add to upstream list
 			newUpstreams = append(newUpstreams, nginx.Upstream{
 				Name: containerName,
@@ -96,44 +187,25 @@ func ZeroDowntimeDeploy(opts ZeroDowntimeDeployOptions) error {
 		}
 	}
 
+	if opts.DryRun {
+		fmt.Println(ui.Info(fmt.Sprintf("Would switch nginx to the new containers and stop %d old container(s)", len(oldContainers))))
+		return nil
+	}
+
 	// Step 3: Wait and health check new containers
 	fmt.Println(ui.Info("Health checking new containers..."))
-	time.Sleep(3 * time.Second)
-
-	for _, service := range cfg.Services {
-		if service.Healthcheck == nil || (service.Healthcheck.Enabled != nil && !*service.Healthcheck.Enabled) {
-			continue
-		}
+	time.Sleep(zeroDowntimeStartDelay)
 
-		healthPath := service.Healthcheck.Path
-		if healthPath == "" {
-			healthPath = "/health"
+	for _, serviceName := range cfg.ServiceOrder() {
+		if waiter.checked(serviceName) || cfg.IsJob(serviceName) {
+			continue // Checked for a depends_on condition, or a one-off job that already exited
 		}
 
-		// Check first new container
-		firstPort := tempPortStart
-
-		healthCfg := docker.HealthCheckConfig{
-			URL:      fmt.Sprintf("http://%s:%d%s", opts.Server.Host, firstPort, healthPath),
-			Expect:   service.Healthcheck.Expect,
-			Timeout:  5 * time.Second,
-			Interval: 2 * time.Second,
-			Retries:  15,
-		}
-
-		if err := docker.CheckHealth(healthCfg); err != nil {
+		if err := checkHealth(serviceName); err != nil {
 			fmt.Println(ui.Error("Health check failed on new containers"))
-			fmt.Println(ui.Warning("Rolling back (stopping new containers)..."))
-			
-			// Rollback: stop new containers
-			for _, upstream := range newUpstreams {
-				client.Execute(fmt.Sprintf("sudo docker stop %s && sudo docker rm %s", upstream.Name, upstream.Name))
-			}
-			
+			rollback()
 			return fmt.Errorf("health check failed: %w", err)
 		}
-
-		fmt.Println(ui.Success("  New containers healthy"))
 	}
 
 	// Step 4: Update nginx upstream
@@ -144,6 +216,7 @@ func ZeroDowntimeDeploy(opts ZeroDowntimeDeployOptions) error {
 	// Ensure nginx is installed
 	if installed, _ := nginxMgr.IsInstalled(); !installed {
 		if err := nginxMgr.Install(); err != nil {
+			rollback()
 			return err
 		}
 	}
@@ -175,6 +248,7 @@ func ZeroDowntimeDeploy(opts ZeroDowntimeDeployOptions) error {
 	}
 
 	if err := nginxMgr.UpdateUpstream(cfg.Service, newUpstreams, domain, sslCfg); err != nil {
+		rollback()
 		return fmt.Errorf("failed to update nginx: %w", err)
 	}
 
@@ -182,21 +256,24 @@ func ZeroDowntimeDeploy(opts ZeroDowntimeDeployOptions) error {
 
 	// Step 5: Wait for connection draining (give nginx time to finish old requests)
 	if len(oldContainers) > 0 {
-		fmt.Println(ui.Info("Draining connections (5s)..."))
-		time.Sleep(5 * time.Second)
+		fmt.Println(ui.Info(fmt.Sprintf("Draining connections (%s)...", zeroDowntimeDrainDelay)))
+		time.Sleep(zeroDowntimeDrainDelay)
 	}
 
 	// Step 6: Stop old containers
 	if len(oldContainers) > 0 {
 		fmt.Println(ui.Info("Stopping old containers..."))
 		for _, containerName := range oldContainers {
-			stopCmd := fmt.Sprintf("sudo docker stop %s && sudo docker rm %s", containerName, containerName)
+			stopCmd := docker.GenerateStopAndRemoveCommand(containerName)
 			client.Execute(stopCmd)
 			fmt.Println(ui.Info(fmt.Sprintf("  Stopped %s", containerName)))
 		}
 		fmt.Println(ui.Success("Old containers removed"))
 	}
 
+	// Step 7: Remove env files of releases that no longer have containers
+	cleanupEnvFiles(client, cfg)
+
 	return nil
 }
 
@@ -0,0 +1,211 @@
+package deploy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/ssh"
+)
+
+// zeroDowntimeClient returns a mock server where the previous release and postgres run
+// and the previous release's migrate job has exited.
+// docker ps honors the container_type filter like docker does: dependencies don't have the label.
+func zeroDowntimeClient(t *testing.T, commands *[]string) *ssh.MockClient {
+	startDelay, drainDelay := zeroDowntimeStartDelay, zeroDowntimeDrainDelay
+	zeroDowntimeStartDelay, zeroDowntimeDrainDelay = 0, 0
+	t.Cleanup(func() {
+		zeroDowntimeStartDelay, zeroDowntimeDrainDelay = startDelay, drainDelay
+	})
+
+	client := ssh.NewMockClient()
+	client.CheckExistingServiceFunc = func(string) (bool, *ssh.ServiceInfo, error) {
+		return true, nil, nil
+	}
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		*commands = append(*commands, cmd)
+		if strings.HasPrefix(cmd, "sudo docker ps") && strings.Contains(cmd, "label=podlift.service=myapp") {
+			output := "myapp-web-old123-1\n"
+			if strings.Contains(cmd, " -a ") {
+				output += "myapp-migrate-old123-1\n" // Job of the old release, exited
+			}
+			if !strings.Contains(cmd, "label=podlift.container_type") {
+				output += "myapp-postgres\n"
+			}
+			return output, nil
+		}
+		return "", nil
+	}
+	return client
+}
+
+func TestZeroDowntimeDeploy_KeepsDependencies(t *testing.T) {
+	var commands []string
+	client := zeroDowntimeClient(t, &commands)
+	cfg := &config.Config{
+		Service:      "myapp",
+		Image:        "myapp",
+		Services:     map[string]config.Service{"web": {Replicas: 1, Port: 8000}},
+		Dependencies: map[string]config.Dependency{"postgres": {Image: "postgres:16"}},
+	}
+
+	err := ZeroDowntimeDeploy(ZeroDowntimeDeployOptions{Config: cfg, Version: "new456", SSHClient: client, Server: config.Server{Host: "192.168.1.10"}})
+	if err != nil {
+		t.Fatalf("ZeroDowntimeDeploy() error = %v", err)
+	}
+
+	joined := strings.Join(commands, "\n")
+	if !strings.Contains(joined, "sudo docker stop myapp-web-old123-1") {
+		t.Errorf("old release should be stopped, commands:\n%s", joined)
+	}
+	if strings.Contains(joined, "docker stop myapp-postgres") || strings.Contains(joined, "docker rm myapp-postgres") {
+		t.Errorf("dependency must survive a redeploy, commands:\n%s", joined)
+	}
+}
+
+func TestZeroDowntimeDeploy_RemovesExitedJobs(t *testing.T) {
+	var commands []string
+	client := zeroDowntimeClient(t, &commands)
+	cfg := &config.Config{
+		Service: "myapp",
+		Image:   "myapp",
+		Services: map[string]config.Service{
+			"migrate": {Replicas: 1, Command: "python manage.py migrate", Restart: "no"},
+			"web":     {Replicas: 1, Port: 8000, DependsOn: config.DependsOn{"migrate": {Condition: config.ConditionCompleted}}},
+		},
+	}
+	execute := client.ExecuteFunc
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		if strings.Contains(cmd, "docker wait") {
+			return "0\n", nil // migrate succeeded
+		}
+		return execute(cmd)
+	}
+
+	err := ZeroDowntimeDeploy(ZeroDowntimeDeployOptions{Config: cfg, Version: "new456", SSHClient: client, Server: config.Server{Host: "192.168.1.10"}})
+	if err != nil {
+		t.Fatalf("ZeroDowntimeDeploy() error = %v", err)
+	}
+
+	joined := strings.Join(commands, "\n")
+	if !strings.Contains(joined, "sudo docker rm myapp-migrate-old123-1") {
+		t.Errorf("exited job of the old release should be removed, commands:\n%s", joined)
+	}
+}
+
+// twoServiceConfig has two services started in one deploy: web, then worker
+func twoServiceConfig() *config.Config {
+	return &config.Config{
+		Service: "myapp",
+		Image:   "myapp",
+		Services: map[string]config.Service{
+			"web":    {Replicas: 2, Port: 8000},
+			"worker": {Replicas: 1, Command: "celery worker", Env: map[string]string{"QUEUE": "default"}, DependsOn: config.DependsOn{"web": {}}},
+		},
+	}
+}
+
+func TestZeroDowntimeDeploy_TempPortPerService(t *testing.T) {
+	var commands []string
+	client := zeroDowntimeClient(t, &commands)
+
+	err := ZeroDowntimeDeploy(ZeroDowntimeDeployOptions{Config: twoServiceConfig(), Version: "new456", SSHClient: client, Server: config.Server{Host: "192.168.1.10"}})
+	if err != nil {
+		t.Fatalf("ZeroDowntimeDeploy() error = %v", err)
+	}
+
+	ports := map[string]string{}
+	for _, cmd := range commands {
+		if !strings.HasPrefix(cmd, "sudo docker run") {
+			continue
+		}
+		for _, container := range []string{"myapp-web-new456-1", "myapp-web-new456-2", "myapp-worker-new456-1"} {
+			if strings.Contains(cmd, "--name "+container+" ") {
+				_, rest, _ := strings.Cut(cmd, " -p ")
+				ports[container], _, _ = strings.Cut(rest, ":")
+			}
+		}
+	}
+	want := map[string]string{"myapp-web-new456-1": "9100", "myapp-web-new456-2": "9101", "myapp-worker-new456-1": "9102"}
+	for container, port := range want {
+		if ports[container] != port {
+			t.Errorf("%s published on %q, want %s (all: %v)", container, ports[container], port, ports)
+		}
+	}
+}
+
+func TestZeroDowntimeDeploy_RollsBackEarlierServices(t *testing.T) {
+	tests := []struct {
+		name string
+		fail func(client *ssh.MockClient, commands *[]string)
+	}{
+		{
+			name: "docker run",
+			fail: func(client *ssh.MockClient, commands *[]string) {
+				execute := client.ExecuteFunc
+				client.ExecuteFunc = func(cmd string) (string, error) {
+					if strings.Contains(cmd, "--name myapp-worker-new456-1") {
+						*commands = append(*commands, cmd)
+						return "", errors.New("port is already allocated")
+					}
+					return execute(cmd)
+				}
+			},
+		},
+		{
+			name: "env file",
+			fail: func(client *ssh.MockClient, commands *[]string) {
+				client.WriteFileFunc = func(content, path string) error {
+					if strings.Contains(path, "worker-new456.env") {
+						return errors.New("disk full")
+					}
+					return nil
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commands []string
+			client := zeroDowntimeClient(t, &commands)
+			tt.fail(client, &commands)
+
+			err := ZeroDowntimeDeploy(ZeroDowntimeDeployOptions{Config: twoServiceConfig(), Version: "new456", SSHClient: client, Server: config.Server{Host: "192.168.1.10"}})
+			if err == nil {
+				t.Fatal("ZeroDowntimeDeploy() should fail")
+			}
+
+			joined := strings.Join(commands, "\n")
+			for _, container := range []string{"myapp-web-new456-1", "myapp-web-new456-2"} {
+				if !strings.Contains(joined, "sudo docker stop "+container) {
+					t.Errorf("%s should be stopped on failure, commands:\n%s", container, joined)
+				}
+			}
+			if strings.Contains(joined, "docker stop myapp-web-old123-1") {
+				t.Errorf("old release must keep running, commands:\n%s", joined)
+			}
+		})
+	}
+}
+
+func TestZeroDowntimeDeploy_DryRun(t *testing.T) {
+	var commands []string
+	client := zeroDowntimeClient(t, &commands)
+	client.WriteFileFunc = func(content, path string) error {
+		t.Errorf("dry run wrote %s", path)
+		return nil
+	}
+
+	err := ZeroDowntimeDeploy(ZeroDowntimeDeployOptions{Config: twoServiceConfig(), Version: "new456", SSHClient: client, Server: config.Server{Host: "192.168.1.10"}, DryRun: true})
+	if err != nil {
+		t.Fatalf("ZeroDowntimeDeploy() error = %v", err)
+	}
+
+	for _, cmd := range commands {
+		if !strings.HasPrefix(cmd, "sudo docker ps") {
+			t.Errorf("dry run executed %q", cmd)
+		}
+	}
+}
@@ -2,7 +2,8 @@ package docker
 
 import (
 	"fmt"
-	"strings"
+
+	"github.com/ekinertac/podlift/internal/shell"
 )
 
 // ContainerConfig represents Docker container configuration
@@ -11,19 +12,46 @@ type ContainerConfig struct {
 	Image       string
 	Port        int
 	InternalPort int
-	Env         map[string]string
+	BindAddress string // Host address for the published port (default: all interfaces)
+	EnvFiles    []string // Env files on the server, passed with --env-file
 	Labels      map[string]string
 	Command     string
+	Restart     string // Restart policy, e.g. unless-stopped
+	Network     string
+	NetworkAliases []string // DNS names on Network, e.g. postgres
 	Volumes     []string
-	Options     map[string]string
+	Runtime     RuntimeOptions
+	Options     map[string]string // Raw docker run flags, rendered as --key=value
+	Foreground  bool // Run attached (without -d), e.g. for one-off tasks
+	Interactive bool // Keep stdin open (-i), for attached runs
+	TTY         bool // Allocate a terminal (-t), for attached runs from a terminal
+	Remove      bool // Remove the container when it exits (--rm)
 }
 
 // GenerateRunCommand generates a docker run command
+// Every value is shell-quoted; Command is split into arguments like a shell would.
+// Flags are always rendered in the same order, so the same config gives the same command.
 func GenerateRunCommand(cfg ContainerConfig) string {
-	var parts []string
-	
-	parts = append(parts, "sudo docker run -d")
-	parts = append(parts, fmt.Sprintf("--name %s", cfg.Name))
+	cmd := shell.Command("sudo", "docker", "run")
+	if !cfg.Foreground {
+		cmd.Arg("-d")
+	}
+	switch {
+	case cfg.Interactive && cfg.TTY:
+		cmd.Arg("-it")
+	case cfg.Interactive:
+		cmd.Arg("-i")
+	case cfg.TTY:
+		cmd.Arg("-t")
+	}
+	if cfg.Remove {
+		cmd.Arg("--rm")
+	}
+	cmd.Flag("--name", cfg.Name)
+
+	if cfg.Restart != "" {
+		cmd.Flag("--restart", cfg.Restart)
+	}
 
 	// Port mapping
 	if cfg.Port > 0 {
@@ -31,59 +59,88 @@ func GenerateRunCommand(cfg ContainerConfig) string {
 		if internalPort == 0 {
 			internalPort = cfg.Port
 		}
-		parts = append(parts, fmt.Sprintf("-p %d:%d", cfg.Port, internalPort))
+		mapping := fmt.Sprintf("%d:%d", cfg.Port, internalPort)
+		if cfg.BindAddress != "" {
+			mapping = cfg.BindAddress + ":" + mapping
+		}
+		cmd.Flag("-p", mapping)
 	}
 
-	// Environment variables
-	for key, value := range cfg.Env {
-		// Escape value for shell
-		escapedValue := strings.ReplaceAll(value, `"`, `\"`)
-		parts = append(parts, fmt.Sprintf(`-e %s="%s"`, key, escapedValue))
+	// Private network
+	if cfg.Network != "" {
+		cmd.Flag("--network", cfg.Network)
+		for _, alias := range cfg.NetworkAliases {
+			cmd.Flag("--network-alias", alias)
+		}
+	}
+
+	// Environment variables (from env files, so values never appear on the command line)
+	for _, envFile := range cfg.EnvFiles {
+		cmd.Flag("--env-file", envFile)
 	}
 
 	// Labels
-	for key, value := range cfg.Labels {
-		parts = append(parts, fmt.Sprintf(`--label %s=%s`, key, value))
+	for _, key := range sortedKeys(cfg.Labels) {
+		cmd.Flag("--label", key+"="+cfg.Labels[key])
 	}
 
 	// Volumes
 	for _, vol := range cfg.Volumes {
-		parts = append(parts, fmt.Sprintf("-v %s", vol))
+		cmd.Flag("-v", vol)
 	}
 
+	// Resource limits and runtime options
+	cmd.Arg(cfg.Runtime.Args()...)
+
 	// Custom options
-	for key, value := range cfg.Options {
+	for _, key := range sortedKeys(cfg.Options) {
+		value := cfg.Options[key]
 		if value == "" {
-			parts = append(parts, fmt.Sprintf("--%s", key))
+			cmd.Arg("--" + key)
 		} else {
-			parts = append(parts, fmt.Sprintf("--%s=%s", key, value))
+			cmd.Arg(fmt.Sprintf("--%s=%s", key, value))
 		}
 	}
 
 	// Image
-	parts = append(parts, cfg.Image)
+	cmd.Arg(cfg.Image)
 
 	// Command (optional)
 	if cfg.Command != "" {
-		parts = append(parts, cfg.Command)
+		args, err := shell.Split(cfg.Command)
+		if err != nil {
+			// Config validation rejects these; never let a malformed command reach the shell unquoted
+			args = []string{cfg.Command}
+		}
+		cmd.Arg(args...)
 	}
 
-	return strings.Join(parts, " ")
+	return cmd.String()
 }
 
 // GenerateLoadCommand generates command to load Docker image from tar
 func GenerateLoadCommand(tarPath string) string {
-	return fmt.Sprintf("sudo docker load -i %s", tarPath)
+	return shell.Join("sudo", "docker", "load", "-i", tarPath)
 }
 
 // GenerateStopCommand generates command to stop container
 func GenerateStopCommand(containerName string) string {
-	return fmt.Sprintf("docker stop %s", containerName)
+	return shell.Join("docker", "stop", containerName)
 }
 
 // GenerateRemoveCommand generates command to remove container
 func GenerateRemoveCommand(containerName string) string {
-	return fmt.Sprintf("docker rm %s", containerName)
+	return shell.Join("docker", "rm", containerName)
+}
+
+// GenerateStopAndRemoveCommand generates command to stop and remove a container
+func GenerateStopAndRemoveCommand(containerName string) string {
+	return shell.Join("sudo", "docker", "stop", containerName) + " && " + shell.Join("sudo", "docker", "rm", containerName)
+}
+
+// GenerateUpdateRestartCommand generates command to change the restart policy of containers
+func GenerateUpdateRestartCommand(policy string, containerNames ...string) string {
+	return shell.Command("sudo", "docker", "update", "--restart", policy).Arg(containerNames...).String()
 }
 
 // GenerateLogsCommand generates command to view logs
@@ -98,13 +155,13 @@ func GenerateLogsCommand(containerName string, tail int, follow bool) string {
 		cmd += " -f"
 	}
 	
-	cmd += " " + containerName
+	cmd += " " + shell.Quote(containerName)
 	
 	return cmd
 }
 
 // GeneratePsCommand generates command to list containers
 func GeneratePsCommand(serviceName string) string {
-	return fmt.Sprintf(`docker ps --filter "label=podlift.service=%s" --format "{{.Names}}\t{{.Status}}\t{{.Label \"podlift.version\"}}"`, serviceName)
+	return "docker ps --filter " + shell.Quote("label=podlift.service="+serviceName) + ` --format "{{.Names}}\t{{.Status}}\t{{.Label \"podlift.version\"}}"`
 }
 
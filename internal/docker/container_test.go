@@ -1,8 +1,11 @@
 package docker
 
 import (
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/ekinertac/podlift/internal/shell"
 )
 
 func TestGenerateRunCommand(t *testing.T) {
@@ -26,18 +29,14 @@ func TestGenerateRunCommand(t *testing.T) {
 			},
 		},
 		{
-			name: "with environment variables",
+			name: "with env files",
 			config: ContainerConfig{
-				Name:  "myapp-web-1",
-				Image: "myapp:abc123",
-				Env: map[string]string{
-					"SECRET_KEY": "test123",
-					"DEBUG":      "false",
-				},
+				Name:     "myapp-web-1",
+				Image:    "myapp:abc123",
+				EnvFiles: []string{"/etc/podlift/myapp/env/web-abc123.env"},
 			},
 			checks: []string{
-				"-e SECRET_KEY=",
-				"-e DEBUG=",
+				"--env-file /etc/podlift/myapp/env/web-abc123.env",
 			},
 		},
 		{
@@ -79,6 +78,42 @@ func TestGenerateRunCommand(t *testing.T) {
 				"celery worker",
 			},
 		},
+		{
+			name: "with restart policy",
+			config: ContainerConfig{
+				Name:    "myapp-web-1",
+				Image:   "myapp:abc123",
+				Restart: "unless-stopped",
+			},
+			checks: []string{
+				"--restart unless-stopped",
+			},
+		},
+		{
+			name: "with network",
+			config: ContainerConfig{
+				Name:           "myapp-postgres",
+				Image:          "postgres:16",
+				Network:        "podlift-myapp",
+				NetworkAliases: []string{"postgres"},
+			},
+			checks: []string{
+				"--network podlift-myapp",
+				"--network-alias postgres",
+			},
+		},
+		{
+			name: "with bind address",
+			config: ContainerConfig{
+				Name:        "myapp-postgres",
+				Image:       "postgres:16",
+				Port:        5432,
+				BindAddress: "127.0.0.1",
+			},
+			checks: []string{
+				"-p 127.0.0.1:5432:5432",
+			},
+		},
 		{
 			name: "with port mapping",
 			config: ContainerConfig{
@@ -108,6 +143,18 @@ func TestGenerateRunCommand(t *testing.T) {
 				"--restart=always",
 			},
 		},
+		{
+			name: "one-off task in the foreground",
+			config: ContainerConfig{
+				Name:       "myapp-web-run-20240101-120000",
+				Image:      "myapp:abc123",
+				Foreground: true,
+				Remove:     true,
+			},
+			checks: []string{
+				"sudo docker run --rm --name myapp-web-run-20240101-120000",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -213,3 +260,135 @@ func extractUptime(status string) string {
 	return "running"
 }
 
+func TestGenerateRunCommand_Quoting(t *testing.T) {
+	got := GenerateRunCommand(ContainerConfig{
+		Name:     "myapp-web-1",
+		Image:    "myapp:abc123",
+		EnvFiles: []string{"/etc/podlift/my app/web.env"},
+		Labels:   map[string]string{"owner": "ops; rm -rf /"},
+		Volumes:  []string{"/srv/my data:/data"},
+		Command:  `sh -c 'echo "$HOME"; date'`,
+	})
+
+	checks := []string{
+		`--env-file '/etc/podlift/my app/web.env'`,
+		`--label 'owner=ops; rm -rf /'`,
+		`-v '/srv/my data:/data'`,
+		`myapp:abc123 sh -c 'echo "$HOME"; date'`,
+	}
+	for _, check := range checks {
+		if !strings.Contains(got, check) {
+			t.Errorf("GenerateRunCommand() missing %q\nGot: %v", check, got)
+		}
+	}
+}
+
+// FuzzGenerateRunCommand checks that every value reaches docker as a single, unchanged argument
+func FuzzGenerateRunCommand(f *testing.F) {
+	f.Add("/etc/podlift/app/web.env", "/data:/var/lib/data", "owner", "ops team", "echo")
+	f.Add("/tmp/a b.env", "/srv/it's:/data", "x", "a;b|c", "$(id)")
+	f.Add("", "", "", "", "")
+
+	f.Fuzz(func(t *testing.T, envFile, volume, labelKey, labelValue, arg string) {
+		for _, s := range []string{envFile, volume, labelKey, labelValue, arg} {
+			if strings.IndexByte(s, 0) >= 0 {
+				t.Skip("NUL can't appear in a shell argument")
+			}
+		}
+
+		cfg := ContainerConfig{
+			Name:     "myapp-web-1",
+			Image:    "myapp:abc123",
+			EnvFiles: []string{envFile},
+			Labels:   map[string]string{labelKey: labelValue},
+			Volumes:  []string{volume},
+			Command:  shell.Join("echo", arg),
+		}
+
+		got, err := shell.Split(GenerateRunCommand(cfg))
+		if err != nil {
+			t.Fatalf("Split() error = %v", err)
+		}
+
+		want := []string{
+			"sudo", "docker", "run", "-d", "--name", "myapp-web-1",
+			"--env-file", envFile,
+			"--label", labelKey + "=" + labelValue,
+			"-v", volume,
+			"myapp:abc123", "echo", arg,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("arguments = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestRuntimeOptionsArgs(t *testing.T) {
+	runtime := RuntimeOptions{
+		Memory:      "512m",
+		CPUs:        0.5,
+		PidsLimit:   100,
+		Ulimits:     map[string]string{"nproc": "512", "nofile": "1024:65536"},
+		User:        "app",
+		ReadOnly:    true,
+		CapAdd:      []string{"NET_BIND_SERVICE"},
+		CapDrop:     []string{"ALL"},
+		StopSignal:  "SIGQUIT",
+		StopTimeout: 30,
+		LogDriver:   "json-file",
+		LogOptions:  map[string]string{"max-size": "10m", "max-file": "3"},
+		Healthcheck: &Healthcheck{Command: "curl -f http://localhost:8000/health", Interval: "10s", Retries: 3},
+	}
+
+	want := []string{
+		"--memory", "512m",
+		"--cpus", "0.5",
+		"--pids-limit", "100",
+		"--ulimit", "nofile=1024:65536",
+		"--ulimit", "nproc=512",
+		"--user", "app",
+		"--read-only",
+		"--cap-add", "NET_BIND_SERVICE",
+		"--cap-drop", "ALL",
+		"--stop-signal", "SIGQUIT",
+		"--stop-timeout", "30",
+		"--log-driver", "json-file",
+		"--log-opt", "max-file=3",
+		"--log-opt", "max-size=10m",
+		"--health-cmd", "curl -f http://localhost:8000/health",
+		"--health-interval", "10s",
+		"--health-retries", "3",
+	}
+
+	if got := runtime.Args(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Args() = %q\nwant %q", got, want)
+	}
+
+	if got := (RuntimeOptions{}).Args(); len(got) != 0 {
+		t.Errorf("Args() of empty options = %q, want none", got)
+	}
+}
+
+func TestGenerateRunCommand_Deterministic(t *testing.T) {
+	cfg := ContainerConfig{
+		Name:    "myapp-web-1",
+		Image:   "myapp:abc123",
+		Labels:  map[string]string{"b": "2", "a": "1", "c": "3", "d": "4"},
+		Options: map[string]string{"memory-swap": "1g", "init": "", "shm-size": "64m"},
+		Runtime: RuntimeOptions{Ulimits: map[string]string{"nofile": "1024", "core": "0", "nproc": "64"}},
+	}
+
+	first := GenerateRunCommand(cfg)
+	for i := 0; i < 20; i++ {
+		if got := GenerateRunCommand(cfg); got != first {
+			t.Fatalf("GenerateRunCommand() changed between calls:\n%s\n%s", first, got)
+		}
+	}
+
+	if !strings.Contains(first, "--label a=1 --label b=2 --label c=3 --label d=4") {
+		t.Errorf("labels not sorted: %s", first)
+	}
+	if !strings.Contains(first, "--init --memory-swap=1g --shm-size=64m") {
+		t.Errorf("options not sorted: %s", first)
+	}
+}
@@ -0,0 +1,57 @@
+package docker
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ekinertac/podlift/internal/shell"
+)
+
+// EnvFileDir returns the directory holding env files for a podlift service on the server
+func EnvFileDir(service string) string {
+	return fmt.Sprintf("/etc/podlift/%s/env", service)
+}
+
+// EnvFilePath returns the env file for one service of a release
+// e.g. /etc/podlift/myapp/env/web-a1b2c3d.env
+func EnvFilePath(service, serviceName, version string) string {
+	return path.Join(EnvFileDir(service), fmt.Sprintf("%s-%s.env", serviceName, version))
+}
+
+// DependencyEnvFilePath returns the env file for a dependency container
+// e.g. /etc/podlift/myapp/env/dependencies/postgres.env
+func DependencyEnvFilePath(service, dependency string) string {
+	return path.Join(EnvFileDir(service), "dependencies", dependency+".env")
+}
+
+// FormatEnvFile renders env vars in docker's --env-file format (sorted KEY=VALUE lines)
+// Docker reads values literally: no quoting or expansion, but no multi-line values either.
+func FormatEnvFile(env map[string]string) (string, error) {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		value := env[key]
+		if key == "" || strings.ContainsAny(key, "=\n\r \t") {
+			return "", fmt.Errorf("invalid environment variable name %q", key)
+		}
+		if strings.ContainsAny(value, "\n\r") {
+			return "", fmt.Errorf("environment variable %s contains a newline (not supported by docker env files)", key)
+		}
+		fmt.Fprintf(&b, "%s=%s\n", key, value)
+	}
+	return b.String(), nil
+}
+
+// GenerateEnvFileCreateCommand generates command to create an empty env file only root can read
+// The file is created with mode 600 before any content is written to it.
+func GenerateEnvFileCreateCommand(envFile string) string {
+	return shell.Join("sudo", "install", "-d", "-m", "700", path.Dir(envFile)) + " && " +
+		shell.Join("sudo", "install", "-m", "600", "/dev/null", envFile)
+}
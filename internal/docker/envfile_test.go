@@ -0,0 +1,44 @@
+package docker
+
+import (
+	"testing"
+)
+
+func TestFormatEnvFile(t *testing.T) {
+	got, err := FormatEnvFile(map[string]string{
+		"SECRET_KEY": `p@$$w0rd"with` + "`backticks`",
+		"DEBUG":      "false",
+		"EMPTY":      "",
+	})
+	if err != nil {
+		t.Fatalf("FormatEnvFile() error = %v", err)
+	}
+
+	// Sorted, and values are written literally
+	want := "DEBUG=false\nEMPTY=\nSECRET_KEY=p@$$w0rd\"with`backticks`\n"
+	if got != want {
+		t.Errorf("FormatEnvFile() = %q, want %q", got, want)
+	}
+
+	if _, err := FormatEnvFile(map[string]string{"CERT": "line1\nline2"}); err == nil {
+		t.Error("FormatEnvFile() should reject multi-line values")
+	}
+	if _, err := FormatEnvFile(map[string]string{"BAD KEY": "x"}); err == nil {
+		t.Error("FormatEnvFile() should reject invalid names")
+	}
+}
+
+func TestEnvFilePaths(t *testing.T) {
+	if got := EnvFilePath("myapp", "web", "abc123"); got != "/etc/podlift/myapp/env/web-abc123.env" {
+		t.Errorf("EnvFilePath() = %v", got)
+	}
+	if got := DependencyEnvFilePath("myapp", "postgres"); got != "/etc/podlift/myapp/env/dependencies/postgres.env" {
+		t.Errorf("DependencyEnvFilePath() = %v", got)
+	}
+
+	cmd := GenerateEnvFileCreateCommand("/etc/podlift/myapp/env/web-abc123.env")
+	expected := "sudo install -d -m 700 /etc/podlift/myapp/env && sudo install -m 600 /dev/null /etc/podlift/myapp/env/web-abc123.env"
+	if cmd != expected {
+		t.Errorf("GenerateEnvFileCreateCommand() = %v, want %v", cmd, expected)
+	}
+}
@@ -0,0 +1,139 @@
+package docker
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/ekinertac/podlift/internal/shell"
+)
+
+// ImageReference represents a parsed image reference
+// e.g. "ghcr.io/org/app:v1.4.2" or "ghcr.io/org/app@sha256:..."
+type ImageReference struct {
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseImageReference splits an image reference into repository, tag and digest
+func ParseImageReference(ref string) ImageReference {
+	var result ImageReference
+
+	// Digest comes after "@"
+	if i := strings.Index(ref, "@"); i >= 0 {
+		result.Digest = ref[i+1:]
+		ref = ref[:i]
+	}
+
+	// Tag is after the last ":" that follows the last "/"
+	// (a ":" before the last "/" is a registry port, e.g. localhost:5000/app)
+	lastSlash := strings.LastIndex(ref, "/")
+	if i := strings.LastIndex(ref, ":"); i > lastSlash {
+		result.Tag = ref[i+1:]
+		ref = ref[:i]
+	}
+
+	result.Repository = ref
+	return result
+}
+
+// Name returns the last path element of the repository
+// e.g. postgis for ghcr.io/postgis/postgis:16-3.4
+func (r ImageReference) Name() string {
+	return r.Repository[strings.LastIndex(r.Repository, "/")+1:]
+}
+
+// IsPinned returns true if the reference names a specific tag or digest
+func (r ImageReference) IsPinned() bool {
+	return r.Tag != "" || r.Digest != ""
+}
+
+// WithTag returns a copy of the reference using the given tag
+func (r ImageReference) WithTag(tag string) ImageReference {
+	r.Tag = tag
+	r.Digest = ""
+	return r
+}
+
+// WithDigest returns a copy of the reference pinned to the given digest
+func (r ImageReference) WithDigest(digest string) ImageReference {
+	r.Digest = digest
+	return r
+}
+
+// String returns the reference in docker format
+func (r ImageReference) String() string {
+	ref := r.Repository
+	if r.Tag != "" {
+		ref += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		ref += "@" + r.Digest
+	}
+	return ref
+}
+
+// ShortDigest returns the first 12 hex characters of a digest
+// e.g. "sha256:4f1c2a..." → "4f1c2a..."
+func ShortDigest(digest string) string {
+	hex := digest
+	if i := strings.Index(digest, ":"); i >= 0 {
+		hex = digest[i+1:]
+	}
+	if len(hex) > 12 {
+		hex = hex[:12]
+	}
+	return hex
+}
+
+// FindRepoDigest picks the digest for a repository from `docker image inspect` RepoDigests output
+// Each line has the form "repository@sha256:...". Digests of other repositories the image
+// was pulled from don't identify what was deployed, so they are never used.
+func FindRepoDigest(output, repository string) (string, error) {
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		line = strings.TrimSpace(line)
+		parts := strings.SplitN(line, "@", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			continue
+		}
+		if familiarRepository(parts[0]) == familiarRepository(repository) {
+			return parts[1], nil
+		}
+	}
+
+	return "", fmt.Errorf("no repository digest found for %s", repository)
+}
+
+// familiarRepository returns the short form docker shows for Docker Hub repositories
+// e.g. docker.io/library/nginx → nginx, docker.io/org/app → org/app
+func familiarRepository(repository string) string {
+	repository = strings.TrimPrefix(repository, "docker.io/")
+	return strings.TrimPrefix(repository, "library/")
+}
+
+// GeneratePullCommand generates command to pull an image on the server
+func GeneratePullCommand(ref string) string {
+	return shell.Join("sudo", "docker", "pull", ref)
+}
+
+// GenerateRepoDigestsCommand generates command to list the repository digests of an image
+func GenerateRepoDigestsCommand(ref string) string {
+	return shell.Join("sudo", "docker", "image", "inspect", "--format", "{{range .RepoDigests}}{{println .}}{{end}}", ref)
+}
+
+// GenerateImageIDCommand generates command to get the image ID (content digest) on the server
+func GenerateImageIDCommand(ref string) string {
+	return shell.Join("sudo", "docker", "image", "inspect", "--format", "{{.Id}}", ref)
+}
+
+// GetImageID returns the local image ID (sha256 content digest) of an image
+// The ID is the same after save/load or push/pull, so it identifies image content across servers
+func GetImageID(ref string) (string, error) {
+	cmd := exec.Command("docker", "image", "inspect", "--format", "{{.Id}}", ref)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect image %s: %w", ref, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,97 @@
+package docker
+
+import (
+	"testing"
+)
+
+func TestParseImageReference(t *testing.T) {
+	tests := []struct {
+		ref    string
+		want   ImageReference
+		pinned bool
+	}{
+		{"myapp", ImageReference{Repository: "myapp"}, false},
+		{"myapp:v1", ImageReference{Repository: "myapp", Tag: "v1"}, true},
+		{"ghcr.io/org/app:v1.4.2", ImageReference{Repository: "ghcr.io/org/app", Tag: "v1.4.2"}, true},
+		{"localhost:5000/app", ImageReference{Repository: "localhost:5000/app"}, false},
+		{"localhost:5000/app:dev", ImageReference{Repository: "localhost:5000/app", Tag: "dev"}, true},
+		{
+			"ghcr.io/org/app@sha256:abc123",
+			ImageReference{Repository: "ghcr.io/org/app", Digest: "sha256:abc123"},
+			true,
+		},
+		{
+			"ghcr.io/org/app:v1@sha256:abc123",
+			ImageReference{Repository: "ghcr.io/org/app", Tag: "v1", Digest: "sha256:abc123"},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got := ParseImageReference(tt.ref)
+			if got != tt.want {
+				t.Errorf("ParseImageReference(%q) = %+v, want %+v", tt.ref, got, tt.want)
+			}
+			if got.IsPinned() != tt.pinned {
+				t.Errorf("IsPinned() = %v, want %v", got.IsPinned(), tt.pinned)
+			}
+			if got.String() != tt.ref {
+				t.Errorf("String() = %q, want %q", got.String(), tt.ref)
+			}
+		})
+	}
+}
+
+func TestImageReference_WithTag(t *testing.T) {
+	ref := ParseImageReference("ghcr.io/org/app@sha256:abc123").WithTag("v2")
+	if ref.String() != "ghcr.io/org/app:v2" {
+		t.Errorf("WithTag() = %q, want ghcr.io/org/app:v2", ref.String())
+	}
+}
+
+func TestShortDigest(t *testing.T) {
+	got := ShortDigest("sha256:4f1c2a9b8e7d6c5b4a3f2e1d")
+	if got != "4f1c2a9b8e7d" {
+		t.Errorf("ShortDigest() = %q, want 4f1c2a9b8e7d", got)
+	}
+}
+
+func TestFindRepoDigest(t *testing.T) {
+	output := "docker.io/org/app@sha256:aaa\nghcr.io/org/app@sha256:bbb\n"
+
+	digest, err := FindRepoDigest(output, "ghcr.io/org/app")
+	if err != nil {
+		t.Fatalf("FindRepoDigest() error = %v", err)
+	}
+	if digest != "sha256:bbb" {
+		t.Errorf("FindRepoDigest() = %q, want sha256:bbb", digest)
+	}
+
+	// Docker Hub repositories are listed in their short form
+	digest, _ = FindRepoDigest(output, "org/app")
+	if digest != "sha256:aaa" {
+		t.Errorf("FindRepoDigest() = %q, want sha256:aaa", digest)
+	}
+	digest, _ = FindRepoDigest("nginx@sha256:ccc\n", "docker.io/library/nginx")
+	if digest != "sha256:ccc" {
+		t.Errorf("FindRepoDigest() = %q, want sha256:ccc", digest)
+	}
+
+	// Another repository's digest doesn't identify the deployed image
+	if digest, err := FindRepoDigest(output, "registry.example.com/other/app"); err == nil {
+		t.Errorf("FindRepoDigest() = %q, want an error for a mismatched repository", digest)
+	}
+
+	if _, err := FindRepoDigest("", "app"); err == nil {
+		t.Error("FindRepoDigest() should fail without digests")
+	}
+}
+
+func TestGenerateImageIDCommand(t *testing.T) {
+	cmd := GenerateImageIDCommand("myapp:v1")
+	expected := "sudo docker image inspect --format '{{.Id}}' myapp:v1"
+	if cmd != expected {
+		t.Errorf("GenerateImageIDCommand() = %v, want %v", cmd, expected)
+	}
+}
@@ -0,0 +1,26 @@
+package docker
+
+import "github.com/ekinertac/podlift/internal/shell"
+
+// NetworkName returns the private bridge network of a podlift service
+// App containers and dependencies on a server share it and reach each other by alias.
+func NetworkName(service string) string {
+	return "podlift-" + service
+}
+
+// GenerateNetworkCreateCommand generates command to create the service network if it doesn't exist
+func GenerateNetworkCreateCommand(service string) string {
+	network := NetworkName(service)
+	return shell.Join("sudo", "docker", "network", "inspect", network) + " >/dev/null 2>&1 || " +
+		shell.Join("sudo", "docker", "network", "create", "--driver", "bridge", "--label", "podlift.service="+service, network)
+}
+
+// GenerateNetworkConnectCommand generates command to attach a running container to a network
+// Fails if the container is already attached.
+func GenerateNetworkConnectCommand(network, containerName string, aliases ...string) string {
+	cmd := shell.Command("sudo", "docker", "network", "connect")
+	for _, alias := range aliases {
+		cmd.Flag("--alias", alias)
+	}
+	return cmd.Arg(network, containerName).String()
+}
@@ -0,0 +1,26 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateNetworkCreateCommand(t *testing.T) {
+	cmd := GenerateNetworkCreateCommand("myapp")
+
+	// Creating is skipped when the network exists, so deploys can run it every time
+	if !strings.HasPrefix(cmd, "sudo docker network inspect podlift-myapp >/dev/null 2>&1 || ") {
+		t.Errorf("command should check for the network first: %s", cmd)
+	}
+	if !strings.Contains(cmd, "network create --driver bridge --label podlift.service=myapp podlift-myapp") {
+		t.Errorf("command should create a labeled bridge network: %s", cmd)
+	}
+}
+
+func TestGenerateNetworkConnectCommand(t *testing.T) {
+	got := GenerateNetworkConnectCommand("podlift-myapp", "myapp-postgres", "postgres")
+	want := "sudo docker network connect --alias postgres podlift-myapp myapp-postgres"
+	if got != want {
+		t.Errorf("GenerateNetworkConnectCommand() = %q, want %q", got, want)
+	}
+}
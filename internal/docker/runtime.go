@@ -0,0 +1,102 @@
+package docker
+
+import (
+	"sort"
+	"strconv"
+)
+
+// RuntimeOptions are resource limits and runtime settings of a container
+type RuntimeOptions struct {
+	Memory      string // e.g. 512m
+	CPUs        float64
+	PidsLimit   int
+	Ulimits     map[string]string // name → limit or soft:hard
+	User        string
+	ReadOnly    bool
+	CapAdd      []string
+	CapDrop     []string
+	StopSignal  string
+	StopTimeout int // Seconds
+	LogDriver   string
+	LogOptions  map[string]string
+	Healthcheck *Healthcheck
+}
+
+// Healthcheck is a docker HEALTHCHECK run inside the container
+type Healthcheck struct {
+	Command     string // Run with the container's shell
+	Interval    string
+	Timeout     string
+	StartPeriod string
+	Retries     int
+}
+
+// Args returns the docker run flags for the options, always in the same order
+func (r RuntimeOptions) Args() []string {
+	var args []string
+
+	if r.Memory != "" {
+		args = append(args, "--memory", r.Memory)
+	}
+	if r.CPUs > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(r.CPUs, 'f', -1, 64))
+	}
+	if r.PidsLimit != 0 {
+		args = append(args, "--pids-limit", strconv.Itoa(r.PidsLimit))
+	}
+	for _, name := range sortedKeys(r.Ulimits) {
+		args = append(args, "--ulimit", name+"="+r.Ulimits[name])
+	}
+	if r.User != "" {
+		args = append(args, "--user", r.User)
+	}
+	if r.ReadOnly {
+		args = append(args, "--read-only")
+	}
+	for _, c := range r.CapAdd {
+		args = append(args, "--cap-add", c)
+	}
+	for _, c := range r.CapDrop {
+		args = append(args, "--cap-drop", c)
+	}
+	if r.StopSignal != "" {
+		args = append(args, "--stop-signal", r.StopSignal)
+	}
+	if r.StopTimeout > 0 {
+		args = append(args, "--stop-timeout", strconv.Itoa(r.StopTimeout))
+	}
+	if r.LogDriver != "" {
+		args = append(args, "--log-driver", r.LogDriver)
+	}
+	for _, key := range sortedKeys(r.LogOptions) {
+		args = append(args, "--log-opt", key+"="+r.LogOptions[key])
+	}
+
+	if hc := r.Healthcheck; hc != nil && hc.Command != "" {
+		args = append(args, "--health-cmd", hc.Command)
+		if hc.Interval != "" {
+			args = append(args, "--health-interval", hc.Interval)
+		}
+		if hc.Timeout != "" {
+			args = append(args, "--health-timeout", hc.Timeout)
+		}
+		if hc.StartPeriod != "" {
+			args = append(args, "--health-start-period", hc.StartPeriod)
+		}
+		if hc.Retries > 0 {
+			args = append(args, "--health-retries", strconv.Itoa(hc.Retries))
+		}
+	}
+
+	return args
+}
+
+// sortedKeys returns map keys in sorted order, so generated commands are stable
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
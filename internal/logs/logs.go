@@ -0,0 +1,369 @@
+// Package logs shows the logs of every replica of a service on every server
+//
+// Each line is prefixed with the host and replica it came from. Without
+// --follow, logs are printed per replica or merged in time order; with
+// --follow, all replicas stream concurrently and containers started later,
+// e.g. by a deploy, are picked up as they appear.
+package logs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/deploy"
+	"github.com/ekinertac/podlift/internal/shell"
+	"github.com/ekinertac/podlift/internal/ssh"
+)
+
+// DefaultPollInterval is how often follow mode looks for new containers
+const DefaultPollInterval = 2 * time.Second
+
+// Server is a connected server to read logs from
+type Server struct {
+	Host   string
+	Client ssh.SSHClient
+}
+
+// Options configures which log lines are shown and how
+type Options struct {
+	Tail         int            // Lines per replica from the end of the logs; 0 shows all
+	Follow       bool           // Stream new lines until the context is done
+	Since        string         // Passed to docker logs, e.g. 2h or 2024-01-01T12:00:00
+	Until        string         // Passed to docker logs
+	Timestamps   bool           // Show docker's timestamp on each line
+	Merge        bool           // Print lines of all replicas in time order (without Follow)
+	Grep         *regexp.Regexp // Only show lines matching this
+	PollInterval time.Duration  // Follow: how often to look for new containers
+}
+
+// prefixColors are assigned to replicas in the order they're first seen
+var prefixColors = []lipgloss.Color{"39", "42", "214", "213", "45", "207", "118", "226"}
+
+// Show writes the logs of a service's replicas on all servers to out
+func Show(ctx context.Context, cfg *config.Config, servers []Server, serviceName string, opts Options, out io.Writer) error {
+	p := &printer{out: out, grep: opts.Grep, colors: map[string]lipgloss.Style{}}
+	if opts.Follow {
+		return follow(ctx, cfg, servers, serviceName, opts, p)
+	}
+	return show(cfg, servers, serviceName, opts, p)
+}
+
+// source is a replica on a server
+type source struct {
+	host    string
+	service string
+	replica deploy.Replica
+}
+
+// prefix returns the line prefix of the source, e.g. 192.168.1.10/web-1
+func (s source) prefix() string {
+	return fmt.Sprintf("%s/%s-%d", s.host, s.service, s.replica.Number)
+}
+
+// logsCommand returns the docker logs command for a container
+// stderr is merged so the lines of both streams keep their order.
+func logsCommand(container string, tail int, since, until string, timestamps, follow bool) string {
+	cmd := shell.Command("sudo", "docker", "logs")
+	if tail > 0 {
+		cmd.Flag("--tail", strconv.Itoa(tail))
+	}
+	if since != "" {
+		cmd.Flag("--since", since)
+	}
+	if until != "" {
+		cmd.Flag("--until", until)
+	}
+	if timestamps {
+		cmd.Arg("--timestamps")
+	}
+	if follow {
+		cmd.Arg("--follow")
+	}
+	return cmd.Arg(container).String() + " 2>&1"
+}
+
+// fetched is the output of docker logs for one source
+type fetched struct {
+	source source
+	output string
+	err    error
+}
+
+// show prints the logs of all sources once, grouped by source or merged by time
+func show(cfg *config.Config, servers []Server, serviceName string, opts Options, p *printer) error {
+	var sources []source
+	clients := map[string]ssh.SSHClient{}
+	for _, server := range servers {
+		replicas, err := deploy.ListReplicas(cfg, server.Client, serviceName)
+		if err != nil {
+			return fmt.Errorf("%s: %w", server.Host, err)
+		}
+		clients[server.Host] = server.Client
+
+		// By replica, oldest release first, so lines read in order
+		serverSources := make([]source, len(replicas))
+		for i, replica := range replicas {
+			serverSources[len(replicas)-1-i] = source{host: server.Host, service: serviceName, replica: replica}
+		}
+		sort.SliceStable(serverSources, func(i, j int) bool {
+			return serverSources[i].replica.Number < serverSources[j].replica.Number
+		})
+		sources = append(sources, serverSources...)
+	}
+	if len(sources) == 0 {
+		return fmt.Errorf("no running container found for service '%s'", serviceName)
+	}
+
+	// Timestamps are needed to merge, and stripped again unless asked for
+	p.stripTimestamps = opts.Merge && !opts.Timestamps
+	results := make([]fetched, len(sources))
+	var wg sync.WaitGroup
+	for i, src := range sources {
+		wg.Add(1)
+		go func(i int, src source) {
+			defer wg.Done()
+			cmd := logsCommand(src.replica.Container, opts.Tail, opts.Since, opts.Until, opts.Timestamps || opts.Merge, false)
+			output, err := clients[src.host].Execute(cmd)
+			results[i] = fetched{source: src, output: output, err: err}
+		}(i, src)
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		if result.err != nil {
+			return fmt.Errorf("failed to get logs of %s on %s: %w", result.source.replica.Container, result.source.host, result.err)
+		}
+	}
+
+	if !opts.Merge {
+		for _, result := range results {
+			for _, line := range splitLines(result.output) {
+				p.line(result.source, line)
+			}
+		}
+		return nil
+	}
+
+	type entry struct {
+		source source
+		time   time.Time
+		line   string
+	}
+	var entries []entry
+	for _, result := range results {
+		var last time.Time
+		for _, line := range splitLines(result.output) {
+			// A line without a timestamp stays with the line before it
+			if t, ok := parseTimestamp(line); ok {
+				last = t
+			}
+			entries = append(entries, entry{source: result.source, time: last, line: line})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].time.Before(entries[j].time)
+	})
+	for _, e := range entries {
+		p.line(e.source, e.line)
+	}
+	return nil
+}
+
+// follow streams the logs of all sources until the context is done or a server fails
+func follow(ctx context.Context, cfg *config.Config, servers []Server, serviceName string, opts Options, p *printer) error {
+	if opts.PollInterval == 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+
+	errs := make(chan error, len(servers))
+	for _, server := range servers {
+		go func(server Server) {
+			errs <- followServer(ctx, cfg, server, serviceName, opts, p)
+		}(server)
+	}
+
+	for range servers {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errs:
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// followServer streams the logs of a service's replicas on a server, attaching to
+// containers as they appear
+func followServer(ctx context.Context, cfg *config.Config, server Server, serviceName string, opts Options, p *printer) error {
+	var mu sync.Mutex
+	streaming := map[string]bool{}
+	stopped := map[string]time.Time{} // Containers whose stream ended, e.g. on restart
+
+	ticker := time.NewTicker(opts.PollInterval)
+	defer ticker.Stop()
+
+	for first := true; ; first = false {
+		replicas, err := deploy.ListReplicas(cfg, server.Client, serviceName)
+		if err != nil {
+			return fmt.Errorf("%s: %w", server.Host, err)
+		}
+
+		for _, replica := range replicas {
+			mu.Lock()
+			active := streaming[replica.Container]
+			endedAt, restarted := stopped[replica.Container]
+			if !active {
+				streaming[replica.Container] = true
+			}
+			mu.Unlock()
+			if active {
+				continue
+			}
+
+			src := source{host: server.Host, service: serviceName, replica: replica}
+
+			// Containers found later are new (all their lines are new) or restarted
+			tail, since := opts.Tail, opts.Since
+			if !first {
+				tail = 0
+				p.notice(src, "attached to "+replica.Container)
+			}
+			if restarted {
+				since = endedAt.UTC().Format(time.RFC3339Nano)
+			}
+
+			cmd := logsCommand(replica.Container, tail, since, opts.Until, opts.Timestamps, true)
+			go func(container string) {
+				w := p.writer(src)
+				server.Client.ExecuteWithOutput(cmd, w, w)
+				w.Flush()
+
+				mu.Lock()
+				delete(streaming, container)
+				stopped[container] = time.Now()
+				mu.Unlock()
+			}(replica.Container)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// printer writes prefixed lines of all sources to one output
+type printer struct {
+	mu              sync.Mutex
+	out             io.Writer
+	grep            *regexp.Regexp
+	stripTimestamps bool // Timestamps were only requested to merge lines
+	width           int
+	colors          map[string]lipgloss.Style
+}
+
+// line prints a line of docker logs output from a source
+func (p *printer) line(src source, line string) {
+	message := line
+	if p.stripTimestamps {
+		if _, ok := parseTimestamp(line); ok {
+			_, message, _ = strings.Cut(line, " ")
+		}
+	}
+	if p.grep != nil && !p.grep.MatchString(message) {
+		return
+	}
+	p.write(src, message)
+}
+
+// notice prints a message from podlift about a source
+func (p *printer) notice(src source, message string) {
+	p.write(src, "--- "+message)
+}
+
+func (p *printer) write(src source, message string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	prefix := src.prefix()
+	style, ok := p.colors[prefix]
+	if !ok {
+		style = lipgloss.NewStyle().Foreground(prefixColors[len(p.colors)%len(prefixColors)])
+		p.colors[prefix] = style
+	}
+	if len(prefix) > p.width {
+		p.width = len(prefix)
+	}
+
+	fmt.Fprintf(p.out, "%s | %s\n", style.Render(fmt.Sprintf("%-*s", p.width, prefix)), message)
+}
+
+// writer returns a writer that prints each complete line written to it
+func (p *printer) writer(src source) *lineWriter {
+	return &lineWriter{printer: p, source: src}
+}
+
+// lineWriter splits a stream into lines for the printer
+type lineWriter struct {
+	mu      sync.Mutex
+	printer *printer
+	source  source
+	buf     bytes.Buffer
+}
+
+func (w *lineWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Write(b)
+	for {
+		i := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimSuffix(string(w.buf.Next(i+1)), "\n")
+		w.printer.line(w.source, strings.TrimSuffix(line, "\r"))
+	}
+	return len(b), nil
+}
+
+// Flush prints a last line without a newline
+func (w *lineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.buf.Len() > 0 {
+		w.printer.line(w.source, w.buf.String())
+		w.buf.Reset()
+	}
+}
+
+// parseTimestamp parses the timestamp docker logs --timestamps puts before each line
+func parseTimestamp(line string) (time.Time, bool) {
+	field, _, _ := strings.Cut(line, " ")
+	t, err := time.Parse(time.RFC3339Nano, field)
+	return t, err == nil
+}
+
+// splitLines splits docker logs output into lines
+func splitLines(output string) []string {
+	output = strings.TrimSuffix(output, "\n")
+	if output == "" {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
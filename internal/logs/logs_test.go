@@ -0,0 +1,197 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/ssh"
+)
+
+func testConfig() *config.Config {
+	return &config.Config{Service: "myapp", Services: map[string]config.Service{"web": {Replicas: 2}}}
+}
+
+// logsClient returns a mock server running the given containers, with docker logs output per container
+func logsClient(ps string, output map[string]string) *ssh.MockClient {
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		if strings.Contains(cmd, "docker ps") {
+			return ps, nil
+		}
+		for container, out := range output {
+			if strings.Contains(cmd, " "+container+" ") {
+				return out, nil
+			}
+		}
+		return "", nil
+	}
+	return client
+}
+
+func TestLogsCommand(t *testing.T) {
+	got := logsCommand("myapp-web-abc123-1", 50, "2h", "10m", true, true)
+	want := "sudo docker logs --tail 50 --since 2h --until 10m --timestamps --follow myapp-web-abc123-1 2>&1"
+	if got != want {
+		t.Errorf("logsCommand() = %q, want %q", got, want)
+	}
+
+	if got := logsCommand("myapp-web-abc123-1", 0, "", "", false, false); got != "sudo docker logs myapp-web-abc123-1 2>&1" {
+		t.Errorf("logsCommand() = %q", got)
+	}
+}
+
+func TestShow(t *testing.T) {
+	servers := []Server{
+		{Host: "10.0.0.1", Client: logsClient("myapp-web-abc123-2\t2\tabc123\nmyapp-web-abc123-1\t1\tabc123\n", map[string]string{
+			"myapp-web-abc123-1": "2024-01-01T12:00:01.000000000Z GET /\n2024-01-01T12:00:04.000000000Z GET /health\n",
+			"myapp-web-abc123-2": "2024-01-01T12:00:02.000000000Z POST /login\n",
+		})},
+		{Host: "10.0.0.2", Client: logsClient("myapp-web-abc123-1\t1\tabc123\n", map[string]string{
+			"myapp-web-abc123-1": "2024-01-01T12:00:03.000000000Z GET /about\n",
+		})},
+	}
+
+	t.Run("grouped", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := Show(context.Background(), testConfig(), servers, "web", Options{Tail: 100, Timestamps: true}, &out); err != nil {
+			t.Fatalf("Show() error = %v", err)
+		}
+		want := "" +
+			"10.0.0.1/web-1 | 2024-01-01T12:00:01.000000000Z GET /\n" +
+			"10.0.0.1/web-1 | 2024-01-01T12:00:04.000000000Z GET /health\n" +
+			"10.0.0.1/web-2 | 2024-01-01T12:00:02.000000000Z POST /login\n" +
+			"10.0.0.2/web-1 | 2024-01-01T12:00:03.000000000Z GET /about\n"
+		if out.String() != want {
+			t.Errorf("Show() output:\n%s\nwant:\n%s", out.String(), want)
+		}
+	})
+
+	t.Run("merged", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := Show(context.Background(), testConfig(), servers, "web", Options{Merge: true}, &out); err != nil {
+			t.Fatalf("Show() error = %v", err)
+		}
+		want := "" +
+			"10.0.0.1/web-1 | GET /\n" +
+			"10.0.0.1/web-2 | POST /login\n" +
+			"10.0.0.2/web-1 | GET /about\n" +
+			"10.0.0.1/web-1 | GET /health\n"
+		if out.String() != want {
+			t.Errorf("Show() output:\n%s\nwant:\n%s", out.String(), want)
+		}
+	})
+
+	t.Run("grep", func(t *testing.T) {
+		var out bytes.Buffer
+		opts := Options{Merge: true, Grep: regexp.MustCompile(`^GET /(about|health)`)}
+		if err := Show(context.Background(), testConfig(), servers, "web", opts, &out); err != nil {
+			t.Fatalf("Show() error = %v", err)
+		}
+		want := "10.0.0.2/web-1 | GET /about\n10.0.0.1/web-1 | GET /health\n"
+		if out.String() != want {
+			t.Errorf("Show() output:\n%s\nwant:\n%s", out.String(), want)
+		}
+	})
+
+	t.Run("not running", func(t *testing.T) {
+		servers := []Server{{Host: "10.0.0.1", Client: logsClient("", nil)}}
+		if err := Show(context.Background(), testConfig(), servers, "web", Options{}, io.Discard); err == nil {
+			t.Error("Show() should fail when no replica is running")
+		}
+	})
+}
+
+func TestShow_FollowPicksUpNewContainers(t *testing.T) {
+	var mu sync.Mutex
+	var commands []string
+	deployed := false
+
+	done := make(chan struct{})
+	defer close(done)
+
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if deployed {
+			return "myapp-web-def456-1\t1\tdef456\nmyapp-web-abc123-1\t1\tabc123\n", nil
+		}
+		deployed = true // The next poll sees the new release
+		return "myapp-web-abc123-1\t1\tabc123\n", nil
+	}
+	client.ExecuteWithOutputFunc = func(cmd string, stdout, stderr io.Writer) error {
+		mu.Lock()
+		commands = append(commands, cmd)
+		mu.Unlock()
+
+		if strings.Contains(cmd, "def456") {
+			io.WriteString(stdout, "Booting worker\nListening")
+		} else {
+			io.WriteString(stdout, "GET /\n")
+		}
+		<-done // Streams until the test ends
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var out safeBuffer
+	result := make(chan error, 1)
+	go func() {
+		result <- Show(ctx, testConfig(), []Server{{Host: "10.0.0.1", Client: client}}, "web",
+			Options{Tail: 100, Follow: true, PollInterval: 5 * time.Millisecond}, &out)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(out.String(), "Booting worker") {
+		if time.Now().After(deadline) {
+			t.Fatalf("new container was not followed, output:\n%s", out.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	if err := <-result; err != nil {
+		t.Fatalf("Show() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(commands) != 2 {
+		t.Fatalf("commands = %v, want one stream per container", commands)
+	}
+	if !strings.Contains(commands[0], "--tail 100 --follow myapp-web-abc123-1") {
+		t.Errorf("first stream = %q, want the tail of the running container", commands[0])
+	}
+	if strings.Contains(commands[1], "--tail") || !strings.Contains(commands[1], "myapp-web-def456-1") {
+		t.Errorf("second stream = %q, want all lines of the new container", commands[1])
+	}
+	for _, want := range []string{"10.0.0.1/web-1 | GET /", "10.0.0.1/web-1 | --- attached to myapp-web-def456-1", "10.0.0.1/web-1 | Booting worker"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output should contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+// safeBuffer is a bytes.Buffer that can be read while it's written
+type safeBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *safeBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
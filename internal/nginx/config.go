@@ -2,8 +2,11 @@ package nginx
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"text/template"
+
+	"github.com/ekinertac/podlift/internal/shell"
 )
 
 // Config represents nginx configuration
@@ -104,6 +107,34 @@ server {
 	return buf.String(), nil
 }
 
+// ParseUpstreams returns the servers of the upstream block in a generated config
+func ParseUpstreams(config string) []Upstream {
+	var upstreams []Upstream
+	inUpstream := false
+
+	for _, line := range strings.Split(config, "\n") {
+		fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(line), ";"))
+		switch {
+		case len(fields) > 0 && fields[0] == "upstream":
+			inUpstream = true
+		case inUpstream && len(fields) > 0 && fields[0] == "}":
+			inUpstream = false
+		case inUpstream && len(fields) > 1 && fields[0] == "server":
+			host, port, ok := strings.Cut(fields[1], ":")
+			if !ok {
+				continue
+			}
+			n, err := strconv.Atoi(port)
+			if err != nil {
+				continue
+			}
+			upstreams = append(upstreams, Upstream{Host: host, Port: n})
+		}
+	}
+
+	return upstreams
+}
+
 // GenerateSitePath returns the nginx sites-available path
 func GenerateSitePath(serviceName string) string {
 	return fmt.Sprintf("/etc/nginx/sites-available/%s", serviceName)
@@ -118,7 +149,7 @@ func GenerateSymlinkPath(serviceName string) string {
 func GenerateEnableCommand(serviceName string) string {
 	sitePath := GenerateSitePath(serviceName)
 	symlinkPath := GenerateSymlinkPath(serviceName)
-	return fmt.Sprintf("sudo ln -sf %s %s", sitePath, symlinkPath)
+	return shell.Join("sudo", "ln", "-sf", sitePath, symlinkPath)
 }
 
 // GenerateReloadCommand generates command to reload nginx
@@ -126,6 +157,11 @@ func GenerateReloadCommand() string {
 	return "sudo nginx -t && sudo systemctl reload nginx"
 }
 
+// GenerateBootEnableCommand generates command to start nginx on boot
+func GenerateBootEnableCommand() string {
+	return "sudo systemctl enable nginx"
+}
+
 // GenerateTestCommand generates command to test nginx config
 func GenerateTestCommand() string {
 	return "sudo nginx -t"
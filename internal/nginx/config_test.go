@@ -147,3 +147,28 @@ func TestGenerateInstallCommand(t *testing.T) {
 	}
 }
 
+func TestParseUpstreams(t *testing.T) {
+	upstreams := []Upstream{
+		{Host: "localhost", Port: 9100},
+		{Host: "10.0.0.2", Port: 9101},
+	}
+
+	config, err := GenerateConfig(Config{
+		Domain:      "example.com",
+		ServiceName: "myapp",
+		Upstreams:   upstreams,
+	})
+	if err != nil {
+		t.Fatalf("GenerateConfig() error = %v", err)
+	}
+
+	got := ParseUpstreams(config)
+	if len(got) != len(upstreams) {
+		t.Fatalf("ParseUpstreams() = %v, want %v", got, upstreams)
+	}
+	for i := range upstreams {
+		if got[i].Host != upstreams[i].Host || got[i].Port != upstreams[i].Port {
+			t.Errorf("ParseUpstreams()[%d] = %v, want %v", i, got[i], upstreams[i])
+		}
+	}
+}
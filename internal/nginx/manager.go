@@ -2,7 +2,9 @@ package nginx
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/ekinertac/podlift/internal/shell"
 	"github.com/ekinertac/podlift/internal/ssh"
 	"github.com/ekinertac/podlift/internal/ui"
 )
@@ -33,6 +35,7 @@ func (m *Manager) Install() error {
 
 	if installed {
 		fmt.Println(ui.Success("nginx already installed"))
+		m.client.Execute(GenerateBootEnableCommand())
 		return nil
 	}
 
@@ -46,6 +49,9 @@ func (m *Manager) Install() error {
 	// Disable default site to avoid conflicts with our configuration
 	m.client.Execute("sudo rm -f /etc/nginx/sites-enabled/default")
 
+	// Start nginx on boot so the site comes back with the containers
+	m.client.Execute(GenerateBootEnableCommand())
+
 	fmt.Println(ui.Success("nginx installed"))
 	return nil
 }
@@ -56,6 +62,21 @@ func (m *Manager) WriteConfig(serviceName, config string) error {
 	return m.client.WriteFile(config, sitePath)
 }
 
+// ReadConfig reads the nginx configuration of a service from the server
+func (m *Manager) ReadConfig(serviceName string) (string, error) {
+	output, err := m.client.Execute(shell.Join("sudo", "cat", GenerateSitePath(serviceName)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read nginx config: %w", err)
+	}
+	return output, nil
+}
+
+// IsActive checks if the nginx service is running
+func (m *Manager) IsActive() bool {
+	output, err := m.client.Execute("systemctl is-active nginx")
+	return err == nil && strings.TrimSpace(output) == "active"
+}
+
 // EnableSite enables an nginx site
 func (m *Manager) EnableSite(serviceName string) error {
 	cmd := GenerateEnableCommand(serviceName)
@@ -131,10 +152,10 @@ func (m *Manager) RemoveSite(serviceName string) error {
 	symlinkPath := GenerateSymlinkPath(serviceName)
 
 	// Remove symlink
-	m.client.Execute(fmt.Sprintf("sudo rm -f %s", symlinkPath))
+	m.client.Execute(shell.Join("sudo", "rm", "-f", symlinkPath))
 	
 	// Remove config
-	m.client.Execute(fmt.Sprintf("sudo rm -f %s", sitePath))
+	m.client.Execute(shell.Join("sudo", "rm", "-f", sitePath))
 
 	// Reload
 	m.Reload()
@@ -0,0 +1,252 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ekinertac/podlift/internal/config"
+)
+
+// dockerHubAuthKey is the key Docker uses for Docker Hub in config.json and credential helpers
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+// Credentials contains resolved registry credentials
+type Credentials struct {
+	Username string
+	Password string
+	Source   string // Where the credentials came from (for display)
+}
+
+// ResolveCredentials finds registry credentials, checking in order:
+//  1. password in podlift.yml (or .env)
+//  2. password_command
+//  3. credential_helper
+//  4. existing entries in ~/.docker/config.json (credHelpers, auths, credsStore)
+func ResolveCredentials(cfg *config.RegistryConfig) (*Credentials, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("registry not configured")
+	}
+
+	if cfg.Password != "" {
+		return &Credentials{Username: cfg.Username, Password: cfg.Password, Source: "config"}, nil
+	}
+
+	if cfg.PasswordCommand != "" {
+		password, err := runPasswordCommand(cfg.PasswordCommand)
+		if err != nil {
+			return nil, err
+		}
+		return &Credentials{Username: cfg.Username, Password: password, Source: "password_command"}, nil
+	}
+
+	if cfg.CredentialHelper != "" {
+		creds, err := runCredentialHelper(cfg.CredentialHelper, authKey(cfg.Server))
+		if err != nil {
+			return nil, err
+		}
+		return withUsername(creds, cfg.Username), nil
+	}
+
+	creds, err := dockerConfigCredentials(cfg.Server)
+	if err != nil {
+		return nil, err
+	}
+	if creds == nil {
+		return nil, fmt.Errorf("no credentials found for %s (set password, password_command or credential_helper, or run docker login)", serverName(cfg.Server))
+	}
+	return withUsername(creds, cfg.Username), nil
+}
+
+// HasDockerConfigAuth checks if ~/.docker/config.json has credentials for a registry
+func HasDockerConfigAuth(server string) bool {
+	dockerCfg, err := loadDockerConfig()
+	if err != nil || dockerCfg == nil {
+		return false
+	}
+
+	key := authKey(server)
+	if dockerCfg.CredHelpers[key] != "" {
+		return true
+	}
+	if _, ok := dockerCfg.findAuth(key); ok {
+		return true
+	}
+	return false
+}
+
+// dockerConfig is the subset of ~/.docker/config.json podlift reads
+type dockerConfig struct {
+	Auths       map[string]dockerAuth `json:"auths"`
+	CredsStore  string                `json:"credsStore"`
+	CredHelpers map[string]string     `json:"credHelpers"`
+}
+
+type dockerAuth struct {
+	Auth     string `json:"auth"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// findAuth looks up an auths entry, ignoring scheme and path differences in keys
+func (d *dockerConfig) findAuth(key string) (dockerAuth, bool) {
+	for k, auth := range d.Auths {
+		if normalizeAuthKey(k) == normalizeAuthKey(key) {
+			return auth, true
+		}
+	}
+	return dockerAuth{}, false
+}
+
+// dockerConfigCredentials reads credentials stored by `docker login`
+// Returns nil credentials (and no error) if nothing is stored for the server
+func dockerConfigCredentials(server string) (*Credentials, error) {
+	dockerCfg, err := loadDockerConfig()
+	if err != nil {
+		return nil, err
+	}
+	if dockerCfg == nil {
+		return nil, nil
+	}
+
+	key := authKey(server)
+
+	// Per-registry helper takes precedence
+	if helper := dockerCfg.CredHelpers[key]; helper != "" {
+		return runCredentialHelper(helper, key)
+	}
+
+	if auth, ok := dockerCfg.findAuth(key); ok {
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("invalid auth entry for %s in docker config: %w", key, err)
+			}
+			parts := strings.SplitN(string(decoded), ":", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("invalid auth entry for %s in docker config", key)
+			}
+			return &Credentials{Username: parts[0], Password: parts[1], Source: "docker config"}, nil
+		}
+		if auth.Password != "" {
+			return &Credentials{Username: auth.Username, Password: auth.Password, Source: "docker config"}, nil
+		}
+
+		// Empty entry means credentials live in the global credential store
+		if dockerCfg.CredsStore != "" {
+			return runCredentialHelper(dockerCfg.CredsStore, key)
+		}
+	}
+
+	return nil, nil
+}
+
+// loadDockerConfig reads $DOCKER_CONFIG/config.json or ~/.docker/config.json
+// Returns nil if the file doesn't exist
+func loadDockerConfig() (*dockerConfig, error) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read docker config: %w", err)
+	}
+
+	var dockerCfg dockerConfig
+	if err := json.Unmarshal(data, &dockerCfg); err != nil {
+		return nil, fmt.Errorf("failed to parse docker config: %w", err)
+	}
+	return &dockerCfg, nil
+}
+
+// runPasswordCommand runs password_command locally and returns its output
+func runPasswordCommand(command string) (string, error) {
+	cmd := exec.Command("sh", "-c", command)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("password_command failed: %w\nOutput: %s", err, stderr.String())
+	}
+
+	password := strings.TrimRight(string(output), "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("password_command returned an empty password")
+	}
+	return password, nil
+}
+
+// runCredentialHelper queries docker-credential-<helper> using the Docker credential helper protocol
+func runCredentialHelper(helper, serverURL string) (*Credentials, error) {
+	program := "docker-credential-" + helper
+
+	cmd := exec.Command(program, "get")
+	cmd.Stdin = strings.NewReader(serverURL)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("credential helper %s failed: %w\nOutput: %s%s", program, err, string(output), stderr.String())
+	}
+
+	var result struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("credential helper %s returned invalid output: %w", program, err)
+	}
+
+	return &Credentials{Username: result.Username, Password: result.Secret, Source: program}, nil
+}
+
+// withUsername prefers the username from podlift.yml over the stored one
+func withUsername(creds *Credentials, username string) *Credentials {
+	if username != "" {
+		creds.Username = username
+	}
+	return creds
+}
+
+// serverName returns the registry server, defaulting to Docker Hub
+func serverName(server string) string {
+	if server == "" {
+		return "docker.io"
+	}
+	return server
+}
+
+// authKey returns the key Docker uses to store credentials for a server
+func authKey(server string) string {
+	switch server {
+	case "", "docker.io", "index.docker.io", "registry-1.docker.io":
+		return dockerHubAuthKey
+	}
+	return server
+}
+
+// normalizeAuthKey strips scheme and path so "https://ghcr.io/v1/" matches "ghcr.io"
+func normalizeAuthKey(key string) string {
+	key = strings.TrimPrefix(key, "https://")
+	key = strings.TrimPrefix(key, "http://")
+	if i := strings.Index(key, "/"); i >= 0 {
+		key = key[:i]
+	}
+	return key
+}
@@ -0,0 +1,126 @@
+package registry
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ekinertac/podlift/internal/config"
+)
+
+// writeDockerConfig writes a docker config.json into a temp DOCKER_CONFIG dir
+func writeDockerConfig(t *testing.T, content string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOCKER_CONFIG", dir)
+}
+
+// writeCredentialHelper installs a fake docker-credential-<name> on PATH
+func writeCredentialHelper(t *testing.T, name, output string) {
+	t.Helper()
+	dir := t.TempDir()
+	script := "#!/bin/sh\ncat > /dev/null\necho '" + output + "'\n"
+	if err := os.WriteFile(filepath.Join(dir, "docker-credential-"+name), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestResolveCredentials_ConfigPassword(t *testing.T) {
+	creds, err := ResolveCredentials(&config.RegistryConfig{Username: "user", Password: "pass"})
+	if err != nil {
+		t.Fatalf("ResolveCredentials() error = %v", err)
+	}
+	if creds.Username != "user" || creds.Password != "pass" {
+		t.Errorf("ResolveCredentials() = %+v", creds)
+	}
+}
+
+func TestResolveCredentials_PasswordCommand(t *testing.T) {
+	creds, err := ResolveCredentials(&config.RegistryConfig{
+		Username:        "user",
+		PasswordCommand: "printf 'from-command\\n'",
+	})
+	if err != nil {
+		t.Fatalf("ResolveCredentials() error = %v", err)
+	}
+	if creds.Password != "from-command" {
+		t.Errorf("Password = %q, want from-command", creds.Password)
+	}
+
+	_, err = ResolveCredentials(&config.RegistryConfig{Username: "user", PasswordCommand: "exit 1"})
+	if err == nil {
+		t.Error("ResolveCredentials() should fail when password_command fails")
+	}
+}
+
+func TestResolveCredentials_CredentialHelper(t *testing.T) {
+	writeCredentialHelper(t, "fake", `{"ServerURL":"ghcr.io","Username":"helper-user","Secret":"helper-secret"}`)
+
+	creds, err := ResolveCredentials(&config.RegistryConfig{Server: "ghcr.io", CredentialHelper: "fake"})
+	if err != nil {
+		t.Fatalf("ResolveCredentials() error = %v", err)
+	}
+	if creds.Username != "helper-user" || creds.Password != "helper-secret" {
+		t.Errorf("ResolveCredentials() = %+v", creds)
+	}
+}
+
+func TestResolveCredentials_DockerConfigAuths(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("stored-user:stored-pass"))
+	writeDockerConfig(t, `{"auths":{"https://ghcr.io":{"auth":"`+auth+`"}}}`)
+
+	creds, err := ResolveCredentials(&config.RegistryConfig{Server: "ghcr.io", Username: "user"})
+	if err != nil {
+		t.Fatalf("ResolveCredentials() error = %v", err)
+	}
+	// Username from podlift.yml wins over the stored one
+	if creds.Username != "user" || creds.Password != "stored-pass" {
+		t.Errorf("ResolveCredentials() = %+v", creds)
+	}
+	if !HasDockerConfigAuth("ghcr.io") {
+		t.Error("HasDockerConfigAuth() = false, want true")
+	}
+}
+
+func TestResolveCredentials_DockerConfigCredHelpers(t *testing.T) {
+	writeCredentialHelper(t, "store", `{"Username":"u","Secret":"s"}`)
+	writeDockerConfig(t, `{"credHelpers":{"registry.example.com":"store"}}`)
+
+	creds, err := ResolveCredentials(&config.RegistryConfig{Server: "registry.example.com"})
+	if err != nil {
+		t.Fatalf("ResolveCredentials() error = %v", err)
+	}
+	if creds.Password != "s" {
+		t.Errorf("Password = %q, want s", creds.Password)
+	}
+}
+
+func TestResolveCredentials_NotFound(t *testing.T) {
+	writeDockerConfig(t, `{"auths":{}}`)
+
+	_, err := ResolveCredentials(&config.RegistryConfig{Server: "ghcr.io", Username: "user"})
+	if err == nil {
+		t.Error("ResolveCredentials() should fail without any credentials")
+	}
+	if HasDockerConfigAuth("ghcr.io") {
+		t.Error("HasDockerConfigAuth() = true, want false")
+	}
+}
+
+func TestAuthKey(t *testing.T) {
+	tests := map[string]string{
+		"":          dockerHubAuthKey,
+		"docker.io": dockerHubAuthKey,
+		"ghcr.io":   "ghcr.io",
+	}
+	for server, want := range tests {
+		if got := authKey(server); got != want {
+			t.Errorf("authKey(%q) = %q, want %q", server, got, want)
+		}
+	}
+}
@@ -6,13 +6,15 @@ import (
 	"strings"
 
 	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/shell"
 	"github.com/ekinertac/podlift/internal/ssh"
 	"github.com/ekinertac/podlift/internal/ui"
 )
 
 // Client handles Docker registry operations
 type Client struct {
-	config *config.RegistryConfig
+	config      *config.RegistryConfig
+	credentials *Credentials // Resolved lazily on first login
 }
 
 // NewClient creates a new registry client
@@ -26,20 +28,22 @@ func (c *Client) Login() error {
 		return fmt.Errorf("registry not configured")
 	}
 
-	server := c.config.Server
-	if server == "" {
-		server = "docker.io"
+	creds, err := c.resolveCredentials()
+	if err != nil {
+		return err
 	}
 
+	server := serverName(c.config.Server)
+
 	fmt.Println(ui.Info(fmt.Sprintf("Logging into %s...", server)))
 
 	// Docker login
 	cmd := exec.Command("docker", "login", server, 
-		"-u", c.config.Username,
+		"-u", creds.Username,
 		"--password-stdin")
 	
 	// Pass password via stdin (more secure than command line)
-	cmd.Stdin = strings.NewReader(c.config.Password)
+	cmd.Stdin = strings.NewReader(creds.Password)
 	
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -56,20 +60,20 @@ func (c *Client) LoginRemote(client ssh.SSHClient) error {
 		return fmt.Errorf("registry not configured")
 	}
 
-	server := c.config.Server
-	if server == "" {
-		server = "docker.io"
+	creds, err := c.resolveCredentials()
+	if err != nil {
+		return err
 	}
 
+	server := serverName(c.config.Server)
+
 	fmt.Println(ui.Info(fmt.Sprintf("Logging into %s on server...", server)))
 
-	// Use echo to pass password via stdin
-	loginCmd := fmt.Sprintf(
-		"echo '%s' | sudo docker login %s -u %s --password-stdin",
-		c.config.Password, server, c.config.Username,
-	)
+	// Stream the password over the session's stdin so it never appears
+	// in the remote command line, process list or shell history
+	loginCmd := shell.Join("sudo", "docker", "login", server, "-u", creds.Username, "--password-stdin")
 
-	if _, err := client.Execute(loginCmd); err != nil {
+	if _, err := client.ExecuteWithInput(loginCmd, strings.NewReader(creds.Password)); err != nil {
 		return fmt.Errorf("registry login failed on server: %w", err)
 	}
 
@@ -77,6 +81,24 @@ func (c *Client) LoginRemote(client ssh.SSHClient) error {
 	return nil
 }
 
+// resolveCredentials resolves and caches credentials for this registry
+func (c *Client) resolveCredentials() (*Credentials, error) {
+	if c.credentials != nil {
+		return c.credentials, nil
+	}
+
+	creds, err := ResolveCredentials(c.config)
+	if err != nil {
+		return nil, err
+	}
+	if creds.Username == "" {
+		return nil, fmt.Errorf("registry username not configured for %s", serverName(c.config.Server))
+	}
+
+	c.credentials = creds
+	return creds, nil
+}
+
 // Push pushes an image to the registry
 func (c *Client) Push(imageName, tag string) error {
 	if c.config == nil {
@@ -118,7 +140,7 @@ func (c *Client) Pull(client ssh.SSHClient, imageName, tag string) error {
 	
 	fmt.Println(ui.Info(fmt.Sprintf("Pulling %s...", registryImage)))
 
-	pullCmd := fmt.Sprintf("sudo docker pull %s", registryImage)
+	pullCmd := shell.Join("sudo", "docker", "pull", registryImage)
 	output, err := client.Execute(pullCmd)
 	if err != nil {
 		return fmt.Errorf("failed to pull image: %w\nOutput: %s", err, output)
@@ -135,6 +157,13 @@ func (c *Client) GetImagePath(imageName, tag string) string {
 	}
 
 	server := c.config.Server
+	if c.config.Username == "" {
+		// Registries whose repositories aren't under a user, e.g. ECR with a credential helper
+		if server == "" || server == "docker.io" {
+			return fmt.Sprintf("%s:%s", imageName, tag)
+		}
+		return fmt.Sprintf("%s/%s:%s", server, imageName, tag)
+	}
 	if server == "" || server == "docker.io" {
 		// Docker Hub format: username/image:tag
 		return fmt.Sprintf("%s/%s:%s", c.config.Username, imageName, tag)
@@ -145,10 +174,26 @@ func (c *Client) GetImagePath(imageName, tag string) string {
 }
 
 // IsConfigured checks if registry is configured
+// Credentials may come from the config, a password command, a credential
+// helper, or an existing `docker login` in ~/.docker/config.json. A credential
+// helper (e.g. ecr-login) supplies the username itself.
 func IsConfigured(cfg *config.Config) bool {
-	return cfg.Registry != nil && 
-		cfg.Registry.Username != "" && 
-		cfg.Registry.Password != ""
+	r := cfg.Registry
+	if r == nil {
+		return false
+	}
+	if r.CredentialHelper != "" {
+		return true
+	}
+	if r.Username == "" {
+		return false
+	}
+
+	if r.Password != "" || r.PasswordCommand != "" {
+		return true
+	}
+
+	return HasDockerConfigAuth(r.Server)
 }
 
 // progressWriter shows docker push/pull progress
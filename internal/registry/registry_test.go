@@ -1,6 +1,8 @@
 package registry
 
 import (
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/ekinertac/podlift/internal/config"
@@ -55,6 +57,16 @@ func TestGetImagePath(t *testing.T) {
 			tag:       "v2.0",
 			want:      "registry.example.com/admin/myapp:v2.0",
 		},
+		{
+			name: "Credential helper without username",
+			registry: &config.RegistryConfig{
+				Server:           "123456789012.dkr.ecr.eu-west-1.amazonaws.com",
+				CredentialHelper: "ecr-login",
+			},
+			imageName: "myapp",
+			tag:       "v1",
+			want:      "123456789012.dkr.ecr.eu-west-1.amazonaws.com/myapp:v1",
+		},
 		{
 			name: "No registry",
 			registry: nil,
@@ -92,6 +104,16 @@ func TestIsConfigured(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "credential helper without username",
+			config: &config.Config{
+				Registry: &config.RegistryConfig{
+					Server:           "123456789012.dkr.ecr.eu-west-1.amazonaws.com",
+					CredentialHelper: "ecr-login",
+				},
+			},
+			want: true,
+		},
 		{
 			name: "no registry",
 			config: &config.Config{
@@ -123,6 +145,9 @@ func TestIsConfigured(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			// Isolate from the developer's own `docker login` state
+			t.Setenv("DOCKER_CONFIG", t.TempDir())
+
 			got := IsConfigured(tt.config)
 			if got != tt.want {
 				t.Errorf("IsConfigured() = %v, want %v", got, tt.want)
@@ -150,3 +175,36 @@ func TestLogin_RequiresConfig(t *testing.T) {
 	}
 }
 
+
+func TestLoginRemote_StreamsPasswordOverStdin(t *testing.T) {
+	password := `it's a "quoted" $ecret`
+
+	var command, stdin string
+	mockSSH := ssh.NewMockClient()
+	mockSSH.ExecuteWithInputFunc = func(cmd string, input io.Reader) (string, error) {
+		command = cmd
+		data, _ := io.ReadAll(input)
+		stdin = string(data)
+		return "Login Succeeded", nil
+	}
+
+	client := NewClient(&config.RegistryConfig{
+		Server:   "ghcr.io",
+		Username: "user",
+		Password: password,
+	})
+
+	if err := client.LoginRemote(mockSSH); err != nil {
+		t.Fatalf("LoginRemote() error = %v", err)
+	}
+
+	if strings.Contains(command, "ecret") {
+		t.Errorf("password leaked into remote command: %s", command)
+	}
+	if !strings.Contains(command, "--password-stdin") {
+		t.Errorf("command should use --password-stdin, got: %s", command)
+	}
+	if stdin != password {
+		t.Errorf("stdin = %q, want %q", stdin, password)
+	}
+}
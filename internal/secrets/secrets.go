@@ -0,0 +1,271 @@
+// Package secrets manages the encrypted secrets file (secrets.enc)
+//
+// Secrets are stored as KEY=VALUE lines, encrypted with XChaCha20-Poly1305.
+// The encrypted file is safe to commit; the key lives in secrets.key (gitignored)
+// or the PODLIFT_SECRETS_KEY environment variable.
+package secrets
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const (
+	// FileName is the encrypted secrets file, next to podlift.yml
+	FileName = "secrets.enc"
+
+	// KeyFileName is the local key file, next to podlift.yml (never commit it)
+	KeyFileName = "secrets.key"
+
+	// KeyEnvVar holds the base64 key, e.g. in CI
+	KeyEnvVar = "PODLIFT_SECRETS_KEY"
+
+	// header identifies the file format and is authenticated with the ciphertext
+	header = "podlift-secrets:v1"
+)
+
+// ErrNoKey is returned when neither PODLIFT_SECRETS_KEY nor secrets.key is available
+var ErrNoKey = errors.New("no secrets key found (set " + KeyEnvVar + " or create " + KeyFileName + " with: podlift secrets init)")
+
+// keyNamePattern restricts secret names to valid environment variable names
+var keyNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// GenerateKey returns a new random 256-bit key
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return key, nil
+}
+
+// EncodeKey encodes a key for secrets.key or PODLIFT_SECRETS_KEY
+func EncodeKey(key []byte) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// ParseKey decodes a base64 key
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid secrets key: %w", err)
+	}
+	if len(key) != chacha20poly1305.KeySize {
+		return nil, fmt.Errorf("invalid secrets key: expected %d bytes, got %d", chacha20poly1305.KeySize, len(key))
+	}
+	return key, nil
+}
+
+// LoadKey reads the key from PODLIFT_SECRETS_KEY, or from secrets.key in dir
+func LoadKey(dir string) ([]byte, error) {
+	if encoded := os.Getenv(KeyEnvVar); encoded != "" {
+		return ParseKey(encoded)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, KeyFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoKey
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", KeyFileName, err)
+	}
+	return ParseKey(string(data))
+}
+
+// WriteKey writes the key to secrets.key in dir, readable only by the owner
+func WriteKey(dir string, key []byte) error {
+	path := filepath.Join(dir, KeyFileName)
+	if err := os.WriteFile(path, []byte(EncodeKey(key)+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", KeyFileName, err)
+	}
+	return nil
+}
+
+// Encrypt encrypts plaintext into the secrets.enc format
+func Encrypt(key, plaintext []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secrets key: %w", err)
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(header))
+	return []byte(header + "\n" + base64.StdEncoding.EncodeToString(sealed) + "\n"), nil
+}
+
+// Decrypt decrypts data in the secrets.enc format
+func Decrypt(key, data []byte) ([]byte, error) {
+	lines := strings.SplitN(strings.TrimSpace(string(data)), "\n", 2)
+	if len(lines) != 2 || lines[0] != header {
+		return nil, fmt.Errorf("not a podlift secrets file")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return nil, fmt.Errorf("corrupt secrets file: %w", err)
+	}
+
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secrets key: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("corrupt secrets file: too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(header))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets (wrong key?)")
+	}
+	return plaintext, nil
+}
+
+// Load decrypts a secrets file into a map
+func Load(path string, key []byte) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := Decrypt(key, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return Parse(plaintext)
+}
+
+// Save encrypts values and writes them to a secrets file
+func Save(path string, key []byte, values map[string]string) error {
+	data, err := Encrypt(key, Format(values))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// rename is os.Rename, replaced in tests
+var rename = os.Rename
+
+// Rotate re-encrypts the secrets file in dir with newKey and stores newKey in secrets.key
+// oldKey is the key the file is encrypted with now. It's put back if the file can't be
+// replaced, so secrets.key never ends up with a key that doesn't open secrets.enc.
+func Rotate(dir string, oldKey, newKey []byte, values map[string]string) error {
+	path := filepath.Join(dir, FileName)
+
+	// Write the re-encrypted file beside the old one, then swap once the new key is stored
+	tmpPath := path + ".tmp"
+	if err := Save(tmpPath, newKey, values); err != nil {
+		return err
+	}
+	if err := WriteKey(dir, newKey); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := rename(tmpPath, path); err != nil {
+		if restoreErr := WriteKey(dir, oldKey); restoreErr != nil {
+			// Keep the new file: it's the one secrets.key opens now
+			return fmt.Errorf("failed to replace %s: %w (restoring the old key also failed, %s is encrypted with the new key)", FileName, err, filepath.Base(tmpPath))
+		}
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace %s: %w", FileName, err)
+	}
+	return nil
+}
+
+// Parse reads KEY=VALUE lines (the decrypted form)
+// Double-quoted values support escapes (\n, \"), single-quoted values are literal.
+func Parse(data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", i+1)
+		}
+
+		name := strings.TrimSpace(parts[0])
+		if err := ValidateName(name); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		value := strings.TrimSpace(parts[1])
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid quoted value: %w", i+1, err)
+			}
+			value = unquoted
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		}
+
+		values[name] = value
+	}
+
+	return values, nil
+}
+
+// Format writes values as sorted KEY=VALUE lines, quoting values that need it
+func Format(values map[string]string) []byte {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		value := values[name]
+		if needsQuoting(value) {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&b, "%s=%s\n", name, value)
+	}
+	return []byte(b.String())
+}
+
+// ValidateName checks that a secret name is a valid environment variable name
+func ValidateName(name string) error {
+	if !keyNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q (use letters, digits and underscores)", name)
+	}
+	return nil
+}
+
+// needsQuoting reports whether a value would not survive a plain KEY=VALUE line
+func needsQuoting(value string) bool {
+	if value == "" {
+		return false
+	}
+	if strings.TrimSpace(value) != value {
+		return true
+	}
+	if strings.ContainsAny(value, "\n\r\"'#\\") {
+		return true
+	}
+	return false
+}
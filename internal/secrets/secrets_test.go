@@ -0,0 +1,215 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := Encrypt(key, []byte("SECRET_KEY=abc\n"))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if strings.Contains(string(data), "abc") {
+		t.Error("Encrypt() output contains plaintext")
+	}
+	if !strings.HasPrefix(string(data), header+"\n") {
+		t.Errorf("Encrypt() output missing header: %q", data)
+	}
+
+	plaintext, err := Decrypt(key, data)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if string(plaintext) != "SECRET_KEY=abc\n" {
+		t.Errorf("Decrypt() = %q", plaintext)
+	}
+
+	// Wrong key
+	otherKey, _ := GenerateKey()
+	if _, err := Decrypt(otherKey, data); err == nil {
+		t.Error("Decrypt() with wrong key should fail")
+	}
+
+	// Tampered ciphertext
+	tampered := []byte(strings.Replace(string(data), "\n", "\nAA", 1))
+	if _, err := Decrypt(key, tampered); err == nil {
+		t.Error("Decrypt() of tampered data should fail")
+	}
+
+	// Not a secrets file
+	if _, err := Decrypt(key, []byte("SECRET_KEY=abc")); err == nil {
+		t.Error("Decrypt() of plaintext should fail")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	values := map[string]string{
+		"PLAIN":     "value",
+		"EMPTY":     "",
+		"MULTILINE": "-----BEGIN KEY-----\nabc\n-----END KEY-----",
+		"QUOTES":    `say "hi" it's`,
+		"SPACES":    "  padded  ",
+		"HASH":      "a#b",
+	}
+
+	parsed, err := Parse(Format(values))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(parsed) != len(values) {
+		t.Fatalf("Parse() returned %d values, want %d", len(parsed), len(values))
+	}
+	for k, v := range values {
+		if parsed[k] != v {
+			t.Errorf("%s = %q, want %q", k, parsed[k], v)
+		}
+	}
+
+	// Output is sorted so diffs in edit are stable
+	if !strings.HasPrefix(string(Format(values)), "EMPTY=\nHASH=") {
+		t.Errorf("Format() not sorted: %q", Format(values))
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []string{
+		"NO_EQUALS",
+		"1BAD=value",
+		"BAD-NAME=value",
+		`QUOTED="unterminated\"`,
+	}
+
+	for _, input := range tests {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("Parse(%q) should fail", input)
+		}
+	}
+
+	values, err := Parse([]byte("# comment\n\nA=1\nB='literal \\n'\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if values["A"] != "1" || values["B"] != `literal \n` {
+		t.Errorf("Parse() = %v", values)
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(KeyEnvVar, "")
+
+	if _, err := LoadKey(dir); err != ErrNoKey {
+		t.Errorf("LoadKey() error = %v, want ErrNoKey", err)
+	}
+
+	fileKey, _ := GenerateKey()
+	if err := WriteKey(dir, fileKey); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(filepath.Join(dir, KeyFileName))
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("key file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	key, err := LoadKey(dir)
+	if err != nil || EncodeKey(key) != EncodeKey(fileKey) {
+		t.Errorf("LoadKey() = %v, %v, want key from file", key, err)
+	}
+
+	// Env var takes precedence over the key file
+	envKey, _ := GenerateKey()
+	t.Setenv(KeyEnvVar, EncodeKey(envKey))
+	key, err = LoadKey(dir)
+	if err != nil || EncodeKey(key) != EncodeKey(envKey) {
+		t.Errorf("LoadKey() = %v, %v, want key from %s", key, err, KeyEnvVar)
+	}
+
+	t.Setenv(KeyEnvVar, "dG9vLXNob3J0")
+	if _, err := LoadKey(dir); err == nil {
+		t.Error("LoadKey() should reject a short key")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	key, _ := GenerateKey()
+	path := filepath.Join(t.TempDir(), FileName)
+
+	if err := Save(path, key, map[string]string{"DB_PASSWORD": "hunter2"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	values, err := Load(path, key)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if values["DB_PASSWORD"] != "hunter2" {
+		t.Errorf("Load() = %v", values)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(KeyEnvVar, "")
+	oldKey, _ := GenerateKey()
+	newKey, _ := GenerateKey()
+	values := map[string]string{"DB_PASSWORD": "hunter2"}
+	if err := WriteKey(dir, oldKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(filepath.Join(dir, FileName), oldKey, values); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Rotate(dir, oldKey, newKey, values); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	key, _ := LoadKey(dir)
+	if EncodeKey(key) != EncodeKey(newKey) {
+		t.Error("Rotate() should store the new key")
+	}
+	if got, err := Load(filepath.Join(dir, FileName), key); err != nil || got["DB_PASSWORD"] != "hunter2" {
+		t.Errorf("Load() with the new key = %v, %v", got, err)
+	}
+}
+
+func TestRotate_RenameFails(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(KeyEnvVar, "")
+	oldKey, _ := GenerateKey()
+	newKey, _ := GenerateKey()
+	values := map[string]string{"DB_PASSWORD": "hunter2"}
+	if err := WriteKey(dir, oldKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(filepath.Join(dir, FileName), oldKey, values); err != nil {
+		t.Fatal(err)
+	}
+
+	rename = func(string, string) error { return errors.New("device busy") }
+	defer func() { rename = os.Rename }()
+
+	if err := Rotate(dir, oldKey, newKey, values); err == nil {
+		t.Fatal("Rotate() should fail when the file can't be replaced")
+	}
+
+	// secrets.key must still open secrets.enc
+	key, err := LoadKey(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Load(filepath.Join(dir, FileName), key); err != nil || got["DB_PASSWORD"] != "hunter2" {
+		t.Errorf("Load() after a failed rotate = %v, %v", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, FileName+".tmp")); !os.IsNotExist(err) {
+		t.Errorf("temp file should be removed, stat error = %v", err)
+	}
+}
@@ -14,6 +14,8 @@ func InstallDocker(client ssh.SSHClient) error {
 	version, err := client.CheckDocker()
 	if err == nil {
 		fmt.Println(ui.Success(fmt.Sprintf("Docker %s already installed", strings.TrimSpace(version))))
+		// Containers only come back after a reboot if the daemon starts on boot
+		client.Execute("sudo systemctl enable docker")
 		return nil
 	}
 
@@ -0,0 +1,113 @@
+package setup
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/shell"
+	"github.com/ekinertac/podlift/internal/ssh"
+	"github.com/ekinertac/podlift/internal/ui"
+)
+
+// DaemonConfigPath is docker's daemon configuration file
+const DaemonConfigPath = "/etc/docker/daemon.json"
+
+// ConfigureLogRotation makes logging the default of the docker daemon
+// Other settings in daemon.json are kept. Docker is restarted only when the file
+// changes, and the previous file is restored if docker doesn't come back.
+// The defaults apply to containers created afterwards.
+func ConfigureLogRotation(client ssh.SSHClient, logging config.LoggingConfig) error {
+	existing := ""
+	exists := false
+	if _, err := client.Execute(shell.Join("sudo", "test", "-f", DaemonConfigPath)); err == nil {
+		exists = true
+		output, err := client.Execute(shell.Join("sudo", "cat", DaemonConfigPath))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", DaemonConfigPath, err)
+		}
+		existing = output
+	}
+
+	content, changed, err := daemonConfigWithLogging(existing, logging)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		fmt.Println(ui.Info(fmt.Sprintf("  Log rotation already configured (%s)", describeLogging(logging))))
+		return nil
+	}
+
+	backupPath := DaemonConfigPath + ".podlift.bak"
+	if exists {
+		if _, err := client.Execute(shell.Join("sudo", "cp", DaemonConfigPath, backupPath)); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", DaemonConfigPath, err)
+		}
+	} else if _, err := client.Execute(shell.Join("sudo", "mkdir", "-p", "/etc/docker")); err != nil {
+		return fmt.Errorf("failed to create /etc/docker: %w", err)
+	}
+
+	if err := client.WriteFile(content, DaemonConfigPath); err != nil {
+		return fmt.Errorf("failed to write %s: %w", DaemonConfigPath, err)
+	}
+
+	fmt.Println(ui.Info("  Restarting Docker (running containers restart too)..."))
+	if output, err := client.Execute(shell.Join("sudo", "systemctl", "restart", "docker")); err != nil {
+		// Put the previous config back so docker isn't left down
+		restoreCmd := shell.Join("sudo", "rm", "-f", DaemonConfigPath)
+		if exists {
+			restoreCmd = shell.Join("sudo", "mv", backupPath, DaemonConfigPath)
+		}
+		client.Execute(restoreCmd + " && " + shell.Join("sudo", "systemctl", "restart", "docker"))
+		return fmt.Errorf("docker failed to restart with the new %s (previous config restored): %w\n%s", DaemonConfigPath, err, output)
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Log rotation configured (%s)", describeLogging(logging))))
+	return nil
+}
+
+// daemonConfigWithLogging sets log-driver and log-opts in a daemon.json, keeping other settings
+// Reports whether the logging settings changed.
+func daemonConfigWithLogging(existing string, logging config.LoggingConfig) (string, bool, error) {
+	daemon := map[string]interface{}{}
+	if strings.TrimSpace(existing) != "" {
+		if err := json.Unmarshal([]byte(existing), &daemon); err != nil {
+			return "", false, fmt.Errorf("%s is not valid JSON, fix it first: %w", DaemonConfigPath, err)
+		}
+	}
+
+	// log-opts values must be strings
+	opts := map[string]interface{}{}
+	for key, value := range logging.DockerOptions() {
+		opts[key] = value
+	}
+
+	oldDriver, oldOpts := daemon["log-driver"], daemon["log-opts"]
+	daemon["log-driver"] = logging.Driver
+	if len(opts) > 0 {
+		daemon["log-opts"] = opts
+	} else {
+		delete(daemon, "log-opts")
+	}
+	changed := !reflect.DeepEqual(oldDriver, daemon["log-driver"]) || !reflect.DeepEqual(oldOpts, daemon["log-opts"])
+
+	content, err := json.MarshalIndent(daemon, "", "  ")
+	if err != nil {
+		return "", false, err
+	}
+	return string(content) + "\n", changed, nil
+}
+
+// describeLogging summarizes a logging config, e.g. json-file, max-size=10m, max-file=3
+func describeLogging(logging config.LoggingConfig) string {
+	parts := []string{logging.Driver}
+	if logging.MaxSize != "" {
+		parts = append(parts, "max-size="+logging.MaxSize)
+	}
+	if logging.MaxFile != 0 {
+		parts = append(parts, fmt.Sprintf("max-file=%d", logging.MaxFile))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,101 @@
+package setup
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ekinertac/podlift/internal/config"
+	"github.com/ekinertac/podlift/internal/ssh"
+)
+
+var defaultLogging = config.LoggingConfig{Driver: "json-file", MaxSize: "10m", MaxFile: 3}
+
+func TestDaemonConfigWithLogging(t *testing.T) {
+	content, changed, err := daemonConfigWithLogging(`{"registry-mirrors": ["https://mirror.example.com"], "log-driver": "json-file"}`, defaultLogging)
+	if err != nil || !changed {
+		t.Fatalf("daemonConfigWithLogging() changed = %v, error = %v", changed, err)
+	}
+
+	var daemon map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &daemon); err != nil {
+		t.Fatalf("result is not JSON: %v\n%s", err, content)
+	}
+	want := map[string]interface{}{
+		"registry-mirrors": []interface{}{"https://mirror.example.com"},
+		"log-driver":       "json-file",
+		"log-opts":         map[string]interface{}{"max-size": "10m", "max-file": "3"},
+	}
+	if !reflect.DeepEqual(daemon, want) {
+		t.Errorf("daemon.json = %v, want %v", daemon, want)
+	}
+
+	// Applying the same settings again changes nothing
+	if _, changed, _ := daemonConfigWithLogging(content, defaultLogging); changed {
+		t.Error("daemonConfigWithLogging() should not change an up-to-date daemon.json")
+	}
+
+	// Switching to a driver without options drops the old log-opts
+	content, changed, _ = daemonConfigWithLogging(content, config.LoggingConfig{Driver: "journald"})
+	if !changed || strings.Contains(content, "log-opts") {
+		t.Errorf("daemon.json = %s, want journald without log-opts", content)
+	}
+
+	if _, _, err := daemonConfigWithLogging("{not json", defaultLogging); err == nil {
+		t.Error("daemonConfigWithLogging() should refuse to overwrite an invalid daemon.json")
+	}
+}
+
+func TestConfigureLogRotation(t *testing.T) {
+	var commands []string
+	var written string
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		commands = append(commands, cmd)
+		if strings.Contains(cmd, "test -f") {
+			return "", errors.New("exit status 1") // No daemon.json yet
+		}
+		return "", nil
+	}
+	client.WriteFileFunc = func(content, path string) error {
+		written = content
+		return nil
+	}
+
+	if err := ConfigureLogRotation(client, defaultLogging); err != nil {
+		t.Fatalf("ConfigureLogRotation() error = %v", err)
+	}
+	if !strings.Contains(written, `"max-size": "10m"`) {
+		t.Errorf("written daemon.json = %s", written)
+	}
+	if last := commands[len(commands)-1]; last != "sudo systemctl restart docker" {
+		t.Errorf("last command = %q, want a docker restart", last)
+	}
+}
+
+func TestConfigureLogRotation_RestoresOnFailedRestart(t *testing.T) {
+	var commands []string
+	client := ssh.NewMockClient()
+	client.ExecuteFunc = func(cmd string) (string, error) {
+		commands = append(commands, cmd)
+		switch {
+		case strings.Contains(cmd, "cat /etc/docker/daemon.json"):
+			return `{"debug": true}`, nil
+		case cmd == "sudo systemctl restart docker":
+			return "Job for docker.service failed", errors.New("exit status 1")
+		}
+		return "", nil
+	}
+
+	err := ConfigureLogRotation(client, defaultLogging)
+	if err == nil || !strings.Contains(err.Error(), "previous config restored") {
+		t.Fatalf("ConfigureLogRotation() error = %v, want restore", err)
+	}
+
+	restore := "sudo mv /etc/docker/daemon.json.podlift.bak /etc/docker/daemon.json && sudo systemctl restart docker"
+	if commands[len(commands)-1] != restore {
+		t.Errorf("last command = %q, want %q", commands[len(commands)-1], restore)
+	}
+}
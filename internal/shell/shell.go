@@ -0,0 +1,143 @@
+// Package shell builds POSIX shell command lines from argument lists
+//
+// Remote commands run through `sh -c` on the server, so every value that
+// comes from configuration or the user must be quoted. Build commands from
+// arguments instead of concatenating strings:
+//
+//	shell.Command("sudo", "docker", "run", "-d").Flag("--name", name).String()
+package shell
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Quote returns s quoted for a POSIX shell
+// Safe strings are returned unchanged, everything else is single-quoted.
+func Quote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if isSafe(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Join quotes each argument and joins them with spaces
+func Join(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = Quote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// Split parses a command line into arguments the way a POSIX shell would
+// (whitespace, single quotes, double quotes and backslash escapes).
+// Shell operators like ; | && are not interpreted: they become plain arguments.
+func Split(s string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		case c == '\'':
+			inArg = true
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote in %q", s)
+			}
+			current.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			inArg = true
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				// Inside double quotes, backslash only escapes $ ` " \ and newline
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("$`\"\\\n", s[i+1]) >= 0 {
+					i++
+					if s[i] == '\n' {
+						continue
+					}
+				}
+				current.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, fmt.Errorf("unterminated double quote in %q", s)
+			}
+		case c == '\\':
+			if i+1 >= len(s) {
+				return nil, fmt.Errorf("trailing backslash in %q", s)
+			}
+			i++
+			if s[i] == '\n' {
+				continue // Line continuation
+			}
+			inArg = true
+			current.WriteByte(s[i])
+		default:
+			inArg = true
+			current.WriteByte(c)
+		}
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// Cmd is a shell command built from arguments
+type Cmd struct {
+	args []string
+}
+
+// Command starts a command with the given (unquoted) arguments
+func Command(args ...string) *Cmd {
+	return &Cmd{args: append([]string(nil), args...)}
+}
+
+// Arg appends arguments
+func (c *Cmd) Arg(args ...string) *Cmd {
+	c.args = append(c.args, args...)
+	return c
+}
+
+// Flag appends a flag followed by its value, e.g. Flag("--name", "web") → --name web
+func (c *Cmd) Flag(flag, value string) *Cmd {
+	c.args = append(c.args, flag, value)
+	return c
+}
+
+// Args returns the unquoted arguments
+func (c *Cmd) Args() []string {
+	return append([]string(nil), c.args...)
+}
+
+// String returns the quoted command line
+func (c *Cmd) String() string {
+	return Join(c.args...)
+}
+
+// isSafe reports whether s contains only characters that never need quoting
+func isSafe(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.IndexByte("-_./:=@%+,", c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,140 @@
+package shell
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", "''"},
+		{"simple", "simple"},
+		{"myapp:v1.2.3", "myapp:v1.2.3"},
+		{"/var/lib/data", "/var/lib/data"},
+		{"KEY=value", "KEY=value"},
+		{"has space", "'has space'"},
+		{"it's", `'it'\''s'`},
+		{"a;rm -rf /", "'a;rm -rf /'"},
+		{"$(whoami)", "'$(whoami)'"},
+		{"`id`", "'`id`'"},
+		{"line\nbreak", "'line\nbreak'"},
+	}
+
+	for _, tt := range tests {
+		if got := Quote(tt.in); got != tt.want {
+			t.Errorf("Quote(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"gunicorn app:app", []string{"gunicorn", "app:app"}, false},
+		{"  spaced   out  ", []string{"spaced", "out"}, false},
+		{`sh -c 'echo "hi"; date'`, []string{"sh", "-c", `echo "hi"; date`}, false},
+		{`echo "a \"b\" \$c \d"`, []string{"echo", `a "b" $c \d`}, false},
+		{`a\ b c`, []string{"a b", "c"}, false},
+		{`''`, []string{""}, false},
+		{`x''y`, []string{"xy"}, false},
+		{`'unterminated`, nil, true},
+		{`"unterminated`, nil, true},
+		{`trailing\`, nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := Split(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Split(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Split(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCommand(t *testing.T) {
+	got := Command("sudo", "docker", "run").
+		Flag("--name", "web 1").
+		Flag("--label", "owner=it's me").
+		Arg("myapp:latest").
+		String()
+
+	want := `sudo docker run --name 'web 1' --label 'owner=it'\''s me' myapp:latest`
+	if got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+// TestJoin_Shell checks quoted arguments against a real shell
+func TestJoin_Shell(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	args := []string{"plain", "", "with space", "it's", `"double"`, "a;b|c&&d", "$HOME", "`id`", "back\\slash", "new\nline", "*"}
+	script := `for a in "$@"; do printf '%s\0' "$a"; done`
+
+	out, err := exec.Command("sh", "-c", "set -- "+Join(args...)+"; "+script).Output()
+	if err != nil {
+		t.Fatalf("sh failed: %v", err)
+	}
+
+	got := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	if !reflect.DeepEqual(got, args) {
+		t.Errorf("shell saw %q, want %q", got, args)
+	}
+}
+
+func FuzzQuote(f *testing.F) {
+	for _, seed := range []string{"", "plain", "it's", "a b", "$(id)", "'\"\\", "KEY=va lue", "/data:/var/lib/x"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		if strings.IndexByte(s, 0) >= 0 {
+			t.Skip("NUL can't appear in a shell argument")
+		}
+
+		got, err := Split(Quote(s))
+		if err != nil {
+			t.Fatalf("Split(Quote(%q)) error = %v", s, err)
+		}
+		if len(got) != 1 || got[0] != s {
+			t.Fatalf("Split(Quote(%q)) = %q", s, got)
+		}
+	})
+}
+
+func FuzzJoinSplit(f *testing.F) {
+	f.Add("KEY", "va lue; rm -rf /", "/host path:/data")
+	f.Add("", "'", `"`)
+	f.Add("$(id)", "`id`", "a\nb")
+
+	f.Fuzz(func(t *testing.T, a, b, c string) {
+		args := []string{a, b, c}
+		for _, arg := range args {
+			if strings.IndexByte(arg, 0) >= 0 {
+				t.Skip("NUL can't appear in a shell argument")
+			}
+		}
+
+		got, err := Split(Join(args...))
+		if err != nil {
+			t.Fatalf("Split(Join(%q)) error = %v", args, err)
+		}
+		if !reflect.DeepEqual(got, args) {
+			t.Fatalf("Split(Join(%q)) = %q", args, got)
+		}
+	})
+}
@@ -2,12 +2,14 @@ package ssh
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"time"
 
+	"github.com/ekinertac/podlift/internal/shell"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -149,6 +151,134 @@ func (c *Client) ExecuteWithOutput(command string, stdout, stderr io.Writer) err
 	return nil
 }
 
+// ExitStatus returns the exit status of a remote command from an Execute* error
+// ok is false if the command didn't run to an exit status (e.g. the connection failed).
+func ExitStatus(err error) (status int, ok bool) {
+	var exitErr interface{ ExitStatus() int }
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), true
+	}
+	return 0, false
+}
+
+// ExecuteWithInput runs a command with stdin streamed over the SSH session
+// Use this for secrets (passwords, keys) so they never appear on the remote command line
+func (c *Client) ExecuteWithInput(command string, stdin io.Reader) (string, error) {
+	if !c.connected {
+		if err := c.Connect(); err != nil {
+			return "", err
+		}
+	}
+
+	session, err := c.client.NewSession()
+	if err != nil {
+		return "", fmt.Errorf("failed to create session: %w", err)
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdin = stdin
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+
+	if err := session.Run(command); err != nil {
+		return "", fmt.Errorf("command failed: %w\nstdout: %s\nstderr: %s",
+			err, stdout.String(), stderr.String())
+	}
+
+	return stdout.String(), nil
+}
+
+// TerminalSize is the size of a terminal in characters
+type TerminalSize struct {
+	Width  int
+	Height int
+}
+
+// InteractiveOptions wires a remote command to a local terminal or pipes
+type InteractiveOptions struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	TTY    bool                // Request a PTY; the remote side merges stderr into stdout
+	Term   string              // TERM of the PTY (default: xterm)
+	Size   TerminalSize        // Initial size of the PTY
+	Resize <-chan TerminalSize // New sizes of the local terminal, forwarded to the PTY
+}
+
+// ExecuteInteractive runs a command with stdin forwarded and output streamed, optionally on a PTY
+// The returned error carries the remote exit status (see ExitStatus).
+func (c *Client) ExecuteInteractive(command string, opts InteractiveOptions) error {
+	if !c.connected {
+		if err := c.Connect(); err != nil {
+			return err
+		}
+	}
+
+	session, err := c.client.NewSession()
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	defer session.Close()
+
+	session.Stdout = opts.Stdout
+	session.Stderr = opts.Stderr
+
+	if opts.TTY {
+		term := opts.Term
+		if term == "" {
+			term = "xterm"
+		}
+		modes := ssh.TerminalModes{
+			ssh.ECHO:          1,
+			ssh.TTY_OP_ISPEED: 14400,
+			ssh.TTY_OP_OSPEED: 14400,
+		}
+		if err := session.RequestPty(term, opts.Size.Height, opts.Size.Width, modes); err != nil {
+			return fmt.Errorf("failed to request PTY: %w", err)
+		}
+	}
+
+	// Copy stdin ourselves: with session.Stdin, Wait blocks until the next local keypress
+	if opts.Stdin != nil {
+		stdin, err := session.StdinPipe()
+		if err != nil {
+			return fmt.Errorf("failed to open stdin: %w", err)
+		}
+		go func() {
+			io.Copy(stdin, opts.Stdin)
+			stdin.Close()
+		}()
+	}
+
+	if err := session.Start(command); err != nil {
+		return fmt.Errorf("failed to start command: %w", err)
+	}
+
+	if opts.Resize != nil {
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			for {
+				select {
+				case size, ok := <-opts.Resize:
+					if !ok {
+						return
+					}
+					session.WindowChange(size.Height, size.Width)
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	if err := session.Wait(); err != nil {
+		return fmt.Errorf("command failed: %w", err)
+	}
+	return nil
+}
+
 // TestConnection tests if SSH connection works
 func (c *Client) TestConnection() error {
 	if err := c.Connect(); err != nil {
@@ -212,7 +342,7 @@ func (c *Client) WriteFile(content, remotePath string) error {
 	}
 
 	// Use tee to write file with sudo
-	writeCmd := fmt.Sprintf("sudo tee %s > /dev/null", remotePath)
+	writeCmd := shell.Join("sudo", "tee", remotePath) + " > /dev/null"
 	
 	session, err := c.client.NewSession()
 	if err != nil {
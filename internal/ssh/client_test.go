@@ -1,6 +1,8 @@
 package ssh
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"strings"
 	"testing"
@@ -185,3 +187,19 @@ func TestCheckDocker_Integration(t *testing.T) {
 	}
 }
 
+type statusError struct{ status int }
+
+func (e *statusError) Error() string   { return "Process exited with status" }
+func (e *statusError) ExitStatus() int { return e.status }
+
+func TestExitStatus(t *testing.T) {
+	if status, ok := ExitStatus(fmt.Errorf("command failed: %w", &statusError{status: 2})); !ok || status != 2 {
+		t.Errorf("ExitStatus() = %d, %v, want 2, true", status, ok)
+	}
+	if _, ok := ExitStatus(errors.New("connection refused")); ok {
+		t.Error("ExitStatus() should be false for errors without an exit status")
+	}
+	if _, ok := ExitStatus(nil); ok {
+		t.Error("ExitStatus(nil) should be false")
+	}
+}
@@ -8,6 +8,8 @@ type SSHClient interface {
 	Close() error
 	Execute(cmd string) (string, error)
 	ExecuteWithOutput(cmd string, stdout, stderr io.Writer) error
+	ExecuteWithInput(cmd string, stdin io.Reader) (string, error)
+	ExecuteInteractive(cmd string, opts InteractiveOptions) error
 	TestConnection() error
 	CheckDocker() (string, error)
 	CheckPort(port int) (bool, error)
@@ -9,6 +9,8 @@ import (
 type MockClient struct {
 	ExecuteFunc             func(string) (string, error)
 	ExecuteWithOutputFunc   func(string, io.Writer, io.Writer) error
+	ExecuteWithInputFunc    func(string, io.Reader) (string, error)
+	ExecuteInteractiveFunc  func(string, InteractiveOptions) error
 	TestConnectionFunc      func() error
 	CheckDockerFunc         func() (string, error)
 	CheckPortFunc           func(int) (bool, error)
@@ -18,6 +20,7 @@ type MockClient struct {
 	CompareGitRepoFunc      func(string, string) (bool, error)
 	CopyFileFunc            func(string, string) error
 	CopyFileWithProgressFunc func(string, string, func(int64, int64)) error
+	WriteFileFunc           func(string, string) error
 	CloseFunc               func() error
 	ConnectFunc             func() error
 	Connected               bool
@@ -37,6 +40,20 @@ func (m *MockClient) ExecuteWithOutput(cmd string, stdout, stderr io.Writer) err
 	return nil
 }
 
+func (m *MockClient) ExecuteWithInput(cmd string, stdin io.Reader) (string, error) {
+	if m.ExecuteWithInputFunc != nil {
+		return m.ExecuteWithInputFunc(cmd, stdin)
+	}
+	return "", nil
+}
+
+func (m *MockClient) ExecuteInteractive(cmd string, opts InteractiveOptions) error {
+	if m.ExecuteInteractiveFunc != nil {
+		return m.ExecuteInteractiveFunc(cmd, opts)
+	}
+	return nil
+}
+
 func (m *MockClient) TestConnection() error {
 	if m.TestConnectionFunc != nil {
 		return m.TestConnectionFunc()
@@ -124,6 +141,9 @@ func (m *MockClient) GetStateFile(serviceName string) (map[string]interface{}, e
 }
 
 func (m *MockClient) WriteFile(content, remotePath string) error {
+	if m.WriteFileFunc != nil {
+		return m.WriteFileFunc(content, remotePath)
+	}
 	return nil
 }
 
@@ -2,6 +2,7 @@ package ssh
 
 import (
 	"bytes"
+	"io"
 	"strings"
 	"testing"
 )
@@ -45,6 +46,25 @@ func TestMockClient_ExecuteWithOutput(t *testing.T) {
 	}
 }
 
+func TestMockClient_ExecuteWithInput(t *testing.T) {
+	var received string
+	mock := &MockClient{
+		ExecuteWithInputFunc: func(cmd string, stdin io.Reader) (string, error) {
+			data, _ := io.ReadAll(stdin)
+			received = string(data)
+			return "ok", nil
+		},
+	}
+
+	output, err := mock.ExecuteWithInput("cat", strings.NewReader("secret"))
+	if err != nil {
+		t.Errorf("ExecuteWithInput() error = %v", err)
+	}
+	if output != "ok" || received != "secret" {
+		t.Errorf("ExecuteWithInput() output = %q, stdin = %q", output, received)
+	}
+}
+
 func TestMockClient_TestConnection(t *testing.T) {
 	mock := NewMockClient()
 	
@@ -5,6 +5,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+
+	"github.com/ekinertac/podlift/internal/shell"
 )
 
 // CopyFile copies a file to the remote server using SCP protocol
@@ -31,7 +33,7 @@ func (c *Client) CopyFile(localPath, remotePath string) error {
 	// Create remote directory if needed
 	remoteDir := filepath.Dir(remotePath)
 	if remoteDir != "." && remoteDir != "/" {
-		mkdirCmd := fmt.Sprintf("mkdir -p %s", remoteDir)
+		mkdirCmd := shell.Join("mkdir", "-p", remoteDir)
 		if _, err := c.Execute(mkdirCmd); err != nil {
 			return fmt.Errorf("failed to create remote directory: %w", err)
 		}
@@ -65,7 +67,7 @@ func (c *Client) CopyFile(localPath, remotePath string) error {
 	}()
 
 	// Run SCP command
-	scpCmd := fmt.Sprintf("scp -t %s", remotePath)
+	scpCmd := shell.Join("scp", "-t", remotePath)
 	if err := session.Run(scpCmd); err != nil {
 		return fmt.Errorf("SCP transfer failed: %w", err)
 	}
@@ -98,7 +100,7 @@ func (c *Client) CopyFileWithProgress(localPath, remotePath string, progressFn f
 	// Create remote directory
 	remoteDir := filepath.Dir(remotePath)
 	if remoteDir != "." && remoteDir != "/" {
-		mkdirCmd := fmt.Sprintf("mkdir -p %s", remoteDir)
+		mkdirCmd := shell.Join("mkdir", "-p", remoteDir)
 		c.Execute(mkdirCmd)
 	}
 
@@ -149,7 +151,7 @@ func (c *Client) CopyFileWithProgress(localPath, remotePath string, progressFn f
 	}()
 
 	// Run SCP
-	scpCmd := fmt.Sprintf("scp -t %s", remotePath)
+	scpCmd := shell.Join("scp", "-t", remotePath)
 	if err := session.Run(scpCmd); err != nil {
 		return fmt.Errorf("SCP transfer failed: %w", err)
 	}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+
+	"github.com/ekinertac/podlift/internal/shell"
 )
 
 // ServiceInfo represents information about a deployed service
@@ -17,10 +19,8 @@ type ServiceInfo struct {
 // CheckExistingService checks if a service is already deployed on the server
 func (c *Client) CheckExistingService(serviceName string) (bool, *ServiceInfo, error) {
 	// Query Docker for containers with podlift.service label
-	cmd := fmt.Sprintf(
-		`docker ps -a --filter "label=podlift.service=%s" --format "{{.Names}},{{.Label \"podlift.version\"}},{{.Label \"podlift.deployed_at\"}}"`,
-		serviceName,
-	)
+	cmd := shell.Join("docker", "ps", "-a", "--filter", "label=podlift.service="+serviceName,
+		"--format", `{{.Names}},{{.Label "podlift.version"}},{{.Label "podlift.deployed_at"}}`)
 
 	output, err := c.Execute(cmd)
 	if err != nil {
@@ -148,7 +148,7 @@ func IsConflictError(err error) bool {
 func (c *Client) GetStateFile(serviceName string) (map[string]interface{}, error) {
 	statePath := fmt.Sprintf("/opt/%s/.podlift/state.json", serviceName)
 	
-	cmd := fmt.Sprintf("cat %s 2>/dev/null || echo '{}'", statePath)
+	cmd := shell.Join("cat", statePath) + " 2>/dev/null || echo '{}'"
 	output, err := c.Execute(cmd)
 	if err != nil {
 		return nil, err
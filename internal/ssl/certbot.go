@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/ekinertac/podlift/internal/shell"
 	"github.com/ekinertac/podlift/internal/ssh"
 	"github.com/ekinertac/podlift/internal/ui"
 )
@@ -63,10 +64,8 @@ func (m *CertbotManager) ObtainCertificate(domain, email string) error {
 	fmt.Println(ui.Info(fmt.Sprintf("Obtaining SSL certificate for %s...", domain)))
 
 	// Use webroot method (nginx must be running)
-	cmd := fmt.Sprintf(
-		"sudo certbot certonly --webroot -w /var/www/html -d %s --email %s --agree-tos --non-interactive",
-		domain, email,
-	)
+	cmd := shell.Join("sudo", "certbot", "certonly", "--webroot", "-w", "/var/www/html",
+		"-d", domain, "--email", email, "--agree-tos", "--non-interactive")
 
 	output, err := m.client.Execute(cmd)
 	if err != nil {
@@ -90,7 +89,7 @@ func (m *CertbotManager) GetKeyPath(domain string) string {
 // CheckCertificate checks if certificate exists for domain
 func (m *CertbotManager) CheckCertificate(domain string) (bool, error) {
 	certPath := m.GetCertificatePath(domain)
-	_, err := m.client.Execute(fmt.Sprintf("sudo test -f %s", certPath))
+	_, err := m.client.Execute(shell.Join("sudo", "test", "-f", certPath))
 	return err == nil, nil
 }
 
@@ -109,7 +108,7 @@ func (m *CertbotManager) RenewCertificates() error {
 
 // GetCertificateInfo gets information about a certificate
 func (m *CertbotManager) GetCertificateInfo(domain string) (map[string]string, error) {
-	cmd := fmt.Sprintf("sudo certbot certificates -d %s 2>&1", domain)
+	cmd := shell.Join("sudo", "certbot", "certificates", "-d", domain) + " 2>&1"
 	output, err := m.client.Execute(cmd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get certificate info: %w", err)
@@ -1,6 +1,8 @@
 package ui
 
 import (
+	"fmt"
+
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -92,8 +94,19 @@ func Info(msg string) string {
 	return StyleInfo.Render(SymbolDot) + " " + msg
 }
 
+// environment is the active deployment environment shown in titles
+var environment string
+
+// SetEnvironment sets the environment shown in every title (empty to hide)
+func SetEnvironment(env string) {
+	environment = env
+}
+
 // Title formats a section title
 func Title(msg string) string {
+	if environment != "" {
+		msg = fmt.Sprintf("%s [%s]", msg, environment)
+	}
 	return StyleTitle.Render(msg)
 }
 
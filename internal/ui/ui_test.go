@@ -27,6 +27,21 @@ func TestErrorMessage(t *testing.T) {
 	}
 }
 
+func TestTitle_ShowsEnvironment(t *testing.T) {
+	SetEnvironment("staging")
+	defer SetEnvironment("")
+
+	msg := Title("Deploying myapp")
+	if !strings.Contains(msg, "[staging]") {
+		t.Errorf("Title() should contain environment, got: %v", msg)
+	}
+
+	SetEnvironment("")
+	if strings.Contains(Title("Deploying myapp"), "[") {
+		t.Error("Title() should not show an environment when none is set")
+	}
+}
+
 func TestStepList(t *testing.T) {
 	steps := NewStepList([]string{"Step 1", "Step 2", "Step 3"})
 